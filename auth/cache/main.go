@@ -5,6 +5,7 @@ type GroupType string
 const (
 	GroupTypeAuth GroupType = "auth"
 	GroupTypeFUP  GroupType = "fup"
+	GroupTypeRole GroupType = "role"
 )
 
 func getPrefix(prefix string, groupPrefix GroupType) string {
@@ -1,6 +1,14 @@
 package cache
 
-import "testing"
+import (
+	"github.com/google/uuid"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
 
 func Test_getPrefix(t *testing.T) {
 	type args struct {
@@ -26,3 +34,195 @@ func Test_getPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryCacheDriver_TakeToken(t *testing.T) {
+	d := NewMemoryCacheDriver()
+	d.Init("prefix:", time.Hour)
+	limit := contract.TokenBucketLimit{Rate: 1, Burst: 20}
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	var allowedCount int
+	var lock sync.Mutex
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, allowed, err := d.TakeToken("client", limit, now, 1)
+			if nil != err {
+				t.Errorf("TakeToken() error = %v", err)
+			}
+			if allowed {
+				lock.Lock()
+				allowedCount++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if 20 != allowedCount {
+		t.Errorf("TakeToken() allowed %d requests, want %d", allowedCount, 20)
+	}
+
+	entry, allowed, _ := d.TakeToken("client", limit, now.Add(time.Second), 1)
+	if !allowed || 0 != entry.Tokens {
+		t.Errorf("TakeToken() = %v, %v, want a refilled token", entry, allowed)
+	}
+	// buckets are independent
+	if _, allowed, _ := d.TakeToken("other-client", limit, now, 1); !allowed {
+		t.Errorf("TakeToken() = %v, want %v", allowed, true)
+	}
+}
+
+func TestMemoryCacheDriver_IncrementFUPEntry(t *testing.T) {
+	d := NewMemoryCacheDriver()
+	d.Init("prefix:", time.Hour)
+	now := time.Now()
+	for _, algorithm := range []constants.FUPAlgorithm{constants.FUPAlgorithmFixedWindow, constants.FUPAlgorithmSlidingWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			hammer(t, 20, 10, func() *contract.AuthError {
+				_, err := d.IncrementFUPEntry(string(algorithm), algorithm, now, 1)
+				return err
+			})
+			entry, err := d.IncrementFUPEntry(string(algorithm), algorithm, now, 1)
+			if nil != err {
+				t.Fatalf("IncrementFUPEntry() error = %v", err)
+			}
+			if got := entry.Used[constants.PeriodDaily]; 201 != got {
+				t.Errorf("IncrementFUPEntry() used = %d, want %d", got, 201)
+			}
+			// the entry is a copy, further increments don't change it
+			_, _ = d.IncrementFUPEntry(string(algorithm), algorithm, now, 1)
+			if got := entry.Used[constants.PeriodDaily]; 201 != got {
+				t.Errorf("IncrementFUPEntry() used = %d, want %d", got, 201)
+			}
+		})
+	}
+}
+
+func TestMemoryCacheDriver_RefundFUPEntry(t *testing.T) {
+	d := NewMemoryCacheDriver()
+	d.Init("prefix:", time.Hour)
+	now := time.Now()
+	if err := d.RefundFUPEntry("client", constants.FUPAlgorithmSlidingWindow, now, 1); nil != err {
+		t.Errorf("RefundFUPEntry() error = %v", err)
+	}
+	_, _ = d.IncrementFUPEntry("client", constants.FUPAlgorithmSlidingWindow, now, 10)
+	hammer(t, 10, 2, func() *contract.AuthError {
+		return d.RefundFUPEntry("client", constants.FUPAlgorithmSlidingWindow, now, 1)
+	})
+	entry, _ := d.GetFUPEntry("client")
+	if got := entry.GetUsed(constants.PeriodDaily); 0 != got {
+		t.Errorf("RefundFUPEntry() used = %d, want %d", got, 0)
+	}
+}
+
+func TestMemoryCacheDriver_QueryFUPEntry(t *testing.T) {
+	d := NewMemoryCacheDriver()
+	d.Init("prefix:", time.Hour)
+	now := time.Now()
+	entry, err := d.QueryFUPEntry("client", constants.FUPAlgorithmFixedWindow, now)
+	if nil != err || 0 != entry.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, now) {
+		t.Errorf("QueryFUPEntry() = %v, %v, want no usage", entry, err)
+	}
+	_, _ = d.IncrementFUPEntry("client", constants.FUPAlgorithmFixedWindow, now, 3)
+	for i := 0; i < 2; i++ {
+		// the query doesn't count a request
+		entry, _ = d.QueryFUPEntry("client", constants.FUPAlgorithmFixedWindow, now)
+		if got := entry.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, now); 3 != got {
+			t.Errorf("QueryFUPEntry() used = %d, want %d", got, 3)
+		}
+	}
+}
+
+func TestMemoryCacheDriver_AcquireSlot(t *testing.T) {
+	d := NewMemoryCacheDriver()
+	d.Init("prefix:", time.Hour)
+	now := time.Now()
+	var acquiredCount int
+	var lock sync.Mutex
+	hammer(t, 10, 1, func() *contract.AuthError {
+		_, acquired, err := d.AcquireSlot("client", uuid.NewString(), 5, time.Minute, now)
+		if acquired {
+			lock.Lock()
+			acquiredCount++
+			lock.Unlock()
+		}
+		return err
+	})
+	if 5 != acquiredCount {
+		t.Errorf("AcquireSlot() acquired %d slots, want %d", acquiredCount, 5)
+	}
+
+	// the released slot can be acquired again
+	_, _, _ = d.AcquireSlot("other-client", "lease", 1, time.Minute, now)
+	if _, acquired, _ := d.AcquireSlot("other-client", "other-lease", 1, time.Minute, now); acquired {
+		t.Errorf("AcquireSlot() = %v, want %v", acquired, false)
+	}
+	_ = d.ReleaseSlot("other-client", "lease")
+	if inFlight, acquired, _ := d.AcquireSlot("other-client", "other-lease", 1, time.Minute, now); !acquired || 1 != inFlight {
+		t.Errorf("AcquireSlot() = %v, %v, want %v, %v", inFlight, acquired, 1, true)
+	}
+
+	// the slots of expired leases are freed
+	if inFlight, acquired, _ := d.AcquireSlot("client", "lease", 5, time.Minute, now.Add(time.Minute)); !acquired || 1 != inFlight {
+		t.Errorf("AcquireSlot() = %v, %v, want %v, %v", inFlight, acquired, 1, true)
+	}
+}
+
+func TestMemoryCacheDriver_RoleScopes(t *testing.T) {
+	d := NewMemoryCacheDriver()
+	d.Init("prefix:", time.Hour)
+	scopes := &contract.RoleScopes{AccessScope: &contract.AccessScope{"/orders": true}}
+	// the role scopes are resolved by concurrent requests
+	hammer(t, 20, 50, func() *contract.AuthError {
+		if err := d.SetRoleScopes("roles", scopes); nil != err {
+			return err
+		}
+		_, err := d.GetRoleScopes("roles")
+		return err
+	})
+	cached, err := d.GetRoleScopes("roles")
+	if nil != err {
+		t.Fatalf("GetRoleScopes() error = %v", err)
+	}
+	if nil == cached || (*cached.AccessScope)["/orders"] != true {
+		t.Errorf("GetRoleScopes() = %v, want %v", cached, scopes)
+	}
+}
+
+func TestMemoryCacheDriver_FUPAdmin(t *testing.T) {
+	d := NewMemoryCacheDriver()
+	d.Init("prefix:", time.Hour)
+	now := time.Now()
+	_, _ = d.IncrementFUPEntry("key_client_*", constants.FUPAlgorithmFixedWindow, now, 3)
+	_, _ = d.IncrementFUPEntry("key_client_-orders", constants.FUPAlgorithmFixedWindow, now, 2)
+	_, _ = d.IncrementFUPEntry("key_other-client_*", constants.FUPAlgorithmFixedWindow, now, 1)
+
+	keys, err := d.ListFUPKeys("key_client_")
+	if nil != err || !reflect.DeepEqual([]string{"key_client_*", "key_client_-orders"}, keys) {
+		t.Errorf("ListFUPKeys() = %v, %v, want %v", keys, err, []string{"key_client_*", "key_client_-orders"})
+	}
+
+	_ = d.AdjustFUPEntry("key_client_*", constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, now, -1)
+	entry, _ := d.QueryFUPEntry("key_client_*", constants.FUPAlgorithmFixedWindow, now)
+	if got := entry.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, now); 2 != got {
+		t.Errorf("AdjustFUPEntry() used = %d, want %d", got, 2)
+	}
+	// the usage is never negative
+	_ = d.AdjustFUPEntry("key_client_*", constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, now, -10)
+	entry, _ = d.QueryFUPEntry("key_client_*", constants.FUPAlgorithmFixedWindow, now)
+	if got := entry.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, now); 0 != got {
+		t.Errorf("AdjustFUPEntry() used = %d, want %d", got, 0)
+	}
+
+	_ = d.ResetFUPEntry("key_client_-orders", constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, now)
+	entry, _ = d.QueryFUPEntry("key_client_-orders", constants.FUPAlgorithmFixedWindow, now)
+	if got := entry.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, now); 0 != got {
+		t.Errorf("ResetFUPEntry() hourly used = %d, want %d", got, 0)
+	}
+	// the other periods are kept
+	if got := entry.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, now); 2 != got {
+		t.Errorf("ResetFUPEntry() daily used = %d, want %d", got, 2)
+	}
+}
@@ -3,6 +3,10 @@ package cache
 import (
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,6 +21,13 @@ type MemoryCacheDriver struct {
 	apiClientMemory map[string]MemoryCacheEntry[contract.ApiClientInterface]
 	apiUserMemory   map[string]MemoryCacheEntry[contract.ApiUserInterface]
 	fupMemory       map[string]MemoryCacheEntry[contract.FUPCacheEntry]
+	fupLock         sync.Mutex
+	roleMemory      map[string]MemoryCacheEntry[contract.RoleScopes]
+	roleLock        sync.Mutex
+	bucketMemory    map[string]contract.TokenBucketEntry
+	bucketLock      sync.Mutex
+	semaphoreMemory map[string]map[string]time.Time
+	semaphoreLock   sync.Mutex
 	prefix          string
 	ttl             time.Duration
 }
@@ -117,6 +128,8 @@ func (d *MemoryCacheDriver) SetApiUserByToken(token string, user contract.ApiUse
 }
 
 func (d *MemoryCacheDriver) GetFUPEntry(key string) (*contract.FUPCacheEntry, *contract.AuthError) {
+	d.fupLock.Lock()
+	defer d.fupLock.Unlock()
 	entryKey := d.getPrefix(GroupTypeFUP) + key
 	if hit, ok := d.fupMemory[entryKey]; ok {
 		return &hit.Value, nil
@@ -129,27 +142,189 @@ func (d *MemoryCacheDriver) GetFUPEntry(key string) (*contract.FUPCacheEntry, *c
 			constants.PeriodDaily:    0,
 			constants.PeriodWeekly:   0,
 			constants.PeriodMonthly:  0,
+			constants.PeriodYearly:   0,
 		},
 	}, nil
 }
 
 func (d *MemoryCacheDriver) SetFUPEntry(key string, entry *contract.FUPCacheEntry) *contract.AuthError {
+	d.fupLock.Lock()
+	defer d.fupLock.Unlock()
 	d.fupMemory[d.getPrefix(GroupTypeFUP)+key] = MemoryCacheEntry[contract.FUPCacheEntry]{
 		Value: *entry,
 	}
 	return nil
 }
 
+func (d *MemoryCacheDriver) IncrementFUPEntry(key string, algorithm constants.FUPAlgorithm, now time.Time, cost int) (*contract.FUPCacheEntry, *contract.AuthError) {
+	d.fupLock.Lock()
+	defer d.fupLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	entry := d.fupMemory[entryKey].Value
+	entry.IncrementWith(algorithm, now, cost)
+	d.fupMemory[entryKey] = MemoryCacheEntry[contract.FUPCacheEntry]{
+		Value: entry,
+	}
+	// the counters keep changing in the memory, so a copy is returned
+	return &contract.FUPCacheEntry{
+		UpdatedAt: entry.UpdatedAt,
+		Used:      maps.Clone(entry.Used),
+		Previous:  maps.Clone(entry.Previous),
+	}, nil
+}
+
+func (d *MemoryCacheDriver) QueryFUPEntry(key string, algorithm constants.FUPAlgorithm, now time.Time) (*contract.FUPCacheEntry, *contract.AuthError) {
+	d.fupLock.Lock()
+	defer d.fupLock.Unlock()
+	entry := d.fupMemory[d.getPrefix(GroupTypeFUP)+key].Value
+	return &contract.FUPCacheEntry{
+		UpdatedAt: entry.UpdatedAt,
+		Used:      maps.Clone(entry.Used),
+		Previous:  maps.Clone(entry.Previous),
+	}, nil
+}
+
+func (d *MemoryCacheDriver) ListFUPKeys(prefix string) ([]string, *contract.AuthError) {
+	d.fupLock.Lock()
+	defer d.fupLock.Unlock()
+	groupPrefix := d.getPrefix(GroupTypeFUP)
+	keys := make([]string, 0)
+	for entryKey := range d.fupMemory {
+		if strings.HasPrefix(entryKey, groupPrefix+prefix) {
+			keys = append(keys, entryKey[len(groupPrefix):])
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (d *MemoryCacheDriver) ResetFUPEntry(key string, algorithm constants.FUPAlgorithm, period constants.Period, now time.Time) *contract.AuthError {
+	d.fupLock.Lock()
+	defer d.fupLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	cacheEntry, ok := d.fupMemory[entryKey]
+	if !ok {
+		return nil
+	}
+	cacheEntry.Value.Reset(algorithm, period, now)
+	d.fupMemory[entryKey] = cacheEntry
+	return nil
+}
+
+func (d *MemoryCacheDriver) AdjustFUPEntry(key string, algorithm constants.FUPAlgorithm, period constants.Period, now time.Time, delta int) *contract.AuthError {
+	d.fupLock.Lock()
+	defer d.fupLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	cacheEntry := d.fupMemory[entryKey]
+	cacheEntry.Value.Adjust(algorithm, period, now, delta)
+	d.fupMemory[entryKey] = cacheEntry
+	return nil
+}
+
+func (d *MemoryCacheDriver) RefundFUPEntry(key string, algorithm constants.FUPAlgorithm, reservedAt time.Time, cost int) *contract.AuthError {
+	d.fupLock.Lock()
+	defer d.fupLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	cacheEntry, ok := d.fupMemory[entryKey]
+	if !ok {
+		return nil
+	}
+	cacheEntry.Value.Refund(algorithm, reservedAt, cost)
+	d.fupMemory[entryKey] = cacheEntry
+	return nil
+}
+
+func (d *MemoryCacheDriver) TakeToken(key string, limit contract.TokenBucketLimit, now time.Time, cost int) (*contract.TokenBucketEntry, bool, *contract.AuthError) {
+	d.bucketLock.Lock()
+	defer d.bucketLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	entry := d.bucketMemory[entryKey]
+	allowed := entry.Take(limit, now, cost)
+	d.bucketMemory[entryKey] = entry
+	return &entry, allowed, nil
+}
+
+func (d *MemoryCacheDriver) ReturnTokens(key string, limit contract.TokenBucketLimit, tokens int) *contract.AuthError {
+	d.bucketLock.Lock()
+	defer d.bucketLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	entry, ok := d.bucketMemory[entryKey]
+	if !ok {
+		return nil
+	}
+	entry.Return(limit, tokens)
+	d.bucketMemory[entryKey] = entry
+	return nil
+}
+
+func (d *MemoryCacheDriver) AcquireSlot(key string, leaseId string, limit int, lease time.Duration, now time.Time) (int, bool, *contract.AuthError) {
+	d.semaphoreLock.Lock()
+	defer d.semaphoreLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	leases, ok := d.semaphoreMemory[entryKey]
+	if !ok {
+		leases = make(map[string]time.Time)
+		d.semaphoreMemory[entryKey] = leases
+	}
+	for id, expireAt := range leases {
+		if !expireAt.After(now) {
+			delete(leases, id)
+		}
+	}
+	if len(leases) >= limit {
+		return len(leases), false, nil
+	}
+	leases[leaseId] = now.Add(lease)
+	return len(leases), true, nil
+}
+
+func (d *MemoryCacheDriver) ReleaseSlot(key string, leaseId string) *contract.AuthError {
+	d.semaphoreLock.Lock()
+	defer d.semaphoreLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	delete(d.semaphoreMemory[entryKey], leaseId)
+	if 0 == len(d.semaphoreMemory[entryKey]) {
+		delete(d.semaphoreMemory, entryKey)
+	}
+	return nil
+}
+
 func (d *MemoryCacheDriver) InvalidateToken(token string) *contract.AuthError {
 	key := d.getPrefix(GroupTypeAuth) + token
 	delete(d.apiUserMemory, key)
 	return nil
 }
 
+func (d *MemoryCacheDriver) GetRoleScopes(key string) (*contract.RoleScopes, *contract.AuthError) {
+	d.roleLock.Lock()
+	defer d.roleLock.Unlock()
+	entryKey := d.getPrefix(GroupTypeRole) + key
+	if hit, ok := d.roleMemory[entryKey]; ok {
+		if hit.ExpireAt.After(time.Now()) {
+			return &hit.Value, nil
+		}
+		delete(d.roleMemory, entryKey)
+	}
+	return nil, nil
+}
+
+func (d *MemoryCacheDriver) SetRoleScopes(key string, scopes *contract.RoleScopes) *contract.AuthError {
+	d.roleLock.Lock()
+	defer d.roleLock.Unlock()
+	d.roleMemory[d.getPrefix(GroupTypeRole)+key] = MemoryCacheEntry[contract.RoleScopes]{
+		Value:    *scopes,
+		ExpireAt: time.Now().Add(d.ttl),
+	}
+	return nil
+}
+
 func NewMemoryCacheDriver() *MemoryCacheDriver {
 	return &MemoryCacheDriver{
 		apiClientMemory: make(map[string]MemoryCacheEntry[contract.ApiClientInterface]),
 		apiUserMemory:   make(map[string]MemoryCacheEntry[contract.ApiUserInterface]),
 		fupMemory:       make(map[string]MemoryCacheEntry[contract.FUPCacheEntry]),
+		roleMemory:      make(map[string]MemoryCacheEntry[contract.RoleScopes]),
+		bucketMemory:    make(map[string]contract.TokenBucketEntry),
+		semaphoreMemory: make(map[string]map[string]time.Time),
 	}
 }
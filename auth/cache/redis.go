@@ -3,16 +3,100 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/redis/go-redis/v9"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
 	"github.com/wernerdweight/api-auth-go/v2/auth/marshaller"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// globEscaper escapes the special characters of the SCAN patterns
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// takeTokenScript refills the token bucket and takes the cost of the request atomically (KEYS[1]: bucket key, ARGV: rate, burst, now in microseconds, cost)
+var takeTokenScript = redis.NewScript(`
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local tokens = tonumber(state[1])
+local updated = ARGV[3]
+local cost = tonumber(ARGV[4])
+if nil == tokens then
+	tokens = burst
+elseif now > tonumber(state[2]) then
+	tokens = math.min(burst, tokens + (now - tonumber(state[2])) / 1000000 * rate)
+else
+	updated = state[2]
+end
+local allowed = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+end
+tokens = string.format("%.6f", tokens)
+redis.call("HSET", KEYS[1], "tokens", tokens, "updated", updated)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tokens, updated}
+`)
+
+// returnTokensScript puts the tokens back into the token bucket if it still exists (KEYS[1]: bucket key, ARGV: burst, tokens)
+var returnTokensScript = redis.NewScript(`
+local tokens = tonumber(redis.call("HGET", KEYS[1], "tokens"))
+if nil ~= tokens then
+	tokens = math.min(tonumber(ARGV[1]), tokens + tonumber(ARGV[2]))
+	redis.call("HSET", KEYS[1], "tokens", string.format("%.6f", tokens))
+end
+return 0
+`)
+
+// refundScript takes back the cost of a request from the counters that still exist (KEYS: counter keys, ARGV: cost)
+var refundScript = redis.NewScript(`
+local cost = tonumber(ARGV[1])
+for _, key in ipairs(KEYS) do
+	local used = tonumber(redis.call("GET", key))
+	if nil ~= used then
+		redis.call("SET", key, math.max(used - cost, 0), "KEEPTTL")
+	end
+end
+return 0
+`)
+
+// adjustScript adds a delta to the counter (KEYS[1]) without letting it drop below zero and sets its expiration
+// (ARGV: delta and the expiration in milliseconds)
+var adjustScript = redis.NewScript(`
+local used = redis.call("INCRBY", KEYS[1], ARGV[1])
+if used < 0 then
+	redis.call("SET", KEYS[1], 0)
+end
+redis.call("PEXPIREAT", KEYS[1], ARGV[2])
+return 0
+`)
+
+// acquireSlotScript frees the slots of expired leases and acquires a slot atomically
+// (KEYS[1]: semaphore key - a sorted set of lease ids scored by their expiration; ARGV: lease id, limit, now and lease in milliseconds)
+var acquireSlotScript = redis.NewScript(`
+local now = tonumber(ARGV[3])
+local lease = tonumber(ARGV[4])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+local inFlight = redis.call("ZCARD", KEYS[1])
+if inFlight >= tonumber(ARGV[2]) then
+	return {0, inFlight}
+end
+redis.call("ZADD", KEYS[1], now + lease, ARGV[1])
+redis.call("PEXPIRE", KEYS[1], lease)
+return {1, inFlight + 1}
+`)
+
 type RedisCacheDriver struct {
 	dsn          string
 	client       *redis.Client
+	clientLock   sync.Mutex
 	prefix       string
 	ttl          time.Duration
 	newApiClient func() contract.ApiClientInterface
@@ -20,6 +104,9 @@ type RedisCacheDriver struct {
 }
 
 func (d *RedisCacheDriver) getClient() *redis.Client {
+	// the client is shared by concurrent requests
+	d.clientLock.Lock()
+	defer d.clientLock.Unlock()
 	if d.client == nil {
 		opts, err := redis.ParseURL(d.dsn)
 		if nil != err {
@@ -196,6 +283,7 @@ func (d *RedisCacheDriver) GetFUPEntry(key string) (*contract.FUPCacheEntry, *co
 					constants.PeriodDaily:    0,
 					constants.PeriodWeekly:   0,
 					constants.PeriodMonthly:  0,
+					constants.PeriodYearly:   0,
 				},
 			}, nil
 		}
@@ -222,6 +310,227 @@ func (d *RedisCacheDriver) SetFUPEntry(key string, entry *contract.FUPCacheEntry
 	return nil
 }
 
+func (d *RedisCacheDriver) getFUPCounterKey(key string, period constants.Period, window string) string {
+	return fmt.Sprintf("%s%s_%s_%s", d.getPrefix(GroupTypeFUP), key, period, window)
+}
+
+// IncrementFUPEntry counts the request in a counter per period and window (INCRBY in a transaction, so no request is lost);
+// the counters expire when they are not needed anymore
+func (d *RedisCacheDriver) IncrementFUPEntry(key string, algorithm constants.FUPAlgorithm, now time.Time, cost int) (*contract.FUPCacheEntry, *contract.AuthError) {
+	ctx := context.Background()
+	usedCommands := make(map[constants.Period]*redis.IntCmd)
+	previousCommands := make(map[constants.Period]*redis.StringCmd)
+	_, err := d.getClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, period := range constants.FUPScopePeriods {
+			counterKeys, expireAt := d.getFUPWindowKeys(key, algorithm, period, now)
+			usedCommands[period] = pipe.IncrBy(ctx, counterKeys[0], int64(cost))
+			// milliseconds, so that the counter doesn't expire during the last second of the window already
+			pipe.PExpireAt(ctx, counterKeys[0], expireAt)
+			if constants.FUPAlgorithmSlidingWindow == algorithm {
+				previousCommands[period] = pipe.Get(ctx, counterKeys[1])
+			}
+		}
+		return nil
+	})
+	// the previous window counter doesn't have to exist
+	if nil != err && redis.Nil != err {
+		return nil, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	entry := &contract.FUPCacheEntry{
+		UpdatedAt: now,
+		Used:      make(map[constants.Period]int),
+		Previous:  make(map[constants.Period]int),
+	}
+	for period, command := range usedCommands {
+		entry.Used[period] = int(command.Val())
+	}
+	for period, command := range previousCommands {
+		previous, _ := command.Int()
+		entry.Previous[period] = previous
+	}
+	return entry, nil
+}
+
+// QueryFUPEntry reads the counters of the windows containing now (the counters are not created if they don't exist)
+func (d *RedisCacheDriver) QueryFUPEntry(key string, algorithm constants.FUPAlgorithm, now time.Time) (*contract.FUPCacheEntry, *contract.AuthError) {
+	ctx := context.Background()
+	usedCommands := make(map[constants.Period]*redis.StringCmd)
+	previousCommands := make(map[constants.Period]*redis.StringCmd)
+	_, err := d.getClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, period := range constants.FUPScopePeriods {
+			if constants.FUPAlgorithmSlidingWindow == algorithm {
+				counterKeys, _ := d.getFUPWindowKeys(key, algorithm, period, now)
+				usedCommands[period] = pipe.Get(ctx, counterKeys[0])
+				previousCommands[period] = pipe.Get(ctx, counterKeys[1])
+				continue
+			}
+			usedCommands[period] = pipe.Get(ctx, d.getFUPCounterKey(key, period, period.GetFormatToCompare(now)))
+		}
+		return nil
+	})
+	// the counters don't have to exist
+	if nil != err && redis.Nil != err {
+		return nil, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	entry := &contract.FUPCacheEntry{
+		UpdatedAt: now,
+		Used:      make(map[constants.Period]int),
+		Previous:  make(map[constants.Period]int),
+	}
+	for period, command := range usedCommands {
+		used, _ := command.Int()
+		entry.Used[period] = used
+	}
+	for period, command := range previousCommands {
+		previous, _ := command.Int()
+		entry.Previous[period] = previous
+	}
+	return entry, nil
+}
+
+// RefundFUPEntry takes back the cost of a request from the counters of the windows the request was counted in
+// (the counters that expired already are not refunded)
+func (d *RedisCacheDriver) RefundFUPEntry(key string, algorithm constants.FUPAlgorithm, reservedAt time.Time, cost int) *contract.AuthError {
+	counterKeys := make([]string, 0, len(constants.FUPScopePeriods))
+	for _, period := range constants.FUPScopePeriods {
+		if constants.FUPAlgorithmSlidingWindow == algorithm {
+			windowKeys, _ := d.getFUPWindowKeys(key, algorithm, period, reservedAt)
+			counterKeys = append(counterKeys, windowKeys[0])
+			continue
+		}
+		counterKeys = append(counterKeys, d.getFUPCounterKey(key, period, period.GetFormatToCompare(reservedAt)))
+	}
+	err := refundScript.Run(context.Background(), d.getClient(), counterKeys, cost).Err()
+	if nil != err {
+		return contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return nil
+}
+
+// getFUPWindowKeys returns the counter keys of the window of the period at now (and of the previous window for the sliding window algorithm)
+// and the time the counter of the window expires at (the beginning of the next window, see IncrementFUPEntry); the sliding windows are the calendar windows
+// in the location of now and the counter is kept until the end of the next window (where it is the previous one)
+func (d *RedisCacheDriver) getFUPWindowKeys(key string, algorithm constants.FUPAlgorithm, period constants.Period, now time.Time) ([]string, time.Time) {
+	if constants.FUPAlgorithmSlidingWindow == algorithm {
+		window := period.GetWindowStart(now)
+		return []string{
+			d.getFUPCounterKey(key, period, strconv.FormatInt(window.Unix(), 10)),
+			d.getFUPCounterKey(key, period, strconv.FormatInt(period.GetPreviousWindowStart(now).Unix(), 10)),
+		}, period.GetNextWindowStart(period.GetNextWindowStart(now))
+	}
+	return []string{d.getFUPCounterKey(key, period, period.GetFormatToCompare(now))}, period.GetNextWindowStart(now)
+}
+
+// ListFUPKeys scans the counters of the FUP entries starting with prefix (the keys are not listed atomically)
+func (d *RedisCacheDriver) ListFUPKeys(prefix string) ([]string, *contract.AuthError) {
+	groupPrefix := d.getPrefix(GroupTypeFUP)
+	pattern := globEscaper.Replace(groupPrefix+prefix) + "*"
+	unique := make(map[string]bool)
+	iterator := d.getClient().Scan(context.Background(), 0, pattern, 100).Iterator()
+	for iterator.Next(context.Background()) {
+		counterKey := iterator.Val()[len(groupPrefix):]
+		// the counter keys end with `_<period>_<window>` (the token bucket and concurrency keys don't)
+		end := -1
+		for _, period := range constants.FUPScopePeriods {
+			end = max(end, strings.LastIndex(counterKey, fmt.Sprintf("_%s_", period)))
+		}
+		if end >= len(prefix) {
+			unique[counterKey[:end]] = true
+		}
+	}
+	if err := iterator.Err(); nil != err {
+		return nil, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	keys := make([]string, 0, len(unique))
+	for key := range unique {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (d *RedisCacheDriver) ResetFUPEntry(key string, algorithm constants.FUPAlgorithm, period constants.Period, now time.Time) *contract.AuthError {
+	counterKeys, _ := d.getFUPWindowKeys(key, algorithm, period, now)
+	err := d.getClient().Del(context.Background(), counterKeys...).Err()
+	if nil != err {
+		return contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return nil
+}
+
+func (d *RedisCacheDriver) AdjustFUPEntry(key string, algorithm constants.FUPAlgorithm, period constants.Period, now time.Time, delta int) *contract.AuthError {
+	counterKeys, expireAt := d.getFUPWindowKeys(key, algorithm, period, now)
+	err := adjustScript.Run(context.Background(), d.getClient(), counterKeys[:1], delta, expireAt.UnixMilli()).Err()
+	if nil != err {
+		return contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return nil
+}
+
+// AcquireSlot acquires a slot of the distributed semaphore (the slots of crashed instances are freed when their leases expire)
+func (d *RedisCacheDriver) AcquireSlot(key string, leaseId string, limit int, lease time.Duration, now time.Time) (int, bool, *contract.AuthError) {
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	result, err := acquireSlotScript.Run(
+		context.Background(),
+		d.getClient(),
+		[]string{entryKey},
+		leaseId,
+		limit,
+		now.UnixMilli(),
+		lease.Milliseconds(),
+	).Int64Slice()
+	if nil != err {
+		return 0, false, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return int(result[1]), 1 == result[0], nil
+}
+
+func (d *RedisCacheDriver) ReleaseSlot(key string, leaseId string) *contract.AuthError {
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	err := d.getClient().ZRem(context.Background(), entryKey, leaseId).Err()
+	if nil != err {
+		return contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return nil
+}
+
+func (d *RedisCacheDriver) TakeToken(key string, limit contract.TokenBucketLimit, now time.Time, cost int) (*contract.TokenBucketEntry, bool, *contract.AuthError) {
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	result, err := takeTokenScript.Run(
+		context.Background(),
+		d.getClient(),
+		[]string{entryKey},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64),
+		limit.Burst,
+		now.UnixMicro(),
+		cost,
+	).Slice()
+	if nil != err {
+		return nil, false, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	allowed, _ := result[0].(int64)
+	tokensValue, _ := result[1].(string)
+	updatedValue, _ := result[2].(string)
+	tokens, err := strconv.ParseFloat(tokensValue, 64)
+	if nil != err {
+		return nil, false, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	updated, err := strconv.ParseInt(updatedValue, 10, 64)
+	if nil != err {
+		return nil, false, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return &contract.TokenBucketEntry{Tokens: tokens, UpdatedAt: time.UnixMicro(updated)}, 1 == allowed, nil
+}
+
+func (d *RedisCacheDriver) ReturnTokens(key string, limit contract.TokenBucketLimit, tokens int) *contract.AuthError {
+	entryKey := d.getPrefix(GroupTypeFUP) + key
+	err := returnTokensScript.Run(context.Background(), d.getClient(), []string{entryKey}, limit.Burst, tokens).Err()
+	if nil != err {
+		return contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return nil
+}
+
 func (d *RedisCacheDriver) InvalidateToken(token string) *contract.AuthError {
 	key := d.getPrefix(GroupTypeAuth) + token
 	err := d.getClient().Del(context.Background(), key).Err()
@@ -231,6 +540,36 @@ func (d *RedisCacheDriver) InvalidateToken(token string) *contract.AuthError {
 	return nil
 }
 
+func (d *RedisCacheDriver) GetRoleScopes(key string) (*contract.RoleScopes, *contract.AuthError) {
+	entryKey := d.getPrefix(GroupTypeRole) + key
+	value, err := d.getClient().Get(context.Background(), entryKey).Result()
+	if nil != err {
+		if redis.Nil == err {
+			return nil, nil
+		}
+		return nil, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	scopes := &contract.RoleScopes{}
+	err = json.Unmarshal([]byte(value), scopes)
+	if nil != err {
+		return nil, contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return scopes, nil
+}
+
+func (d *RedisCacheDriver) SetRoleScopes(key string, scopes *contract.RoleScopes) *contract.AuthError {
+	entryKey := d.getPrefix(GroupTypeRole) + key
+	value, err := json.Marshal(scopes)
+	if nil != err {
+		return contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	err = d.getClient().Set(context.Background(), entryKey, value, d.ttl).Err()
+	if nil != err {
+		return contract.NewInternalError(contract.CacheError, map[string]string{"details": err.Error()})
+	}
+	return nil
+}
+
 func NewRedisCacheDriver(dsn string, newApiClient func() contract.ApiClientInterface, newApiUser func() contract.ApiUserInterface) *RedisCacheDriver {
 	return &RedisCacheDriver{
 		dsn:          dsn,
@@ -0,0 +1,330 @@
+package cache
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestRedisCacheDriver(t *testing.T) *RedisCacheDriver {
+	server := miniredis.RunT(t)
+	d := NewRedisCacheDriver("redis://"+server.Addr(), nil, nil)
+	d.Init("prefix:", time.Hour)
+	return d
+}
+
+func hammer(t *testing.T, workers int, requests int, request func() *contract.AuthError) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < requests; j++ {
+				if err := request(); nil != err {
+					t.Errorf("request error = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRedisCacheDriver_IncrementFUPEntry(t *testing.T) {
+	d := newTestRedisCacheDriver(t)
+	now := time.Now()
+	hammer(t, 20, 10, func() *contract.AuthError {
+		_, err := d.IncrementFUPEntry("client", constants.FUPAlgorithmFixedWindow, now, 1)
+		return err
+	})
+	entry, err := d.IncrementFUPEntry("client", constants.FUPAlgorithmFixedWindow, now, 1)
+	if nil != err {
+		t.Fatalf("IncrementFUPEntry() error = %v", err)
+	}
+	for _, period := range constants.FUPScopePeriods {
+		if got := entry.GetUsed(period); 201 != got {
+			t.Errorf("IncrementFUPEntry() used %s = %d, want %d", period, got, 201)
+		}
+	}
+	// keys are counted separately
+	entry, _ = d.IncrementFUPEntry("other-client", constants.FUPAlgorithmFixedWindow, now, 1)
+	if got := entry.GetUsed(constants.PeriodMinutely); 1 != got {
+		t.Errorf("IncrementFUPEntry() used = %d, want %d", got, 1)
+	}
+	// weighted requests
+	entry, _ = d.IncrementFUPEntry("other-client", constants.FUPAlgorithmFixedWindow, now, 100)
+	if got := entry.GetUsed(constants.PeriodMinutely); 101 != got {
+		t.Errorf("IncrementFUPEntry() used = %d, want %d", got, 101)
+	}
+}
+
+func TestRedisCacheDriver_IncrementFUPEntry_EndOfWindow(t *testing.T) {
+	server := miniredis.RunT(t)
+	d := NewRedisCacheDriver("redis://"+server.Addr(), nil, nil)
+	d.Init("prefix:", time.Hour)
+	// the last second of the minute
+	now := time.Date(2026, 10, 19, 12, 0, 59, 500000000, time.UTC)
+	server.SetTime(now)
+	var entry *contract.FUPCacheEntry
+	for i := 0; i < 5; i++ {
+		var err *contract.AuthError
+		entry, err = d.IncrementFUPEntry("client", constants.FUPAlgorithmFixedWindow, now, 1)
+		if nil != err {
+			t.Fatalf("IncrementFUPEntry() error = %v", err)
+		}
+	}
+	if got := entry.Used[constants.PeriodMinutely]; 5 != got {
+		t.Errorf("IncrementFUPEntry() used = %d, want %d", got, 5)
+	}
+	counterKey := d.getFUPCounterKey("client", constants.PeriodMinutely, constants.PeriodMinutely.GetFormatToCompare(now))
+	if got := server.TTL(counterKey); time.Millisecond*500 != got {
+		t.Errorf("TTL() = %v, want %v", got, time.Millisecond*500)
+	}
+}
+
+func TestRedisCacheDriver_IncrementFUPEntry_Weekly(t *testing.T) {
+	server := miniredis.RunT(t)
+	d := NewRedisCacheDriver("redis://"+server.Addr(), nil, nil)
+	d.Init("prefix:", time.Hour)
+	// Sunday is the last day of the ISO week
+	sunday := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	server.SetTime(sunday)
+	for _, now := range []time.Time{sunday.AddDate(0, 0, -1), sunday} {
+		if _, err := d.IncrementFUPEntry("client", constants.FUPAlgorithmFixedWindow, now, 1); nil != err {
+			t.Fatalf("IncrementFUPEntry() error = %v", err)
+		}
+	}
+	counterKey := d.getFUPCounterKey("client", constants.PeriodWeekly, "2026-42")
+	if got, _ := server.Get(counterKey); "2" != got {
+		t.Errorf("weekly counter = %s, want %s", got, "2")
+	}
+	// the counter of the week expires on Monday
+	if got := server.TTL(counterKey); time.Hour*12 != got {
+		t.Errorf("TTL() = %v, want %v", got, time.Hour*12)
+	}
+	if got := constants.PeriodWeekly.GetResetTimeAt(sunday); !got.Equal(time.Date(2026, 10, 18, 23, 59, 59, 999999999, time.UTC)) {
+		t.Errorf("GetResetTimeAt() = %v, want the end of Sunday", got)
+	}
+}
+
+func TestRedisCacheDriver_IncrementFUPEntry_SlidingWindow(t *testing.T) {
+	d := newTestRedisCacheDriver(t)
+	window := time.Now().Truncate(time.Minute)
+	hammer(t, 20, 10, func() *contract.AuthError {
+		_, err := d.IncrementFUPEntry("client", constants.FUPAlgorithmSlidingWindow, window.Add(time.Second*30), 1)
+		return err
+	})
+	entry, err := d.IncrementFUPEntry("client", constants.FUPAlgorithmSlidingWindow, window.Add(time.Second*90), 1)
+	if nil != err {
+		t.Fatalf("IncrementFUPEntry() error = %v", err)
+	}
+	if got := entry.Used[constants.PeriodMinutely]; 1 != got {
+		t.Errorf("IncrementFUPEntry() used = %d, want %d", got, 1)
+	}
+	if got := entry.Previous[constants.PeriodMinutely]; 200 != got {
+		t.Errorf("IncrementFUPEntry() previous = %d, want %d", got, 200)
+	}
+	// a half of the previous window is still covered by the last minute
+	if got := entry.GetSlidingWindowUsed(constants.PeriodMinutely, window.Add(time.Second*90)); 101 != got {
+		t.Errorf("GetSlidingWindowUsed() = %d, want %d", got, 101)
+	}
+}
+
+func TestRedisCacheDriver_TakeToken(t *testing.T) {
+	d := newTestRedisCacheDriver(t)
+	limit := contract.TokenBucketLimit{Rate: 1, Burst: 20}
+	now := time.Now()
+	var allowedCount int
+	var lock sync.Mutex
+	hammer(t, 10, 3, func() *contract.AuthError {
+		_, allowed, err := d.TakeToken("client", limit, now, 1)
+		if allowed {
+			lock.Lock()
+			allowedCount++
+			lock.Unlock()
+		}
+		return err
+	})
+	if 20 != allowedCount {
+		t.Errorf("TakeToken() allowed %d requests, want %d", allowedCount, 20)
+	}
+
+	entry, allowed, err := d.TakeToken("client", limit, now.Add(time.Second), 1)
+	if nil != err || !allowed || 0 != entry.Tokens {
+		t.Errorf("TakeToken() = %v, %v, %v, want a refilled token", entry, allowed, err)
+	}
+	if !entry.UpdatedAt.Equal(now.Add(time.Second).Truncate(time.Microsecond)) {
+		t.Errorf("TakeToken() updated at = %v, want %v", entry.UpdatedAt, now.Add(time.Second))
+	}
+}
+
+func TestRedisCacheDriver_TakeTokenCost(t *testing.T) {
+	d := newTestRedisCacheDriver(t)
+	limit := contract.TokenBucketLimit{Rate: 1, Burst: 50}
+	now := time.Now()
+	// nothing to return to a full bucket
+	if err := d.ReturnTokens("client", limit, 10); nil != err {
+		t.Errorf("ReturnTokens() error = %v", err)
+	}
+	for i, want := range []bool{true, true, false} {
+		if _, allowed, err := d.TakeToken("client", limit, now, 20); nil != err || want != allowed {
+			t.Errorf("TakeToken() #%d = %v, %v, want %v", i, allowed, err, want)
+		}
+	}
+
+	if err := d.ReturnTokens("client", limit, 20); nil != err {
+		t.Errorf("ReturnTokens() error = %v", err)
+	}
+	entry, allowed, err := d.TakeToken("client", limit, now, 30)
+	if nil != err || !allowed || 0 != entry.Tokens {
+		t.Errorf("TakeToken() = %v, %v, %v, want the returned tokens taken", entry, allowed, err)
+	}
+	if err := d.ReturnTokens("client", limit, 100); nil != err {
+		t.Errorf("ReturnTokens() error = %v", err)
+	}
+	if entry, _, _ := d.TakeToken("client", limit, now, 0); 50 != entry.Tokens {
+		t.Errorf("TakeToken() tokens = %v, want the burst %v", entry.Tokens, 50)
+	}
+}
+
+func TestRedisCacheDriver_RefundFUPEntry(t *testing.T) {
+	for _, algorithm := range []constants.FUPAlgorithm{constants.FUPAlgorithmFixedWindow, constants.FUPAlgorithmSlidingWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			d := newTestRedisCacheDriver(t)
+			now := time.Now()
+			// nothing to refund
+			if err := d.RefundFUPEntry("client", algorithm, now, 1); nil != err {
+				t.Fatalf("RefundFUPEntry() error = %v", err)
+			}
+			hammer(t, 10, 10, func() *contract.AuthError {
+				_, err := d.IncrementFUPEntry("client", algorithm, now, 2)
+				return err
+			})
+			hammer(t, 10, 5, func() *contract.AuthError {
+				return d.RefundFUPEntry("client", algorithm, now, 2)
+			})
+			entry, err := d.IncrementFUPEntry("client", algorithm, now, 0)
+			if nil != err {
+				t.Fatalf("IncrementFUPEntry() error = %v", err)
+			}
+			if got := entry.Used[constants.PeriodDaily]; 100 != got {
+				t.Errorf("RefundFUPEntry() used = %d, want %d", got, 100)
+			}
+			// the counters are never negative
+			_ = d.RefundFUPEntry("client", algorithm, now, 1000)
+			entry, _ = d.IncrementFUPEntry("client", algorithm, now, 0)
+			if got := entry.Used[constants.PeriodDaily]; 0 != got {
+				t.Errorf("RefundFUPEntry() used = %d, want %d", got, 0)
+			}
+		})
+	}
+}
+
+func TestRedisCacheDriver_AcquireSlot(t *testing.T) {
+	d := newTestRedisCacheDriver(t)
+	now := time.Now()
+	var acquiredCount int
+	var lock sync.Mutex
+	hammer(t, 10, 3, func() *contract.AuthError {
+		_, acquired, err := d.AcquireSlot("client", uuid.NewString(), 5, time.Minute, now)
+		if acquired {
+			lock.Lock()
+			acquiredCount++
+			lock.Unlock()
+		}
+		return err
+	})
+	if 5 != acquiredCount {
+		t.Errorf("AcquireSlot() acquired %d slots, want %d", acquiredCount, 5)
+	}
+
+	// the released slot can be acquired again
+	_, _, _ = d.AcquireSlot("other-client", "lease", 1, time.Minute, now)
+	if _, acquired, _ := d.AcquireSlot("other-client", "other-lease", 1, time.Minute, now); acquired {
+		t.Errorf("AcquireSlot() = %v, want %v", acquired, false)
+	}
+	if err := d.ReleaseSlot("other-client", "lease"); nil != err {
+		t.Errorf("ReleaseSlot() error = %v", err)
+	}
+	if inFlight, acquired, _ := d.AcquireSlot("other-client", "other-lease", 1, time.Minute, now); !acquired || 1 != inFlight {
+		t.Errorf("AcquireSlot() = %v, %v, want %v, %v", inFlight, acquired, 1, true)
+	}
+
+	// the slots of crashed instances are freed when their leases expire
+	if inFlight, acquired, _ := d.AcquireSlot("client", "lease", 5, time.Minute, now.Add(time.Minute)); !acquired || 1 != inFlight {
+		t.Errorf("AcquireSlot() = %v, %v, want %v, %v", inFlight, acquired, 1, true)
+	}
+}
+
+func TestRedisCacheDriver_QueryFUPEntry(t *testing.T) {
+	for _, algorithm := range []constants.FUPAlgorithm{constants.FUPAlgorithmFixedWindow, constants.FUPAlgorithmSlidingWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			server := miniredis.RunT(t)
+			d := NewRedisCacheDriver("redis://"+server.Addr(), nil, nil)
+			d.Init("prefix:", time.Hour)
+			now := time.Now()
+			entry, err := d.QueryFUPEntry("client", algorithm, now)
+			if nil != err {
+				t.Fatalf("QueryFUPEntry() error = %v", err)
+			}
+			if got := entry.GetUsedAt(algorithm, constants.PeriodDaily, now); 0 != got {
+				t.Errorf("QueryFUPEntry() used = %d, want %d", got, 0)
+			}
+			// no counters are created by the query
+			if keys := server.Keys(); 0 != len(keys) {
+				t.Errorf("QueryFUPEntry() created keys %v", keys)
+			}
+			_, _ = d.IncrementFUPEntry("client", algorithm, now, 5)
+			for i := 0; i < 2; i++ {
+				entry, _ = d.QueryFUPEntry("client", algorithm, now)
+				if got := entry.GetUsedAt(algorithm, constants.PeriodDaily, now); 5 != got {
+					t.Errorf("QueryFUPEntry() used = %d, want %d", got, 5)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisCacheDriver_FUPAdmin(t *testing.T) {
+	for _, algorithm := range []constants.FUPAlgorithm{constants.FUPAlgorithmFixedWindow, constants.FUPAlgorithmSlidingWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			d := newTestRedisCacheDriver(t)
+			now := time.Now()
+			_, _ = d.IncrementFUPEntry("key_client_*", algorithm, now, 3)
+			_, _ = d.IncrementFUPEntry("key_client_-orders", algorithm, now, 2)
+			_, _ = d.IncrementFUPEntry("key_other-client_*", algorithm, now, 1)
+			_, _, _ = d.TakeToken("key_client_-export_bucket", contract.TokenBucketLimit{Rate: 1, Burst: 1}, now, 1)
+
+			keys, err := d.ListFUPKeys("key_client_")
+			if nil != err || !reflect.DeepEqual([]string{"key_client_*", "key_client_-orders"}, keys) {
+				t.Errorf("ListFUPKeys() = %v, %v, want %v", keys, err, []string{"key_client_*", "key_client_-orders"})
+			}
+
+			_ = d.AdjustFUPEntry("key_client_*", algorithm, constants.PeriodDaily, now, -1)
+			entry, _ := d.QueryFUPEntry("key_client_*", algorithm, now)
+			if got := entry.GetUsedAt(algorithm, constants.PeriodDaily, now); 2 != got {
+				t.Errorf("AdjustFUPEntry() used = %d, want %d", got, 2)
+			}
+			// the usage is never negative
+			_ = d.AdjustFUPEntry("key_client_*", algorithm, constants.PeriodDaily, now, -10)
+			entry, _ = d.QueryFUPEntry("key_client_*", algorithm, now)
+			if got := entry.GetUsedAt(algorithm, constants.PeriodDaily, now); 0 != got {
+				t.Errorf("AdjustFUPEntry() used = %d, want %d", got, 0)
+			}
+
+			_ = d.ResetFUPEntry("key_client_-orders", algorithm, constants.PeriodHourly, now)
+			entry, _ = d.QueryFUPEntry("key_client_-orders", algorithm, now)
+			if got := entry.GetUsedAt(algorithm, constants.PeriodHourly, now); 0 != got {
+				t.Errorf("ResetFUPEntry() hourly used = %d, want %d", got, 0)
+			}
+			// the other periods are kept
+			if got := entry.GetUsedAt(algorithm, constants.PeriodDaily, now); 2 != got {
+				t.Errorf("ResetFUPEntry() daily used = %d, want %d", got, 2)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package checker
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
 	"strings"
@@ -12,10 +11,10 @@ type PathAccessScopeChecker struct {
 	hierarchySeparator string
 }
 
-func (ch PathAccessScopeChecker) Check(scope *contract.AccessScope, c *gin.Context) constants.ScopeAccessibility {
-	if nil == scope || nil == c || nil == c.Request || nil == c.Request.URL {
+func (ch PathAccessScopeChecker) Check(scope *contract.AccessScope, c contract.RequestContext) constants.ScopeAccessibility {
+	if nil == scope || nil == c || nil == c.GetRequest() || nil == c.GetRequest().URL {
 		return constants.ScopeAccessibilityForbidden
 	}
-	path := strings.ToLower(c.Request.URL.Path)
-	return scope.GetAccessibility(path, ch.hierarchySeparator)
+	path := strings.ToLower(c.GetRequest().URL.Path)
+	return scope.CheckAccessibility(path, ch.hierarchySeparator, c)
 }
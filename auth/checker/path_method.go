@@ -2,7 +2,6 @@ package checker
 
 import (
 	"fmt"
-	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
 	"strings"
@@ -13,11 +12,11 @@ type PathAndMethodAccessScopeChecker struct {
 	hierarchySeparator string
 }
 
-func (ch PathAndMethodAccessScopeChecker) Check(scope *contract.AccessScope, c *gin.Context) constants.ScopeAccessibility {
-	if nil == scope || nil == c || nil == c.Request || nil == c.Request.URL {
+func (ch PathAndMethodAccessScopeChecker) Check(scope *contract.AccessScope, c contract.RequestContext) constants.ScopeAccessibility {
+	if nil == scope || nil == c || nil == c.GetRequest() || nil == c.GetRequest().URL {
 		return constants.ScopeAccessibilityForbidden
 	}
-	path := strings.ToLower(c.Request.URL.Path)
-	method := strings.ToLower(c.Request.Method)
-	return scope.GetAccessibility(fmt.Sprintf("%s:%s", method, path), ch.hierarchySeparator)
+	path := strings.ToLower(c.GetRequest().URL.Path)
+	method := strings.ToLower(c.GetRequest().Method)
+	return scope.CheckAccessibility(fmt.Sprintf("%s:%s", method, path), ch.hierarchySeparator, c)
 }
@@ -125,7 +125,7 @@ func TestPathAndMethodAccessScopeChecker_Check(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.ch.Check(tt.args.scope, tt.args.c); got != tt.want {
+			if got := tt.ch.Check(tt.args.scope, contract.NewGinContext(tt.args.c)); got != tt.want {
 				t.Errorf("PathAndMethodAccessScopeChecker.Check() = %v, want %v", got, tt.want)
 			}
 		})
@@ -101,7 +101,7 @@ func TestPathAccessScopeChecker_Check(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.ch.Check(tt.args.scope, tt.args.c); got != tt.want {
+			if got := tt.ch.Check(tt.args.scope, contract.NewGinContext(tt.args.c)); got != tt.want {
 				t.Errorf("PathAccessScopeChecker.Check() = %v, want %v", got, tt.want)
 			}
 		})
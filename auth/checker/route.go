@@ -0,0 +1,21 @@
+package checker
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"strings"
+)
+
+// RouteAccessScopeChecker is an implementation of the AccessScopeCheckerInterface for the route template-based access model
+// (the matched route, e.g. `/orders/:id`, is used instead of the requested URL path)
+type RouteAccessScopeChecker struct {
+	hierarchySeparator string
+}
+
+func (ch RouteAccessScopeChecker) Check(scope *contract.AccessScope, c contract.RequestContext) constants.ScopeAccessibility {
+	if nil == scope || nil == c || "" == c.FullPath() {
+		return constants.ScopeAccessibilityForbidden
+	}
+	route := strings.ToLower(c.FullPath())
+	return scope.CheckAccessibility(route, ch.hierarchySeparator, c)
+}
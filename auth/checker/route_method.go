@@ -0,0 +1,22 @@
+package checker
+
+import (
+	"fmt"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"strings"
+)
+
+// RouteAndMethodAccessScopeChecker is an implementation of the AccessScopeCheckerInterface for the route template and method-based access model
+type RouteAndMethodAccessScopeChecker struct {
+	hierarchySeparator string
+}
+
+func (ch RouteAndMethodAccessScopeChecker) Check(scope *contract.AccessScope, c contract.RequestContext) constants.ScopeAccessibility {
+	if nil == scope || nil == c || nil == c.GetRequest() || "" == c.FullPath() {
+		return constants.ScopeAccessibilityForbidden
+	}
+	route := strings.ToLower(c.FullPath())
+	method := strings.ToLower(c.GetRequest().Method)
+	return scope.CheckAccessibility(fmt.Sprintf("%s:%s", method, route), ch.hierarchySeparator, c)
+}
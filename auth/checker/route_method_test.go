@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteAndMethodAccessScopeChecker_Check(t *testing.T) {
+	type args struct {
+		scope *contract.AccessScope
+		c     *gin.Context
+	}
+	tests := []struct {
+		name string
+		ch   RouteAndMethodAccessScopeChecker
+		args args
+		want constants.ScopeAccessibility
+	}{
+		{
+			name: "Nil scope",
+			ch:   RouteAndMethodAccessScopeChecker{},
+			args: args{scope: nil, c: nil},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Empty scope and context",
+			ch:   RouteAndMethodAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{}, c: &gin.Context{}},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Context without matched route",
+			ch:   RouteAndMethodAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"patch:/orders/:id": true}, c: &gin.Context{Request: httptest.NewRequest(http.MethodPatch, "/orders/42", nil)}},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Scope with route template and different method",
+			ch:   RouteAndMethodAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"get:/orders/:id": true}, c: newRouteContext(http.MethodPatch, "/orders/:id", "/orders/42")},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Scope with route template and method",
+			ch:   RouteAndMethodAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"patch:/orders/:id": true}, c: newRouteContext(http.MethodPatch, "/orders/:id", "/orders/42")},
+			want: constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name: "Scope with route template and method, on-behalf",
+			ch:   RouteAndMethodAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"patch:/orders/:id": "on-behalf"}, c: newRouteContext(http.MethodPatch, "/orders/:id", "/orders/43")},
+			want: constants.ScopeAccessibilityOnBehalf,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ch.Check(tt.args.scope, contract.NewGinContext(tt.args.c)); got != tt.want {
+				t.Errorf("RouteAndMethodAccessScopeChecker.Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package checker
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newRouteContext returns a copy of the context of a request matched to the given route template
+func newRouteContext(method string, template string, path string) *gin.Context {
+	var routeContext *gin.Context
+	engine := gin.New()
+	engine.Handle(method, template, func(c *gin.Context) {
+		routeContext = c.Copy()
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	return routeContext
+}
+
+func TestRouteAccessScopeChecker_Check(t *testing.T) {
+	type args struct {
+		scope *contract.AccessScope
+		c     *gin.Context
+	}
+	tests := []struct {
+		name string
+		ch   RouteAccessScopeChecker
+		args args
+		want constants.ScopeAccessibility
+	}{
+		{
+			name: "Nil scope",
+			ch:   RouteAccessScopeChecker{},
+			args: args{scope: nil, c: nil},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Empty scope",
+			ch:   RouteAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{}, c: nil},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Context without matched route",
+			ch:   RouteAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"/orders/:id": true}, c: &gin.Context{Request: httptest.NewRequest(http.MethodGet, "/orders/42", nil)}},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Scope with concrete path",
+			ch:   RouteAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"/orders/42": true}, c: newRouteContext(http.MethodGet, "/orders/:id", "/orders/42")},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Scope with route template",
+			ch:   RouteAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"/orders/:id": true}, c: newRouteContext(http.MethodGet, "/orders/:id", "/orders/42")},
+			want: constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name: "Scope with route template, different ID",
+			ch:   RouteAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"/orders/:id": "on-behalf"}, c: newRouteContext(http.MethodGet, "/orders/:id", "/orders/43")},
+			want: constants.ScopeAccessibilityOnBehalf,
+		},
+		{
+			name: "Scope with route template with mixed case",
+			ch:   RouteAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"/orders/:orderid": true}, c: newRouteContext(http.MethodGet, "/Orders/:orderId", "/Orders/42")},
+			want: constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name: "Scope with different route template",
+			ch:   RouteAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{"/orders": true}, c: newRouteContext(http.MethodGet, "/orders/:id", "/orders/42")},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ch.Check(tt.args.scope, contract.NewGinContext(tt.args.c)); got != tt.want {
+				t.Errorf("RouteAccessScopeChecker.Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package checker
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"regexp"
+	"strings"
+)
+
+// defaultKeyTemplate is used if no template is configured (equivalent to the PathAccessScopeChecker)
+const defaultKeyTemplate = "{path}"
+
+var keyTemplatePlaceholder = regexp.MustCompile(`\{([a-z]+)(?::([^{}]+))?}`)
+
+// keyTemplateValueEscaper escapes the separators of the scope keys (the `|` hierarchy separator, the `/` of paths, the `.` of FUP scopes
+// and the `:` commonly used in the templates) in the values set by the client, so that they can't reach other scope keys
+var keyTemplateValueEscaper = strings.NewReplacer("%", "%25", ".", "%2E", "/", "%2F", ":", "%3A", "|", "%7C")
+
+// RenderKeyTemplate builds a scope lookup key from the given template and request; supported placeholders are
+// {method}, {path}, {route}, {host}, {ip}, {header:Name}, {query:name} and {param:name}
+// (unknown placeholders and missing values are replaced by an empty string); the resulting key is lowercase.
+// The separators in the header, query and param values are percent-encoded (e.g. the `1.2` version renders as `1%2e2`)
+func RenderKeyTemplate(template string, c contract.RequestContext) string {
+	request := c.GetRequest()
+	if "" == template {
+		template = defaultKeyTemplate
+	}
+	key := keyTemplatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		parts := keyTemplatePlaceholder.FindStringSubmatch(placeholder)
+		name, argument := parts[1], parts[2]
+		switch name {
+		case "method":
+			return request.Method
+		case "path":
+			return request.URL.Path
+		case "route":
+			return c.FullPath()
+		case "host":
+			return request.Host
+		case "ip":
+			return c.ClientIP()
+		case "header":
+			return keyTemplateValueEscaper.Replace(request.Header.Get(argument))
+		case "query":
+			return keyTemplateValueEscaper.Replace(request.URL.Query().Get(argument))
+		case "param":
+			return keyTemplateValueEscaper.Replace(c.Param(argument))
+		}
+		return ""
+	})
+	return strings.ToLower(key)
+}
+
+// TemplateAccessScopeChecker is an implementation of the AccessScopeCheckerInterface for the access model
+// keyed by a configurable template (e.g. `{method}:{header:X-Api-Version}:{path}`)
+type TemplateAccessScopeChecker struct {
+	Template           string
+	hierarchySeparator string
+}
+
+func (ch TemplateAccessScopeChecker) Check(scope *contract.AccessScope, c contract.RequestContext) constants.ScopeAccessibility {
+	if nil == scope || nil == c || nil == c.GetRequest() || nil == c.GetRequest().URL {
+		return constants.ScopeAccessibilityForbidden
+	}
+	return scope.CheckAccessibility(RenderKeyTemplate(ch.Template, c), ch.hierarchySeparator, c)
+}
@@ -0,0 +1,136 @@
+package checker
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTemplateContext(method string, path string, headers map[string]string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(method, path, nil)
+	for key, value := range headers {
+		c.Request.Header.Set(key, value)
+	}
+	return c
+}
+
+func TestRenderKeyTemplate(t *testing.T) {
+	c := newTemplateContext(http.MethodGet, "/Orders?Format=PDF", map[string]string{"X-Api-Version": "V2"})
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "Default template", template: "", want: "/orders"},
+		{name: "Method and path", template: "{method}:{path}", want: "get:/orders"},
+		{name: "Header", template: "{method}:{header:X-Api-Version}:{path}", want: "get:v2:/orders"},
+		{name: "Missing header", template: "{header:X-Tenant}:{path}", want: ":/orders"},
+		{name: "Query parameter", template: "{path}|{query:Format}", want: "/orders|pdf"},
+		{name: "Unknown placeholder", template: "{unknown}:{path}", want: ":/orders"},
+		{name: "Literal text", template: "v1:{path}", want: "v1:/orders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderKeyTemplate(tt.template, contract.NewGinContext(c)); got != tt.want {
+				t.Errorf("RenderKeyTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderKeyTemplate_Escaping(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		path     string
+		headers  map[string]string
+		want     string
+	}{
+		{name: "Dot", template: "{method}:{header:X-Api-Version}:{path}", path: "/orders", headers: map[string]string{"X-Api-Version": "1.2"}, want: "get:1%2e2:/orders"},
+		{name: "Hierarchy separator", template: "{header:X-Tenant}|{path}", path: "/orders", headers: map[string]string{"X-Tenant": "acme|admin"}, want: "acme%7cadmin|/orders"},
+		{name: "Slash and colon", template: "{method}:{header:X-Api-Version}:{path}", path: "/orders", headers: map[string]string{"X-Api-Version": "v2:/admin"}, want: "get:v2%3a%2fadmin:/orders"},
+		{name: "Percent sign", template: "{header:X-Tenant}|{path}", path: "/orders", headers: map[string]string{"X-Tenant": "acme%7C"}, want: "acme%257c|/orders"},
+		{name: "Query parameter", template: "{path}|{query:format}", path: "/orders?format=a.b%7Cc", want: "/orders|a%2eb%7cc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTemplateContext(http.MethodGet, tt.path, tt.headers)
+			if got := RenderKeyTemplate(tt.template, contract.NewGinContext(c)); got != tt.want {
+				t.Errorf("RenderKeyTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	// the escaped values can't reach the nested scopes
+	scope := &contract.AccessScope{"acme": map[string]any{"admin": true}}
+	c := newTemplateContext(http.MethodGet, "/orders", map[string]string{"X-Tenant": "acme|admin"})
+	if got := (TemplateAccessScopeChecker{Template: "{header:X-Tenant}"}).Check(scope, contract.NewGinContext(c)); constants.ScopeAccessibilityForbidden != got {
+		t.Errorf("TemplateAccessScopeChecker.Check() = %v, want %v", got, constants.ScopeAccessibilityForbidden)
+	}
+}
+
+func TestTemplateAccessScopeChecker_Check(t *testing.T) {
+	type args struct {
+		scope *contract.AccessScope
+		c     *gin.Context
+	}
+	tests := []struct {
+		name string
+		ch   TemplateAccessScopeChecker
+		args args
+		want constants.ScopeAccessibility
+	}{
+		{
+			name: "Nil scope",
+			ch:   TemplateAccessScopeChecker{},
+			args: args{scope: nil, c: nil},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Empty scope and context",
+			ch:   TemplateAccessScopeChecker{},
+			args: args{scope: &contract.AccessScope{}, c: &gin.Context{}},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Header template, matching header",
+			ch:   TemplateAccessScopeChecker{Template: "{method}:{header:X-Api-Version}:{path}"},
+			args: args{scope: &contract.AccessScope{"get:v2:/orders": true}, c: newTemplateContext(http.MethodGet, "/orders", map[string]string{"X-Api-Version": "v2"})},
+			want: constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name: "Header template, different header",
+			ch:   TemplateAccessScopeChecker{Template: "{method}:{header:X-Api-Version}:{path}"},
+			args: args{scope: &contract.AccessScope{"get:v2:/orders": true}, c: newTemplateContext(http.MethodGet, "/orders", map[string]string{"X-Api-Version": "v1"})},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name: "Header template, regex key",
+			ch:   TemplateAccessScopeChecker{Template: "{method}:{header:X-Api-Version}:{path}"},
+			args: args{scope: &contract.AccessScope{"r#^get:v[0-9]+:/orders$": "on-behalf"}, c: newTemplateContext(http.MethodGet, "/orders", map[string]string{"X-Api-Version": "v3"})},
+			want: constants.ScopeAccessibilityOnBehalf,
+		},
+		{
+			name: "Nested scope keyed by header",
+			ch:   TemplateAccessScopeChecker{Template: "{header:X-Tenant}|{method}:{path}"},
+			args: args{scope: &contract.AccessScope{"acme": map[string]any{"get:/orders": true}}, c: newTemplateContext(http.MethodGet, "/orders", map[string]string{"X-Tenant": "ACME"})},
+			want: constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name: "Nested scope keyed by query parameter",
+			ch:   TemplateAccessScopeChecker{Template: "{query:partition}|{path}"},
+			args: args{scope: &contract.AccessScope{"eu": map[string]any{"/orders": true}}, c: newTemplateContext(http.MethodGet, "/orders?partition=us", nil)},
+			want: constants.ScopeAccessibilityForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ch.Check(tt.args.scope, contract.NewGinContext(tt.args.c)); got != tt.want {
+				t.Errorf("TemplateAccessScopeChecker.Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,16 @@
 package config
 
 import (
+	"fmt"
+	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/checker"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -46,6 +54,10 @@ func (p *Provider) ShouldExcludeOptionsRequests() bool {
 	return *p.config.ExcludeOptionsRequests
 }
 
+func (p *Provider) IsReportOnlyEnabled() bool {
+	return *p.config.ReportOnly
+}
+
 func (p *Provider) GetClientScopeAccessChecker() contract.AccessScopeCheckerInterface {
 	return p.config.Client.AccessScopeChecker
 }
@@ -62,6 +74,13 @@ func (p *Provider) GetUserScopeAccessChecker() contract.AccessScopeCheckerInterf
 	return p.config.User.AccessScopeChecker
 }
 
+func (p *Provider) GetScopeCombination() constants.ScopeCombination {
+	if !p.IsUserScopeAccessModelEnabled() {
+		return constants.ScopeCombinationClientOnly
+	}
+	return *p.config.User.ScopeCombination
+}
+
 func (p *Provider) GetApiTokenExpirationInterval() time.Duration {
 	return *p.config.User.ApiTokenExpirationInterval
 }
@@ -118,6 +137,122 @@ func (p *Provider) GetOneOffTokenExpirationInterval() time.Duration {
 	return *p.config.Client.OneOffTokenExpirationInterval
 }
 
+func (p *Provider) IsSignedUrlModeEnabled() bool {
+	return *p.config.Mode.SignedUrl
+}
+
+// IsAuthenticationEnabled returns true if any of the client authentication modes (api key, client id and secret, signed URL) is enabled
+func (p *Provider) IsAuthenticationEnabled() bool {
+	return p.IsApiKeyModeEnabled() || p.IsClientIdAndSecretModeEnabled() || p.IsSignedUrlModeEnabled()
+}
+
+func (p *Provider) GetSignedUrlKey() string {
+	if nil == p.config.Client.SignedUrlKey {
+		return ""
+	}
+	return *p.config.Client.SignedUrlKey
+}
+
+func (p *Provider) GetRoleProvider() contract.ApiRoleProviderInterface {
+	return p.config.Roles.Provider
+}
+
+func (p *Provider) IsRolesEnabled() bool {
+	return nil != p.config.Roles.Provider
+}
+
+func (p *Provider) GetOrganisationProvider() contract.ApiOrganisationProviderInterface {
+	return p.config.Organisation.Provider
+}
+
+func (p *Provider) GetOrganisationFUPChecker() contract.FUPCheckerInterface {
+	return p.config.Organisation.FUPChecker
+}
+
+func (p *Provider) IsOrganisationFUPEnabled() bool {
+	return nil != p.config.Organisation.Provider && nil != p.config.Organisation.FUPChecker
+}
+
+func (p *Provider) GetFUPAlgorithm() constants.FUPAlgorithm {
+	return *p.config.FUP.Algorithm
+}
+
+// GetFUPCost returns the cost of a request configured for the FUP key (see FUPConfig.Costs) or 1
+func (p *Provider) GetFUPCost(key string) int {
+	if cost, ok := p.config.FUP.Costs[key]; ok && cost >= 0 {
+		return cost
+	}
+	return 1
+}
+
+func (p *Provider) GetFUPCostHeader() string {
+	return *p.config.FUP.CostHeader
+}
+
+func (p *Provider) GetFUPAccounting() constants.FUPAccounting {
+	return *p.config.FUP.Accounting
+}
+
+// ShouldChargeFUPStatus returns true if the response status is charged in the post-handler accounting mode (see FUPConfig.ChargedStatusCodes)
+func (p *Provider) ShouldChargeFUPStatus(status int) bool {
+	code := strconv.Itoa(status)
+	for _, pattern := range p.config.FUP.ChargedStatusCodes {
+		if code == pattern || (3 == len(pattern) && strings.HasSuffix(strings.ToLower(pattern), "xx") && code[:1] == pattern[:1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFUPExempt returns true if the requests of the path are not counted towards FUP limits (see FUPConfig.ExemptHandlers)
+func (p *Provider) IsFUPExempt(path string) bool {
+	for _, exemptHandler := range p.config.FUP.ExemptHandlers {
+		matched, err := regexp.MatchString(exemptHandler, path)
+		if nil != err {
+			log.Printf("can't match FUP exempt handler pattern '%s': %v", exemptHandler, err)
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldSendFUPLimitsJSONHeaders returns true if the FUP limits are returned as JSON (see FUPConfig.LimitsHeaders)
+func (p *Provider) ShouldSendFUPLimitsJSONHeaders() bool {
+	return constants.FUPLimitsHeadersRateLimit != *p.config.FUP.LimitsHeaders
+}
+
+// ShouldSendRateLimitHeaders returns true if the FUP limits are returned in the RateLimit headers (see FUPConfig.LimitsHeaders)
+func (p *Provider) ShouldSendRateLimitHeaders() bool {
+	return constants.FUPLimitsHeadersJSON != *p.config.FUP.LimitsHeaders
+}
+
+func (p *Provider) GetRateLimitHeaderNaming() constants.RateLimitHeaderNaming {
+	return *p.config.FUP.RateLimitHeaderNaming
+}
+
+func (p *Provider) IsTraceHeaderEnabled() bool {
+	return *p.config.Trace.Header
+}
+
+// CanReceiveTraceHeader returns true if the trace header can be returned to the client (see TraceConfig.HeaderClients)
+func (p *Provider) CanReceiveTraceHeader(clientId string) bool {
+	return p.IsTraceHeaderEnabled() && slices.Contains(p.config.Trace.HeaderClients, clientId)
+}
+
+func (p *Provider) GetTraceHeaderMaxLength() int {
+	return *p.config.Trace.HeaderMaxLength
+}
+
+func (p *Provider) IsTraceLogEnabled() bool {
+	return *p.config.Trace.Log
+}
+
+func (p *Provider) IsTracingEnabled() bool {
+	return p.IsTraceHeaderEnabled() || p.IsTraceLogEnabled()
+}
+
 func (p *Provider) initUser(config contract.Config) {
 	if nil != config.User.Provider {
 		p.config.User.Provider = config.User.Provider
@@ -143,6 +278,51 @@ func (p *Provider) initUser(config contract.Config) {
 	if nil != config.User.FUPChecker {
 		p.config.User.FUPChecker = config.User.FUPChecker
 	}
+	if nil != config.User.ScopeCombination {
+		if !slices.Contains(constants.ScopeCombinationOptions, *config.User.ScopeCombination) {
+			panic(fmt.Sprintf("invalid scope combination %s (use one of %v)", *config.User.ScopeCombination, constants.ScopeCombinationOptions))
+		}
+		p.config.User.ScopeCombination = config.User.ScopeCombination
+	}
+}
+
+func (p *Provider) initFUP(config contract.Config) {
+	if nil != config.FUP.Algorithm {
+		if !slices.Contains(constants.FUPAlgorithmOptions, *config.FUP.Algorithm) {
+			panic(fmt.Sprintf("invalid FUP algorithm %s (use one of %v)", *config.FUP.Algorithm, constants.FUPAlgorithmOptions))
+		}
+		p.config.FUP.Algorithm = config.FUP.Algorithm
+	}
+	if nil != config.FUP.Costs {
+		p.config.FUP.Costs = config.FUP.Costs
+	}
+	if nil != config.FUP.CostHeader {
+		p.config.FUP.CostHeader = config.FUP.CostHeader
+	}
+	if nil != config.FUP.Accounting {
+		if !slices.Contains(constants.FUPAccountingOptions, *config.FUP.Accounting) {
+			panic(fmt.Sprintf("invalid FUP accounting %s (use one of %v)", *config.FUP.Accounting, constants.FUPAccountingOptions))
+		}
+		p.config.FUP.Accounting = config.FUP.Accounting
+	}
+	if nil != config.FUP.ChargedStatusCodes {
+		p.config.FUP.ChargedStatusCodes = config.FUP.ChargedStatusCodes
+	}
+	if nil != config.FUP.ExemptHandlers {
+		p.config.FUP.ExemptHandlers = config.FUP.ExemptHandlers
+	}
+	if nil != config.FUP.LimitsHeaders {
+		if !slices.Contains(constants.FUPLimitsHeadersOptions, *config.FUP.LimitsHeaders) {
+			panic(fmt.Sprintf("invalid FUP limits headers %s (use one of %v)", *config.FUP.LimitsHeaders, constants.FUPLimitsHeadersOptions))
+		}
+		p.config.FUP.LimitsHeaders = config.FUP.LimitsHeaders
+	}
+	if nil != config.FUP.RateLimitHeaderNaming {
+		if !slices.Contains(constants.RateLimitHeaderNamingOptions, *config.FUP.RateLimitHeaderNaming) {
+			panic(fmt.Sprintf("invalid RateLimit header naming %s (use one of %v)", *config.FUP.RateLimitHeaderNaming, constants.RateLimitHeaderNamingOptions))
+		}
+		p.config.FUP.RateLimitHeaderNaming = config.FUP.RateLimitHeaderNaming
+	}
 }
 
 func (p *Provider) initMode(config contract.Config) {
@@ -158,6 +338,9 @@ func (p *Provider) initMode(config contract.Config) {
 	if nil != config.Mode.OneOffToken {
 		p.config.Mode.OneOffToken = config.Mode.OneOffToken
 	}
+	if nil != config.Mode.SignedUrl {
+		p.config.Mode.SignedUrl = config.Mode.SignedUrl
+	}
 }
 
 func (p *Provider) initCache(config contract.Config) {
@@ -188,6 +371,9 @@ func (p *Provider) Init(config contract.Config) {
 	if nil != config.Client.OneOffTokenExpirationInterval {
 		p.config.Client.OneOffTokenExpirationInterval = config.Client.OneOffTokenExpirationInterval
 	}
+	if nil != config.Client.SignedUrlKey {
+		p.config.Client.SignedUrlKey = config.Client.SignedUrlKey
+	}
 
 	if nil != config.User {
 		p.initUser(config)
@@ -209,6 +395,10 @@ func (p *Provider) Init(config contract.Config) {
 		p.config.ExcludeOptionsRequests = config.ExcludeOptionsRequests
 	}
 
+	if nil != config.ReportOnly {
+		p.config.ReportOnly = config.ReportOnly
+	}
+
 	if nil != config.Cache {
 		p.initCache(config)
 	}
@@ -216,12 +406,63 @@ func (p *Provider) Init(config contract.Config) {
 	if nil != config.TargetOneOffTokenHandlers {
 		p.config.TargetOneOffTokenHandlers = config.TargetOneOffTokenHandlers
 	}
+
+	if nil != config.Roles && nil != config.Roles.Provider {
+		p.config.Roles.Provider = config.Roles.Provider
+	}
+
+	if nil != config.Organisation {
+		if nil != config.Organisation.Provider {
+			p.config.Organisation.Provider = config.Organisation.Provider
+		}
+		if nil != config.Organisation.FUPChecker {
+			p.config.Organisation.FUPChecker = config.Organisation.FUPChecker
+		}
+	}
+
+	if nil != config.FUP {
+		p.initFUP(config)
+	}
+
+	if nil != config.Trace {
+		if nil != config.Trace.Header {
+			p.config.Trace.Header = config.Trace.Header
+		}
+		if nil != config.Trace.Log {
+			p.config.Trace.Log = config.Trace.Log
+		}
+		if nil != config.Trace.HeaderClients {
+			p.config.Trace.HeaderClients = config.Trace.HeaderClients
+		}
+		if nil != config.Trace.HeaderMaxLength {
+			p.config.Trace.HeaderMaxLength = config.Trace.HeaderMaxLength
+		}
+	}
+
+	p.bindDataProviders()
+}
+
+// ProviderAwareInterface is implemented by data providers that depend on the configuration instance they are used in (e.g. GORM providers).
+// SetConfigProvider is called by Init for each data provider of the configuration; a provider that can't be shared
+// by several configuration instances should panic if it is bound to another instance already.
+type ProviderAwareInterface interface {
+	SetConfigProvider(p *Provider)
+}
+
+func (p *Provider) bindDataProviders() {
+	dataProviders := []any{p.config.Client.Provider, p.config.User.Provider, p.config.Roles.Provider, p.config.Organisation.Provider}
+	for _, dataProvider := range dataProviders {
+		if providerAware, ok := dataProvider.(ProviderAwareInterface); ok {
+			providerAware.SetConfigProvider(p)
+		}
+	}
 }
 
 var (
 	defaultApiKeyMode                     = false
 	defaultAdditionalApiKeys              = false
 	defaultOneOffTokenMode                = false
+	defaultSignedUrlMode                  = false
 	defaultClientIdAndSecretMode          = true
 	defaultExcludeOptionsRequests         = false
 	defaultClientUseScopeAccessModel      = false
@@ -232,10 +473,55 @@ var (
 	defaultOneOffTokenExpirationInterval  = time.Hour
 	defaultCacheTTL                       = time.Hour
 	defaultCachePrefix                    = "api-auth-go:"
+	defaultScopeCombination               = constants.ScopeCombinationIntersection
+	defaultTraceHeader                    = false
+	defaultTraceLog                       = false
+	defaultTraceHeaderMaxLength           = 4096
+	defaultReportOnly                     = false
+	defaultFUPAlgorithm                   = constants.FUPAlgorithmFixedWindow
+	defaultFUPCostHeader                  = ""
+	defaultFUPAccounting                  = constants.FUPAccountingPreHandler
+	defaultFUPChargedStatusCodes          = []string{"2xx"}
+	defaultFUPExemptHandlers              = []string{"/fup/usage$"}
+	defaultFUPLimitsHeaders               = constants.FUPLimitsHeadersJSON
+	defaultRateLimitHeaderNaming          = constants.RateLimitHeaderNamingIETF
 )
 
+// ProviderInstance is the default configuration instance (used by Middleware, Init and routes.Register);
+// use NewProvider to set up additional independent configurations
 var ProviderInstance = &Provider{
-	config: contract.Config{
+	config: newDefaultConfig(),
+}
+
+// NewProvider returns a new configuration instance initialized with the given config (missing values are set to defaults)
+func NewProvider(config contract.Config) *Provider {
+	p := &Provider{
+		config: newDefaultConfig(),
+	}
+	p.Init(config)
+	return p
+}
+
+// GetProvider returns the configuration instance bound to the request (see Bind) or ProviderInstance if none is bound
+func GetProvider(c contract.ValueGetter) *Provider {
+	if value, ok := contract.GetValue(c, constants.ConfigProvider); ok {
+		return value.(*Provider)
+	}
+	return ProviderInstance
+}
+
+// Bind binds the configuration instance to the request (it can be used as a gin.HandlerFunc)
+func (p *Provider) Bind(c *gin.Context) {
+	c.Set(constants.ConfigProvider, p)
+}
+
+// BindTo binds the configuration instance to the request of other frameworks than gin (see contract.HttpContext)
+func (p *Provider) BindTo(c contract.RequestContext) {
+	c.Set(constants.ConfigProvider, p)
+}
+
+func newDefaultConfig() contract.Config {
+	return contract.Config{
 		Client: contract.ClientConfig{
 			Provider:                      nil,
 			UseScopeAccessModel:           &defaultClientUseScopeAccessModel,
@@ -252,12 +538,14 @@ var ProviderInstance = &Provider{
 			WithRegistration:                    &defaultWithRegistration,
 			ConfirmationTokenExpirationInterval: &defaultConfirmationExpirationInterval,
 			FUPChecker:                          nil,
+			ScopeCombination:                    &defaultScopeCombination,
 		},
 		Mode: &contract.ModesConfig{
 			ApiKey:            &defaultApiKeyMode,
 			AdditionalApiKeys: &defaultAdditionalApiKeys,
 			ClientIdAndSecret: &defaultClientIdAndSecretMode,
 			OneOffToken:       &defaultOneOffTokenMode,
+			SignedUrl:         &defaultSignedUrlMode,
 		},
 		TargetHandlers:         nil,
 		ExcludeHandlers:        nil,
@@ -268,5 +556,28 @@ var ProviderInstance = &Provider{
 			TTL:    &defaultCacheTTL,
 		},
 		TargetOneOffTokenHandlers: nil,
-	},
+		Roles: &contract.RolesConfig{
+			Provider: nil,
+		},
+		Organisation: &contract.OrganisationConfig{
+			Provider:   nil,
+			FUPChecker: nil,
+		},
+		FUP: &contract.FUPConfig{
+			Algorithm:             &defaultFUPAlgorithm,
+			Costs:                 nil,
+			CostHeader:            &defaultFUPCostHeader,
+			Accounting:            &defaultFUPAccounting,
+			ChargedStatusCodes:    defaultFUPChargedStatusCodes,
+			ExemptHandlers:        defaultFUPExemptHandlers,
+			LimitsHeaders:         &defaultFUPLimitsHeaders,
+			RateLimitHeaderNaming: &defaultRateLimitHeaderNaming,
+		},
+		Trace: &contract.TraceConfig{
+			Header:          &defaultTraceHeader,
+			Log:             &defaultTraceLog,
+			HeaderMaxLength: &defaultTraceHeaderMaxLength,
+		},
+		ReportOnly: &defaultReportOnly,
+	}
 }
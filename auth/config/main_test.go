@@ -5,7 +5,9 @@ import (
 	"github.com/stretchr/testify/suite"
 	"github.com/wernerdweight/api-auth-go/v2/auth/cache"
 	"github.com/wernerdweight/api-auth-go/v2/auth/checker"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -20,6 +22,10 @@ func (m mockApiClientProvider) ProvideByApiKey(apiKey string) (contract.ApiClien
 	return nil, nil
 }
 
+func (m mockApiClientProvider) ProvideByClientId(id string) (contract.ApiClientInterface, *contract.AuthError) {
+	return nil, nil
+}
+
 func (m mockApiClientProvider) Save(client contract.ApiClientInterface) *contract.AuthError {
 	return nil
 }
@@ -60,7 +66,7 @@ func (m mockApiUserProvider) Save(user contract.ApiUserInterface) *contract.Auth
 
 type mockFUPChecker struct{}
 
-func (m mockFUPChecker) Check(fup *contract.FUPScope, c *gin.Context, key string) contract.FUPScopeLimits {
+func (m mockFUPChecker) Check(fup *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
 	return contract.FUPScopeLimits{}
 }
 
@@ -87,12 +93,14 @@ func (s *TestSuite) SetupTest() {
 				AccessScopeChecker:                  checker.PathAccessScopeChecker{},
 				WithRegistration:                    &defaultWithRegistration,
 				ConfirmationTokenExpirationInterval: &defaultConfirmationExpirationInterval,
+				ScopeCombination:                    &defaultScopeCombination,
 			},
 			Mode: &contract.ModesConfig{
 				ApiKey:            &defaultApiKeyMode,
 				AdditionalApiKeys: &defaultAdditionalApiKeys,
 				ClientIdAndSecret: &defaultClientIdAndSecretMode,
 				OneOffToken:       &defaultOneOffTokenMode,
+				SignedUrl:         &defaultSignedUrlMode,
 			},
 			TargetHandlers:         nil,
 			ExcludeHandlers:        nil,
@@ -103,6 +111,29 @@ func (s *TestSuite) SetupTest() {
 				TTL:    &defaultCacheTTL,
 			},
 			TargetOneOffTokenHandlers: nil,
+			Roles: &contract.RolesConfig{
+				Provider: nil,
+			},
+			Organisation: &contract.OrganisationConfig{
+				Provider:   nil,
+				FUPChecker: nil,
+			},
+			FUP: &contract.FUPConfig{
+				Algorithm:             &defaultFUPAlgorithm,
+				Costs:                 nil,
+				CostHeader:            &defaultFUPCostHeader,
+				Accounting:            &defaultFUPAccounting,
+				ChargedStatusCodes:    defaultFUPChargedStatusCodes,
+				ExemptHandlers:        defaultFUPExemptHandlers,
+				LimitsHeaders:         &defaultFUPLimitsHeaders,
+				RateLimitHeaderNaming: &defaultRateLimitHeaderNaming,
+			},
+			Trace: &contract.TraceConfig{
+				Header:          &defaultTraceHeader,
+				Log:             &defaultTraceLog,
+				HeaderMaxLength: &defaultTraceHeaderMaxLength,
+			},
+			ReportOnly: &defaultReportOnly,
 		},
 	}
 }
@@ -212,6 +243,15 @@ func (s *TestSuite) TestProvider_ShouldExcludeOptionsRequests() {
 	s.True(s.provider.ShouldExcludeOptionsRequests())
 }
 
+func (s *TestSuite) TestProvider_IsReportOnlyEnabled() {
+	s.False(s.provider.IsReportOnlyEnabled())
+	enabled := true
+	s.provider.Init(contract.Config{
+		ReportOnly: &enabled,
+	})
+	s.True(s.provider.IsReportOnlyEnabled())
+}
+
 func (s *TestSuite) TestProvider_GetClientScopeAccessChecker() {
 	s.NotNil(s.provider.GetClientScopeAccessChecker())
 	s.provider.Init(contract.Config{
@@ -401,3 +441,296 @@ func (s *TestSuite) TestProvider_GetOneOffTokenExpirationInterval() {
 	})
 	s.Equal(interval, s.provider.GetOneOffTokenExpirationInterval())
 }
+
+func (s *TestSuite) TestProvider_IsSignedUrlModeEnabled() {
+	s.False(s.provider.IsSignedUrlModeEnabled())
+	enabled := true
+	s.provider.Init(contract.Config{
+		Mode: &contract.ModesConfig{
+			SignedUrl: &enabled,
+		},
+	})
+	s.True(s.provider.IsSignedUrlModeEnabled())
+}
+
+func (s *TestSuite) TestProvider_GetSignedUrlKey() {
+	s.Equal("", s.provider.GetSignedUrlKey())
+	key := "server-key"
+	s.provider.Init(contract.Config{
+		Client: contract.ClientConfig{
+			SignedUrlKey: &key,
+		},
+	})
+	s.Equal(key, s.provider.GetSignedUrlKey())
+}
+
+type mockApiRoleProvider struct{}
+
+func (m mockApiRoleProvider) ProvideByNames(names []string) ([]contract.ApiRoleInterface, *contract.AuthError) {
+	return nil, nil
+}
+
+func (s *TestSuite) TestProvider_GetRoleProvider() {
+	s.Nil(s.provider.GetRoleProvider())
+	s.False(s.provider.IsRolesEnabled())
+	s.provider.Init(contract.Config{
+		Roles: &contract.RolesConfig{
+			Provider: mockApiRoleProvider{},
+		},
+	})
+	s.NotNil(s.provider.GetRoleProvider())
+	s.True(s.provider.IsRolesEnabled())
+}
+
+type mockApiOrganisationProvider struct{}
+
+func (m mockApiOrganisationProvider) ProvideById(id string) (contract.ApiOrganisationInterface, *contract.AuthError) {
+	return nil, nil
+}
+
+func (s *TestSuite) TestProvider_GetOrganisationProvider() {
+	s.Nil(s.provider.GetOrganisationProvider())
+	s.Nil(s.provider.GetOrganisationFUPChecker())
+	s.False(s.provider.IsOrganisationFUPEnabled())
+	s.provider.Init(contract.Config{
+		Organisation: &contract.OrganisationConfig{
+			Provider: mockApiOrganisationProvider{},
+		},
+	})
+	s.NotNil(s.provider.GetOrganisationProvider())
+	s.False(s.provider.IsOrganisationFUPEnabled())
+	s.provider.Init(contract.Config{
+		Organisation: &contract.OrganisationConfig{
+			FUPChecker: mockFUPChecker{},
+		},
+	})
+	s.NotNil(s.provider.GetOrganisationFUPChecker())
+	s.True(s.provider.IsOrganisationFUPEnabled())
+}
+
+func (s *TestSuite) TestProvider_GetScopeCombination() {
+	s.Equal(constants.ScopeCombinationClientOnly, s.provider.GetScopeCombination())
+	enabled := true
+	s.provider.Init(contract.Config{
+		User: &contract.UserConfig{
+			UseScopeAccessModel: &enabled,
+		},
+	})
+	s.Equal(constants.ScopeCombinationIntersection, s.provider.GetScopeCombination())
+	union := constants.ScopeCombinationUnion
+	s.provider.Init(contract.Config{
+		User: &contract.UserConfig{
+			ScopeCombination: &union,
+		},
+	})
+	s.Equal(constants.ScopeCombinationUnion, s.provider.GetScopeCombination())
+	invalid := constants.ScopeCombination("unoin")
+	s.Panics(func() {
+		s.provider.Init(contract.Config{
+			User: &contract.UserConfig{
+				ScopeCombination: &invalid,
+			},
+		})
+	})
+	s.Equal(constants.ScopeCombinationUnion, s.provider.GetScopeCombination())
+}
+
+func (s *TestSuite) TestProvider_IsTracingEnabled() {
+	s.False(s.provider.IsTracingEnabled())
+	enabled := true
+	s.provider.Init(contract.Config{
+		Trace: &contract.TraceConfig{
+			Log: &enabled,
+		},
+	})
+	s.True(s.provider.IsTraceLogEnabled())
+	s.False(s.provider.IsTraceHeaderEnabled())
+	s.True(s.provider.IsTracingEnabled())
+}
+
+func (s *TestSuite) TestProvider_CanReceiveTraceHeader() {
+	s.False(s.provider.CanReceiveTraceHeader("admin"))
+	s.Equal(4096, s.provider.GetTraceHeaderMaxLength())
+	enabled := true
+	maxLength := 1024
+	s.provider.Init(contract.Config{
+		Trace: &contract.TraceConfig{
+			Header:          &enabled,
+			HeaderClients:   []string{"admin"},
+			HeaderMaxLength: &maxLength,
+		},
+	})
+	s.True(s.provider.CanReceiveTraceHeader("admin"))
+	s.False(s.provider.CanReceiveTraceHeader("client"))
+	s.Equal(1024, s.provider.GetTraceHeaderMaxLength())
+}
+
+func (s *TestSuite) TestProvider_GetFUPAlgorithm() {
+	s.Equal(constants.FUPAlgorithmFixedWindow, s.provider.GetFUPAlgorithm())
+	slidingWindow := constants.FUPAlgorithmSlidingWindow
+	s.provider.Init(contract.Config{
+		FUP: &contract.FUPConfig{
+			Algorithm: &slidingWindow,
+		},
+	})
+	s.Equal(constants.FUPAlgorithmSlidingWindow, s.provider.GetFUPAlgorithm())
+}
+
+func (s *TestSuite) TestProvider_GetFUPCost() {
+	s.Equal(1, s.provider.GetFUPCost("/export"))
+	s.Equal("", s.provider.GetFUPCostHeader())
+	costHeader := "X-Rows-Returned"
+	s.provider.Init(contract.Config{
+		FUP: &contract.FUPConfig{
+			Costs:      map[string]int{"/export": 100, "/ping": 0, "/invalid": -1},
+			CostHeader: &costHeader,
+		},
+	})
+	s.Equal(100, s.provider.GetFUPCost("/export"))
+	s.Equal(0, s.provider.GetFUPCost("/ping"))
+	s.Equal(1, s.provider.GetFUPCost("/invalid"))
+	s.Equal(1, s.provider.GetFUPCost("/orders"))
+	s.Equal(costHeader, s.provider.GetFUPCostHeader())
+}
+
+func (s *TestSuite) TestProvider_ShouldChargeFUPStatus() {
+	s.Equal(constants.FUPAccountingPreHandler, s.provider.GetFUPAccounting())
+	s.True(s.provider.ShouldChargeFUPStatus(http.StatusOK))
+	s.True(s.provider.ShouldChargeFUPStatus(http.StatusNoContent))
+	s.False(s.provider.ShouldChargeFUPStatus(http.StatusNotModified))
+	s.False(s.provider.ShouldChargeFUPStatus(http.StatusUnprocessableEntity))
+	s.False(s.provider.ShouldChargeFUPStatus(http.StatusInternalServerError))
+	postHandler := constants.FUPAccountingPostHandler
+	s.provider.Init(contract.Config{
+		FUP: &contract.FUPConfig{
+			Accounting:         &postHandler,
+			ChargedStatusCodes: []string{"2XX", "404"},
+		},
+	})
+	s.Equal(constants.FUPAccountingPostHandler, s.provider.GetFUPAccounting())
+	s.True(s.provider.ShouldChargeFUPStatus(http.StatusCreated))
+	s.True(s.provider.ShouldChargeFUPStatus(http.StatusNotFound))
+	s.False(s.provider.ShouldChargeFUPStatus(http.StatusBadRequest))
+	s.False(s.provider.ShouldChargeFUPStatus(http.StatusNotModified))
+}
+
+func (s *TestSuite) TestProvider_InvalidFUPConfig() {
+	algorithm := constants.FUPAlgorithm("sliding")
+	accounting := constants.FUPAccounting("post")
+	headers := constants.FUPLimitsHeaders("rate-limit")
+	naming := constants.RateLimitHeaderNaming("ietf-draft")
+	for _, fupConfig := range []*contract.FUPConfig{
+		{Algorithm: &algorithm},
+		{Accounting: &accounting},
+		{LimitsHeaders: &headers},
+		{RateLimitHeaderNaming: &naming},
+	} {
+		s.Panics(func() {
+			s.provider.Init(contract.Config{FUP: fupConfig})
+		})
+	}
+	s.Equal(constants.FUPAlgorithmFixedWindow, s.provider.GetFUPAlgorithm())
+	s.Equal(constants.FUPAccountingPreHandler, s.provider.GetFUPAccounting())
+	s.Equal(constants.RateLimitHeaderNamingIETF, s.provider.GetRateLimitHeaderNaming())
+}
+
+func (s *TestSuite) TestProvider_IsFUPExempt() {
+	s.True(s.provider.IsFUPExempt("/fup/usage"))
+	s.True(s.provider.IsFUPExempt("/api/fup/usage"))
+	s.False(s.provider.IsFUPExempt("/fup/usage/other"))
+	s.False(s.provider.IsFUPExempt("/orders"))
+	s.provider.Init(contract.Config{
+		FUP: &contract.FUPConfig{
+			ExemptHandlers: []string{},
+		},
+	})
+	s.False(s.provider.IsFUPExempt("/fup/usage"))
+	s.provider.Init(contract.Config{
+		FUP: &contract.FUPConfig{
+			ExemptHandlers: []string{"^/health", "("},
+		},
+	})
+	s.True(s.provider.IsFUPExempt("/health/live"))
+	s.False(s.provider.IsFUPExempt("/fup/usage"))
+}
+
+func (s *TestSuite) TestProvider_LimitsHeaders() {
+	s.True(s.provider.ShouldSendFUPLimitsJSONHeaders())
+	s.False(s.provider.ShouldSendRateLimitHeaders())
+	s.Equal(constants.RateLimitHeaderNamingIETF, s.provider.GetRateLimitHeaderNaming())
+	for _, tt := range []struct {
+		headers   constants.FUPLimitsHeaders
+		json      bool
+		rateLimit bool
+	}{
+		{constants.FUPLimitsHeadersJSON, true, false},
+		{constants.FUPLimitsHeadersRateLimit, false, true},
+		{constants.FUPLimitsHeadersBoth, true, true},
+	} {
+		headers := tt.headers
+		naming := constants.RateLimitHeaderNamingXPrefixed
+		s.provider.Init(contract.Config{
+			FUP: &contract.FUPConfig{
+				LimitsHeaders:         &headers,
+				RateLimitHeaderNaming: &naming,
+			},
+		})
+		s.Equal(tt.json, s.provider.ShouldSendFUPLimitsJSONHeaders(), tt.headers)
+		s.Equal(tt.rateLimit, s.provider.ShouldSendRateLimitHeaders(), tt.headers)
+		s.Equal(constants.RateLimitHeaderNamingXPrefixed, s.provider.GetRateLimitHeaderNaming())
+	}
+}
+
+type mockProviderAwareApiClientProvider struct {
+	mockApiClientProvider
+	configProvider *Provider
+}
+
+func (m *mockProviderAwareApiClientProvider) SetConfigProvider(p *Provider) {
+	m.configProvider = p
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+	enabled := true
+	clientProvider := &mockProviderAwareApiClientProvider{}
+	first := NewProvider(contract.Config{
+		Client: contract.ClientConfig{
+			Provider:            clientProvider,
+			UseScopeAccessModel: &enabled,
+		},
+		User: &contract.UserConfig{
+			Provider: mockApiUserProvider{},
+		},
+	})
+	second := NewProvider(contract.Config{})
+
+	if !first.IsClientScopeAccessModelEnabled() || second.IsClientScopeAccessModelEnabled() {
+		t.Errorf("IsClientScopeAccessModelEnabled() is shared between instances")
+	}
+	if nil == first.GetUserProvider() || nil != second.GetUserProvider() {
+		t.Errorf("GetUserProvider() is shared between instances")
+	}
+	if nil != ProviderInstance.GetUserProvider() {
+		t.Errorf("NewProvider() modified the default instance")
+	}
+	if first != clientProvider.configProvider {
+		t.Errorf("SetConfigProvider() was not called with the configuration instance")
+	}
+}
+
+func TestGetProvider(t *testing.T) {
+	t.Parallel()
+	if ProviderInstance != GetProvider(nil) {
+		t.Errorf("GetProvider(nil) should return the default instance")
+	}
+	c := &gin.Context{}
+	if ProviderInstance != GetProvider(c) {
+		t.Errorf("GetProvider() should return the default instance if no instance is bound")
+	}
+	p := NewProvider(contract.Config{})
+	p.Bind(c)
+	if p != GetProvider(c) {
+		t.Errorf("GetProvider() should return the bound instance")
+	}
+}
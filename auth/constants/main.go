@@ -3,11 +3,30 @@ package constants
 import (
 	"fmt"
 	"github.com/jinzhu/now"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type ScopeAccessibility string
 
+type ScopeCombination string
+
+type UserRequirement string
+
+type AuthenticationMethod string
+
+type FUPAlgorithm string
+
+type FUPAccounting string
+
+type FUPLimitsHeaders string
+
+type RateLimitHeaderNaming string
+
+type FUPEntryAction string
+
 const (
 	ClientIdHeader                                  = "X-Client-Id"
 	ClientSecretHeader                              = "X-Client-Secret"
@@ -16,7 +35,15 @@ const (
 	OneOffTokenHeader                               = "X-Token"
 	ClientFUPLimitsHeader                           = "X-Client-FUP-Limits"
 	UserFUPLimitsHeader                             = "X-User-FUP-Limits"
+	OrganisationFUPLimitsHeader                     = "X-Organisation-FUP-Limits"
 	RetryAfterHeader                                = "Retry-After"
+	DecisionTraceHeader                             = "X-Auth-Decision-Trace"
+	DebugHeader                                     = "X-Auth-Debug"
+	RateLimitHeader                                 = "RateLimit"
+	RateLimitPolicyHeader                           = "RateLimit-Policy"
+	RateLimitLimitHeader                            = "RateLimit-Limit"
+	RateLimitRemainingHeader                        = "RateLimit-Remaining"
+	RateLimitResetHeader                            = "RateLimit-Reset"
 	ScopeAccessibilityAccessible ScopeAccessibility = "true"
 	ScopeAccessibilityForbidden  ScopeAccessibility = "false"
 	ScopeAccessibilityOnBehalf   ScopeAccessibility = "on-behalf"
@@ -28,11 +55,62 @@ const (
 	PeriodDaily                  Period             = "daily"
 	PeriodWeekly                 Period             = "weekly"
 	PeriodMonthly                Period             = "monthly"
+	PeriodYearly                 Period             = "yearly"
+	PeriodTokenBucket            Period             = "bucket"
+	PeriodConcurrent             Period             = "concurrent"
+	ScopeCombinationClientOnly   ScopeCombination   = "client-only"
+	ScopeCombinationUserOnly     ScopeCombination   = "user-only"
+	ScopeCombinationIntersection ScopeCombination   = "intersection"
+	ScopeCombinationUnion        ScopeCombination   = "union"
+	UserRequirementScope         UserRequirement    = "scope"
+	UserRequirementRequired      UserRequirement    = "required"
+	UserRequirementOptional      UserRequirement    = "optional"
 	FUPIPKey                                        = "per-ip"
 	FUPCookieKey                                    = "per-cookie"
+	FUPCostKey                                      = "cost"
+	FUPTimezoneKey                                  = "timezone"
+	SignedUrlClientIdParam                          = "auth_client"
+	SignedUrlUserParam                              = "auth_user"
+	SignedUrlExpiresParam                           = "auth_expires"
+	SignedUrlMethodParam                            = "auth_method"
+	SignedUrlSignatureParam                         = "auth_signature"
 
-	ApiClient = "api-client"
-	ApiUser   = "api-user"
+	AuthenticationMethodClientSecret     AuthenticationMethod = "client-secret"
+	AuthenticationMethodApiKey           AuthenticationMethod = "api-key"
+	AuthenticationMethodAdditionalApiKey AuthenticationMethod = "additional-api-key"
+	AuthenticationMethodOneOffToken      AuthenticationMethod = "one-off-token"
+	AuthenticationMethodSignedUrl        AuthenticationMethod = "signed-url"
+
+	FUPAlgorithmFixedWindow   FUPAlgorithm = "fixed-window"
+	FUPAlgorithmSlidingWindow FUPAlgorithm = "sliding-window"
+
+	FUPAccountingPreHandler  FUPAccounting = "pre-handler"
+	FUPAccountingPostHandler FUPAccounting = "post-handler"
+
+	FUPLimitsHeadersJSON      FUPLimitsHeaders = "json"
+	FUPLimitsHeadersRateLimit FUPLimitsHeaders = "ratelimit"
+	FUPLimitsHeadersBoth      FUPLimitsHeaders = "both"
+
+	RateLimitHeaderNamingIETF       RateLimitHeaderNaming = "ietf"
+	RateLimitHeaderNamingIETFLegacy RateLimitHeaderNaming = "ietf-legacy"
+	RateLimitHeaderNamingXPrefixed  RateLimitHeaderNaming = "x-prefixed"
+
+	FUPEntryActionReset  FUPEntryAction = "reset"
+	FUPEntryActionAdjust FUPEntryAction = "adjust"
+
+	ApiClient       = "api-client"
+	ApiUser         = "api-user"
+	TenantId        = "tenant-id"
+	EffectiveScope  = "effective-scope"
+	DecisionTrace   = "decision-trace"
+	WouldHaveDenied = "would-have-denied"
+	ConfigProvider  = "config-provider"
+	Principal       = "principal"
+	FUPCharges      = "fup-charges"
+	FUPCost         = "fup-cost"
+	FUPLeases       = "fup-leases"
+	FUPRateLimits   = "fup-rate-limits"
+	SignedUrlClaims = "signed-url-claims"
 )
 
 var ScopeAccessibilityOptions = []ScopeAccessibility{
@@ -41,6 +119,35 @@ var ScopeAccessibilityOptions = []ScopeAccessibility{
 	ScopeAccessibilityOnBehalf,
 }
 
+var ScopeCombinationOptions = []ScopeCombination{
+	ScopeCombinationClientOnly,
+	ScopeCombinationUserOnly,
+	ScopeCombinationIntersection,
+	ScopeCombinationUnion,
+}
+
+var FUPAlgorithmOptions = []FUPAlgorithm{
+	FUPAlgorithmFixedWindow,
+	FUPAlgorithmSlidingWindow,
+}
+
+var FUPAccountingOptions = []FUPAccounting{
+	FUPAccountingPreHandler,
+	FUPAccountingPostHandler,
+}
+
+var FUPLimitsHeadersOptions = []FUPLimitsHeaders{
+	FUPLimitsHeadersJSON,
+	FUPLimitsHeadersRateLimit,
+	FUPLimitsHeadersBoth,
+}
+
+var RateLimitHeaderNamingOptions = []RateLimitHeaderNaming{
+	RateLimitHeaderNamingIETF,
+	RateLimitHeaderNamingIETFLegacy,
+	RateLimitHeaderNamingXPrefixed,
+}
+
 var FUPScopeAccessibilityOptions = []ScopeAccessibility{
 	ScopeAccessibilityAccessible,
 	ScopeAccessibilityForbidden,
@@ -49,6 +156,33 @@ var FUPScopeAccessibilityOptions = []ScopeAccessibility{
 
 type Period string
 
+// PeriodDefinition defines a custom FUP period (see RegisterPeriod)
+type PeriodDefinition struct {
+	// Duration: the length of the period; without Start and End, the windows are aligned to multiples of the duration (e.g. 10 seconds)
+	Duration time.Duration
+	// Start, End: the beginning and the end of the calendar period containing t in the location of t (e.g. a quarter; optional)
+	Start func(t time.Time) time.Time
+	End   func(t time.Time) time.Time
+}
+
+var periodDefinitions = map[Period]PeriodDefinition{}
+
+// RegisterPeriod registers a custom FUP period usable in the FUP scopes along with the built-in ones
+// (e.g. `RegisterPeriod("10s", PeriodDefinition{Duration: time.Second * 10})` and `{"*": {"10s": 20}}`);
+// register the periods when the application starts (before the first request)
+func RegisterPeriod(period Period, definition PeriodDefinition) {
+	if "" == period || strings.Contains(string(period), ".") || slices.Contains(FUPScopePeriods, period) ||
+		slices.Contains([]Period{PeriodTokenBucket, PeriodConcurrent, FUPCostKey, FUPTimezoneKey}, period) {
+		panic(fmt.Sprintf("FUP period %s can't be registered", period))
+	}
+	if definition.Duration <= 0 || (nil == definition.Start) != (nil == definition.End) {
+		panic(fmt.Sprintf("invalid definition of FUP period %s", period))
+	}
+	periodDefinitions[period] = definition
+	FUPScopePeriods = append(FUPScopePeriods, period)
+}
+
+// GetFormatToCompare returns the window of the period containing t (calendar periods use the location of t)
 func (p Period) GetFormatToCompare(t time.Time) string {
 	switch p {
 	case PeriodMinutely:
@@ -62,30 +196,114 @@ func (p Period) GetFormatToCompare(t time.Time) string {
 		return fmt.Sprintf("%d-%d", year, week)
 	case PeriodMonthly:
 		return t.Format("2006-01")
+	case PeriodYearly:
+		return t.Format("2006")
+	}
+	if definition, ok := periodDefinitions[p]; ok {
+		if nil != definition.Start {
+			return strconv.FormatInt(definition.Start(t).UnixMilli(), 10)
+		}
+		return strconv.FormatInt(t.Truncate(definition.Duration).UnixMilli(), 10)
 	}
 	return ""
 }
 
+// GetDuration returns the nominal length of the period (e.g. the window of the RateLimit-Policy header; a month is 30 days, a year is 365 days)
+func (p Period) GetDuration() time.Duration {
+	switch p {
+	case PeriodMinutely:
+		return time.Minute
+	case PeriodHourly:
+		return time.Hour
+	case PeriodDaily:
+		return time.Hour * 24
+	case PeriodWeekly:
+		return time.Hour * 24 * 7
+	case PeriodMonthly:
+		return time.Hour * 24 * 30
+	case PeriodYearly:
+		return time.Hour * 24 * 365
+	}
+	if definition, ok := periodDefinitions[p]; ok {
+		return definition.Duration
+	}
+	return 0
+}
+
+// GetWindowStart returns the beginning of the window of the period containing t (calendar periods use the location of t)
+func (p Period) GetWindowStart(t time.Time) time.Time {
+	switch p {
+	case PeriodMinutely:
+		return now.With(t).BeginningOfMinute()
+	case PeriodHourly:
+		return now.With(t).BeginningOfHour()
+	case PeriodDaily:
+		return now.With(t).BeginningOfDay()
+	case PeriodWeekly:
+		// ISO weeks start on Monday (see GetFormatToCompare)
+		day := now.With(t).BeginningOfDay()
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PeriodMonthly:
+		return now.With(t).BeginningOfMonth()
+	case PeriodYearly:
+		return now.With(t).BeginningOfYear()
+	}
+	if definition, ok := periodDefinitions[p]; ok {
+		if nil != definition.Start {
+			return definition.Start(t)
+		}
+		return t.Truncate(definition.Duration)
+	}
+	return t
+}
+
+// GetNextWindowStart returns the beginning of the window following the window of the period containing t
+// (windows of calendar periods differ in length, e.g. months or days with a DST change)
+func (p Period) GetNextWindowStart(t time.Time) time.Time {
+	return p.GetWindowStart(p.GetResetTimeAt(t).Add(time.Nanosecond))
+}
+
+// GetPreviousWindowStart returns the beginning of the window preceding the window of the period containing t
+func (p Period) GetPreviousWindowStart(t time.Time) time.Time {
+	return p.GetWindowStart(p.GetWindowStart(t).Add(-time.Nanosecond))
+}
+
+// GetResetTime returns the end of the current window of the period in the server-local time (see GetResetTimeAt)
 func (p Period) GetResetTime() time.Time {
+	return p.GetResetTimeAt(time.Now())
+}
+
+// GetResetTimeAt returns the end of the window of the period containing t (calendar periods use the location of t)
+func (p Period) GetResetTimeAt(t time.Time) time.Time {
 	switch p {
 	case PeriodMinutely:
-		return now.EndOfMinute()
+		return now.With(t).EndOfMinute()
 	case PeriodHourly:
-		return now.EndOfHour()
+		return now.With(t).EndOfHour()
 	case PeriodDaily:
-		return now.EndOfDay()
+		return now.With(t).EndOfDay()
 	case PeriodWeekly:
-		return now.EndOfWeek()
+		return PeriodWeekly.GetWindowStart(t).AddDate(0, 0, 7).Add(-time.Nanosecond)
 	case PeriodMonthly:
-		return now.EndOfMonth()
+		return now.With(t).EndOfMonth()
+	case PeriodYearly:
+		return now.With(t).EndOfYear()
+	}
+	if definition, ok := periodDefinitions[p]; ok {
+		if nil != definition.End {
+			return definition.End(t)
+		}
+		return t.Truncate(definition.Duration).Add(definition.Duration)
 	}
-	return time.Now()
+	return t
 }
 
+// FUPScopePeriods lists the built-in periods and the periods registered by RegisterPeriod
 var FUPScopePeriods = []Period{
 	PeriodMinutely,
 	PeriodHourly,
 	PeriodDaily,
 	PeriodWeekly,
 	PeriodMonthly,
+	PeriodYearly,
 }
@@ -1,6 +1,9 @@
 package contract
 
-import "time"
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"time"
+)
 
 type CacheDriverInterface interface {
 	Init(prefix string, ttl time.Duration) *AuthError
@@ -17,3 +20,50 @@ type CacheDriverInterface interface {
 	SetFUPEntry(key string, entry *FUPCacheEntry) *AuthError
 	InvalidateToken(token string) *AuthError
 }
+
+// RoleCacheDriverInterface is implemented by cache drivers that cache the merged scopes of roles (see rbac.ResolveRoleScopes;
+// otherwise the roles are loaded from the role provider on every request)
+type RoleCacheDriverInterface interface {
+	// GetRoleScopes returns the merged scopes cached under key (nil if not cached)
+	GetRoleScopes(key string) (*RoleScopes, *AuthError)
+	// SetRoleScopes caches the merged scopes under key for the TTL of the cache
+	SetRoleScopes(key string, scopes *RoleScopes) *AuthError
+}
+
+// AtomicFUPCacheDriverInterface is implemented by cache drivers that count FUP requests atomically
+// (concurrent requests are never lost, not even across instances; otherwise the entry is read, incremented and written back by the FUP checkers)
+type AtomicFUPCacheDriverInterface interface {
+	// IncrementFUPEntry atomically counts a request of the given cost made at now into the counters stored under key and returns the updated counters
+	IncrementFUPEntry(key string, algorithm constants.FUPAlgorithm, now time.Time, cost int) (*FUPCacheEntry, *AuthError)
+	// RefundFUPEntry atomically takes back the cost of a request counted at reservedAt (see FUPCacheEntry.Refund)
+	RefundFUPEntry(key string, algorithm constants.FUPAlgorithm, reservedAt time.Time, cost int) *AuthError
+	// QueryFUPEntry returns the counters stored under key at now without counting a request (see FUPCacheEntry.GetUsedAt)
+	QueryFUPEntry(key string, algorithm constants.FUPAlgorithm, now time.Time) (*FUPCacheEntry, *AuthError)
+}
+
+// FUPAdminCacheDriverInterface is implemented by cache drivers that support the administration of FUP entries (see fup.ListEntries, fup.ResetEntry and fup.AdjustEntry)
+type FUPAdminCacheDriverInterface interface {
+	// ListFUPKeys returns the keys of the FUP entries (the keys passed to IncrementFUPEntry) starting with prefix
+	ListFUPKeys(prefix string) ([]string, *AuthError)
+	// ResetFUPEntry atomically clears the usage of the period at now stored under key (see FUPCacheEntry.Reset)
+	ResetFUPEntry(key string, algorithm constants.FUPAlgorithm, period constants.Period, now time.Time) *AuthError
+	// AdjustFUPEntry atomically adds delta to the usage of the period at now stored under key (see FUPCacheEntry.Adjust)
+	AdjustFUPEntry(key string, algorithm constants.FUPAlgorithm, period constants.Period, now time.Time, delta int) *AuthError
+}
+
+// ConcurrencyCacheDriverInterface is implemented by cache drivers that support concurrency FUP limits (see fup.ConcurrencyFUPChecker)
+type ConcurrencyCacheDriverInterface interface {
+	// AcquireSlot atomically frees the slots of expired leases and acquires a slot of the semaphore stored under key for the lease
+	// (acquired is false if all limit slots are taken; inFlight is the number of taken slots)
+	AcquireSlot(key string, leaseId string, limit int, lease time.Duration, now time.Time) (inFlight int, acquired bool, err *AuthError)
+	// ReleaseSlot releases the slot acquired for the lease
+	ReleaseSlot(key string, leaseId string) *AuthError
+}
+
+// TokenBucketCacheDriverInterface is implemented by cache drivers that support token bucket FUP limits (see fup.TokenBucketFUPChecker)
+type TokenBucketCacheDriverInterface interface {
+	// TakeToken atomically refills the bucket stored under key and takes cost tokens from it (allowed is false if there aren't enough tokens)
+	TakeToken(key string, limit TokenBucketLimit, now time.Time, cost int) (entry *TokenBucketEntry, allowed bool, err *AuthError)
+	// ReturnTokens atomically puts tokens taken by a request back into the bucket stored under key (up to the burst)
+	ReturnTokens(key string, limit TokenBucketLimit, tokens int) *AuthError
+}
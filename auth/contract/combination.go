@@ -0,0 +1,81 @@
+package contract
+
+import "strings"
+
+func isPatternScopeKey(key string) bool {
+	return isGlobScopeKey(key) || strings.HasPrefix(key, regexScopePrefix)
+}
+
+// lookupCounterpart finds the value that applies to key in scope; pattern keys (regex, glob) can only be matched exactly
+func lookupCounterpart(scope map[string]any, key string) (any, bool) {
+	if value, ok := scope[key]; ok {
+		return value, true
+	}
+	if isPatternScopeKey(key) {
+		return nil, false
+	}
+	return lookupScopeEntry(scope, key)
+}
+
+func intersectScopeValues(value any, other any) (any, bool) {
+	valueMap, valueIsMap := asScopeMap(value)
+	otherMap, otherIsMap := asScopeMap(other)
+	if valueIsMap && otherIsMap {
+		return intersectScopeMaps(valueMap, otherMap), true
+	}
+	if valueIsMap || otherIsMap {
+		// the scopes differ in structure, nothing is accessible in both of them
+		return nil, false
+	}
+	if getAccessRank(other) < getAccessRank(value) {
+		return other, true
+	}
+	return value, true
+}
+
+func intersectScopeMaps(scope map[string]any, other map[string]any) map[string]any {
+	result := map[string]any{}
+	for key, value := range scope {
+		if otherValue, ok := lookupCounterpart(other, key); ok {
+			if intersection, ok := intersectScopeValues(value, otherValue); ok {
+				result[key] = copyScopeValue(intersection)
+			}
+		}
+	}
+	for key, otherValue := range other {
+		if _, ok := scope[key]; ok {
+			// already intersected above
+			continue
+		}
+		if value, ok := lookupCounterpart(scope, key); ok {
+			if intersection, ok := intersectScopeValues(value, otherValue); ok {
+				result[key] = copyScopeValue(intersection)
+			}
+		}
+	}
+	return result
+}
+
+// IntersectAccessScopes computes the scope accessible in both given scopes: for each key, the value that applies
+// in the other scope is looked up and the least permissive of the two is kept (false < on-behalf < true);
+// keys not accessible in the other scope are left out. Pattern keys (regexes, globs) are only intersected
+// with the same pattern, so the result may be narrower than the actual intersection.
+func IntersectAccessScopes(scope *AccessScope, other *AccessScope) *AccessScope {
+	if nil == scope || nil == other {
+		return nil
+	}
+	result := AccessScope(intersectScopeMaps(*scope, *other))
+	return &result
+}
+
+// UnionAccessScopes computes the scope accessible in any of the given scopes: nested scopes are merged recursively
+// and for conflicting values the most permissive one wins (true > on-behalf > false).
+func UnionAccessScopes(scope *AccessScope, other *AccessScope) *AccessScope {
+	if nil == scope {
+		return other
+	}
+	if nil == other {
+		return scope
+	}
+	return MergeAccessScopes(nil, scope, other)
+}
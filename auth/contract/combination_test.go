@@ -0,0 +1,90 @@
+package contract
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIntersectAccessScopes(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope *AccessScope
+		other *AccessScope
+		want  *AccessScope
+	}{
+		{
+			name:  "Nil scope",
+			scope: nil,
+			other: &AccessScope{"/path": true},
+			want:  nil,
+		},
+		{
+			name:  "Disjoint scopes",
+			scope: &AccessScope{"/path": true},
+			other: &AccessScope{"/other": true},
+			want:  &AccessScope{},
+		},
+		{
+			name:  "Least permissive value wins",
+			scope: &AccessScope{"/path": true, "/other": "on-behalf", "/denied": true},
+			other: &AccessScope{"/path": "on-behalf", "/other": true, "/denied": false},
+			want:  &AccessScope{"/path": "on-behalf", "/other": "on-behalf", "/denied": false},
+		},
+		{
+			name:  "Literal keys are matched against patterns",
+			scope: &AccessScope{"g#/orders/**": true},
+			other: &AccessScope{"/orders/42": true, "r#^/orders/[0-9]+/items$": true, "/invoices": true},
+			want:  &AccessScope{"/orders/42": true},
+		},
+		{
+			name:  "Identical patterns are intersected",
+			scope: &AccessScope{"g#/orders/*": true},
+			other: &AccessScope{"g#/orders/*": "on-behalf"},
+			want:  &AccessScope{"g#/orders/*": "on-behalf"},
+		},
+		{
+			name:  "Nested scopes",
+			scope: &AccessScope{"api": map[string]any{"/path": true, "/other": true}},
+			other: &AccessScope{"api": AccessScope{"/path": true}},
+			want:  &AccessScope{"api": map[string]any{"/path": true}},
+		},
+		{
+			name:  "Different structure",
+			scope: &AccessScope{"api": map[string]any{"/path": true}},
+			other: &AccessScope{"api": true},
+			want:  &AccessScope{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IntersectAccessScopes(tt.scope, tt.other))
+		})
+	}
+}
+
+func TestUnionAccessScopes(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope *AccessScope
+		other *AccessScope
+		want  *AccessScope
+	}{
+		{
+			name:  "Nil scope",
+			scope: nil,
+			other: &AccessScope{"/path": true},
+			want:  &AccessScope{"/path": true},
+		},
+		{
+			name:  "Most permissive value wins",
+			scope: &AccessScope{"/path": true, "/other": "on-behalf"},
+			other: &AccessScope{"/path": false, "/other": true, "/another": "on-behalf"},
+			want:  &AccessScope{"/path": true, "/other": true, "/another": "on-behalf"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UnionAccessScopes(tt.scope, tt.other))
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package contract
 
-import "time"
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"time"
+)
 
 type ClientConfig struct {
 	// Provider: your provider that implements ApiClientProviderInterface
@@ -14,6 +17,8 @@ type ClientConfig struct {
 	FUPChecker FUPCheckerInterface
 	// ApiTokenExpirationInterval: token expiration in seconds - defaults to 3600 (1 hour)
 	OneOffTokenExpirationInterval *time.Duration
+	// SignedUrlKey: server key used to sign and verify signed URLs (mandatory if signed URL mode is enabled)
+	SignedUrlKey *string
 }
 
 type UserConfig struct {
@@ -34,6 +39,9 @@ type UserConfig struct {
 	// FUPChecker: the checker used to check FUP limits that implements FUPCheckerInterface (optional; if you omit FUP checker, FUP limits will not be checked)
 	// NOTE: if you want to use FUP limits, you must also enable Cache (see below)
 	FUPChecker FUPCheckerInterface
+	// ScopeCombination: how client and user scopes are combined for on-behalf requests (client-only, user-only, intersection, union) - defaults to intersection
+	// NOTE: only applies if UseScopeAccessModel is enabled (otherwise only the client scope is used)
+	ScopeCombination *constants.ScopeCombination
 }
 
 type ModesConfig struct {
@@ -45,6 +53,8 @@ type ModesConfig struct {
 	ClientIdAndSecret *bool
 	// OneOffToken: one-off token authentication mode (optional; default false)
 	OneOffToken *bool
+	// SignedUrl: signed URL authentication mode (optional; default false)
+	SignedUrl *bool
 }
 
 type CacheConfig struct {
@@ -56,6 +66,63 @@ type CacheConfig struct {
 	TTL *time.Duration
 }
 
+type RolesConfig struct {
+	// Provider: your provider that implements ApiRoleProviderInterface
+	Provider ApiRoleProviderInterface
+}
+
+type OrganisationConfig struct {
+	// Provider: your provider that implements ApiOrganisationProviderInterface
+	Provider ApiOrganisationProviderInterface
+	// FUPChecker: the checker used to check organisation-wide FUP limits that implements FUPCheckerInterface (optional; if you omit FUP checker, organisation FUP limits will not be checked)
+	// NOTE: if you want to use FUP limits, you must also enable Cache (see below)
+	FUPChecker FUPCheckerInterface
+}
+
+type FUPConfig struct {
+	// Algorithm: the algorithm used to count requests towards FUP limits (fixed-window, sliding-window) - defaults to fixed-window
+	// fixed-window: counters are reset at calendar boundaries (e.g. every full minute)
+	// sliding-window: requests are counted over the last period (e.g. the last 60 seconds), estimated from the current and the previous window
+	Algorithm *constants.FUPAlgorithm
+	// Costs: the cost of a request per FUP key (e.g. `{"/export": 100}`; the key is the one used by the FUP checker, e.g. the URL path or the route) - defaults to 1
+	// NOTE: the `cost` set for the key in the FUP scope of the client/user/organisation takes precedence (e.g. `{"/export": {"hourly": 1000, "cost": 100}}`)
+	Costs map[string]int
+	// CostHeader: the response header the handler uses to report the actual cost of the request (e.g. `X-Rows-Returned`; optional; see also fup.SetCost)
+	// NOTE: if the reported cost is higher than the cost charged by the FUP checker, the difference is charged after the handler
+	CostHeader *string
+	// Accounting: when the requests are counted towards FUP limits (pre-handler, post-handler) - defaults to pre-handler
+	// pre-handler: every request is counted before the handler (including failed ones)
+	// post-handler: the quota is reserved before the handler and refunded after it if the response status doesn't match ChargedStatusCodes
+	Accounting *constants.FUPAccounting
+	// ChargedStatusCodes: response status codes (e.g. `200`) or classes (e.g. `2xx`) charged in the post-handler accounting mode - defaults to 2xx
+	ChargedStatusCodes []string
+	// ExemptHandlers: patterns of the request paths that are neither counted towards FUP limits nor throttled - defaults to `/fup/usage$`
+	// NOTE: set an empty list to count the requests of all handlers (including the FUP usage route)
+	ExemptHandlers []string
+	// LimitsHeaders: the headers the FUP limits are returned in (json, ratelimit, both) - defaults to json
+	// json: the `X-Client-FUP-Limits`, `X-User-FUP-Limits` and `X-Organisation-FUP-Limits` headers with all the limits as JSON
+	// ratelimit: the RateLimit headers (draft-ietf-httpapi-ratelimit-headers) with the most restrictive limit (see RateLimitHeaderNaming)
+	LimitsHeaders *constants.FUPLimitsHeaders
+	// RateLimitHeaderNaming: the RateLimit headers to use (ietf, ietf-legacy, x-prefixed) - defaults to ietf
+	// ietf: `RateLimit-Policy: "client";q=100;w=3600` and `RateLimit: "client";r=50;t=30` with a policy per client/user/organisation
+	// ietf-legacy: `RateLimit-Limit`, `RateLimit-Remaining`, `RateLimit-Reset` and `RateLimit-Policy: 100;w=3600` (the drafts up to 07)
+	// x-prefixed: `X-RateLimit-Limit`, `X-RateLimit-Remaining` and `X-RateLimit-Reset`
+	// NOTE: the headers with a single limit contain the most restrictive limit of the client, the user and the organisation
+	RateLimitHeaderNaming *constants.RateLimitHeaderNaming
+}
+
+type TraceConfig struct {
+	// Header: if set to true, the authorization decision trace is returned in the `X-Auth-Decision-Trace` response header (for debugging only, exposes your scopes) - default false
+	// NOTE: the header is only returned to the clients listed in HeaderClients and only if they request it by sending the `X-Auth-Debug` header
+	Header *bool
+	// HeaderClients: ids of the (privileged) clients that can request the trace header
+	HeaderClients []string
+	// HeaderMaxLength: the maximum length of the trace header in bytes (the last checks are left out of longer traces) - defaults to 4096
+	HeaderMaxLength *int
+	// Log: if set to true, the authorization decision trace is logged as a structured log (slog) - default false
+	Log *bool
+}
+
 type Config struct {
 	// Client: api client configuration (mandatory)
 	Client ClientConfig
@@ -86,4 +153,20 @@ type Config struct {
 	// '.*'            	# all handlers
 	// '/v1/*'   		# all handlers starting with '/v1/'
 	// '/v1/some/path'  # only '/v1/some/path' handler
+
+	// Roles: role-based access control configuration (optional; if you omit roles configuration, roles will not be resolved)
+	Roles *RolesConfig
+
+	// Organisation: organisation (tenant) configuration (optional; if you omit organisation configuration, organisation FUP limits will not be checked)
+	Organisation *OrganisationConfig
+
+	// FUP: FUP limits configuration (optional; the FUP checkers are set in the client/user/organisation configuration)
+	FUP *FUPConfig
+
+	// Trace: authorization decision tracing configuration (optional; tracing is disabled by default)
+	Trace *TraceConfig
+
+	// ReportOnly: if set to true, forbidden and over-limit requests are let through and a WouldHaveDeniedEvent is dispatched instead - default false
+	// NOTE: report-only mode can also be enabled for individual clients (see ReportOnlyAwareInterface)
+	ReportOnly *bool
 }
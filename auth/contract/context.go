@@ -0,0 +1,54 @@
+package contract
+
+import (
+	"context"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+)
+
+type contextKey string
+
+const (
+	apiClientContextKey      contextKey = constants.ApiClient
+	apiUserContextKey        contextKey = constants.ApiUser
+	tenantIdContextKey       contextKey = constants.TenantId
+	effectiveScopeContextKey contextKey = constants.EffectiveScope
+	principalContextKey      contextKey = constants.Principal
+)
+
+// NewAuthContext returns a copy of ctx carrying the principal, authenticated client, user, tenant and effective scope stored in c
+// (used by the adapters for other frameworks than gin, see HttpContext.Request)
+func NewAuthContext(ctx context.Context, c ValueGetter) context.Context {
+	if isNilGetter(c) {
+		return ctx
+	}
+	for _, key := range []contextKey{apiClientContextKey, apiUserContextKey, tenantIdContextKey, effectiveScopeContextKey, principalContextKey} {
+		if value, ok := c.Get(string(key)); ok {
+			ctx = context.WithValue(ctx, key, value)
+		}
+	}
+	return ctx
+}
+
+// ApiClientFromContext returns the authenticated api client stored in ctx (see NewAuthContext) or nil
+func ApiClientFromContext(ctx context.Context) ApiClientInterface {
+	apiClient, _ := ctx.Value(apiClientContextKey).(ApiClientInterface)
+	return apiClient
+}
+
+// ApiUserFromContext returns the authenticated api user stored in ctx (see NewAuthContext) or nil
+func ApiUserFromContext(ctx context.Context) ApiUserInterface {
+	apiUser, _ := ctx.Value(apiUserContextKey).(ApiUserInterface)
+	return apiUser
+}
+
+// TenantIdFromContext returns the tenant id stored in ctx (see NewAuthContext) or an empty string
+func TenantIdFromContext(ctx context.Context) string {
+	tenantId, _ := ctx.Value(tenantIdContextKey).(string)
+	return tenantId
+}
+
+// EffectiveScopeFromContext returns the effective access scope stored in ctx (see NewAuthContext) or nil
+func EffectiveScopeFromContext(ctx context.Context) *AccessScope {
+	effectiveScope, _ := ctx.Value(effectiveScopeContextKey).(*AccessScope)
+	return effectiveScope
+}
@@ -3,6 +3,8 @@ package contract
 import (
 	"cmp"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"log"
+	"path"
 	"regexp"
 	"slices"
 	"strings"
@@ -63,12 +65,95 @@ func sortedRegexScopeKeys(scope map[string]any) []string {
 	return keys
 }
 
+// globScopePrefix marks a scope key as a glob pattern. The prefix is stripped
+// before matching; within a path segment `*` matches any sequence of
+// characters except `/`, and a `**` segment matches zero or more whole path
+// segments. Keys without the prefix (including those containing `*`) are
+// matched literally.
+const globScopePrefix = "g#"
+
+// isGlobScopeKey reports whether key is a glob pattern (see globScopePrefix).
+func isGlobScopeKey(key string) bool {
+	return strings.HasPrefix(key, globScopePrefix)
+}
+
+// matchGlobSegments matches path segments against glob pattern segments.
+func matchGlobSegments(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlobSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, err := path.Match(pattern[0], segments[0]); err != nil || !matched {
+		return false
+	}
+	return matchGlobSegments(pattern[1:], segments[1:])
+}
+
+// globSpecificity is the number of literal (non-wildcard) characters of the
+// glob pattern, so that e.g. `/orders/*/items` is more specific than
+// `/orders/**`.
+func globSpecificity(pattern string) int {
+	return len(pattern) - strings.Count(pattern, "*")
+}
+
+// isDenyScopeValue reports whether value explicitly denies access.
+func isDenyScopeValue(value any) bool {
+	switch typedValue := value.(type) {
+	case bool:
+		return !typedValue
+	case string:
+		return typedValue == string(constants.ScopeAccessibilityForbidden)
+	}
+	return false
+}
+
+// scopeMatch is a key matching the looked-up segment.
+type scopeMatch struct {
+	key         string
+	value       any
+	specificity int
+	matchType   string
+}
+
+// isPreferredTo reports whether the glob match m takes precedence over the
+// glob match other: the more specific match wins, deny beats allow at equal
+// specificity, and remaining ties are broken lexicographically to keep the
+// lookup deterministic.
+func (m scopeMatch) isPreferredTo(other scopeMatch) bool {
+	if m.specificity != other.specificity {
+		return m.specificity > other.specificity
+	}
+	if isDeny, isOtherDeny := isDenyScopeValue(m.value), isDenyScopeValue(other.value); isDeny != isOtherDeny {
+		return isDeny
+	}
+	return m.key < other.key
+}
+
 // lookupScopeEntry finds the value associated with segment in scope. Exact
 // matches always win; otherwise regex-enabled keys are tried in
-// most-specific-first order (see sortedRegexScopeKeys).
+// most-specific-first order (see sortedRegexScopeKeys). If no regex matches,
+// the most specific matching glob key wins (see globSpecificity); at equal
+// specificity deny beats allow.
 func lookupScopeEntry(scope map[string]any, segment string) (any, bool) {
+	match, ok := findScopeEntry(scope, segment)
+	return match.value, ok
+}
+
+// findScopeEntry is lookupScopeEntry that also reports the matched key and
+// the type of the match (see TraceStep).
+func findScopeEntry(scope map[string]any, segment string) (scopeMatch, bool) {
 	if value, ok := scope[segment]; ok {
-		return value, true
+		return scopeMatch{key: segment, value: value, matchType: TraceMatchExact}, true
 	}
 	for _, scopeEntry := range sortedRegexScopeKeys(scope) {
 		re := getCompiledScopeRegex(scopeEntry[len(regexScopePrefix):])
@@ -76,10 +161,31 @@ func lookupScopeEntry(scope map[string]any, segment string) (any, bool) {
 			continue
 		}
 		if re.MatchString(segment) {
-			return scope[scopeEntry], true
+			return scopeMatch{key: scopeEntry, value: scope[scopeEntry], matchType: TraceMatchRegex}, true
 		}
 	}
-	return nil, false
+	var best *scopeMatch
+	var segments []string
+	for scopeEntry, value := range scope {
+		if !isGlobScopeKey(scopeEntry) {
+			continue
+		}
+		if segments == nil {
+			segments = strings.Split(segment, "/")
+		}
+		pattern := scopeEntry[len(globScopePrefix):]
+		if !matchGlobSegments(strings.Split(pattern, "/"), segments) {
+			continue
+		}
+		candidate := scopeMatch{key: scopeEntry, value: value, specificity: globSpecificity(pattern), matchType: TraceMatchGlob}
+		if best == nil || candidate.isPreferredTo(*best) {
+			best = &candidate
+		}
+	}
+	if best == nil {
+		return scopeMatch{matchType: TraceMatchNone}, false
+	}
+	return *best, true
 }
 
 type AccessScope map[string]any
@@ -106,13 +212,36 @@ func (s AccessScope) getBoolAccessibility(index int, pathSegments []string, type
 }
 
 func (s AccessScope) GetAccessibility(key string, hierarchySeparator string) constants.ScopeAccessibility {
+	return s.getAccessibility(key, hierarchySeparator, nil)
+}
+
+// CheckAccessibility is GetAccessibility that also records the lookup into the decision trace of the request
+// (if tracing is enabled, see GetDecisionTrace); checkers should prefer it over GetAccessibility
+func (s AccessScope) CheckAccessibility(key string, hierarchySeparator string, c ValueGetter) constants.ScopeAccessibility {
+	trace := GetDecisionTrace(c)
+	if nil == trace {
+		return s.getAccessibility(key, hierarchySeparator, nil)
+	}
+	scopeTrace := &ScopeTrace{Subject: trace.Subject, Type: TraceTypeAccess, Key: key}
+	result := s.getAccessibility(key, hierarchySeparator, scopeTrace)
+	scopeTrace.Result = string(result)
+	trace.Checks = append(trace.Checks, *scopeTrace)
+	return result
+}
+
+// getAccessibility resolves the accessibility of key; if trace is not nil, the visited segments are recorded
+func (s AccessScope) getAccessibility(key string, hierarchySeparator string, trace *ScopeTrace) constants.ScopeAccessibility {
 	if hierarchySeparator == "" {
 		hierarchySeparator = "|"
 	}
 	pathSegments := strings.Split(key, hierarchySeparator)
 	currentScope := s
 	for index, segment := range pathSegments {
-		value, ok := lookupScopeEntry(currentScope, segment)
+		match, ok := findScopeEntry(currentScope, segment)
+		if nil != trace {
+			trace.addStep(segment, match)
+		}
+		value := match.value
 		if !ok {
 			return constants.ScopeAccessibilityForbidden
 		}
@@ -120,6 +249,11 @@ func (s AccessScope) GetAccessibility(key string, hierarchySeparator string) con
 			currentScope = nested
 			continue
 		}
+		// nested scopes loaded from JSON (database, cache) are plain maps
+		if nested, ok := value.(map[string]any); ok {
+			currentScope = nested
+			continue
+		}
 		if typedValue, ok := value.(string); ok {
 			return s.getStringAccessibility(index, pathSegments, typedValue)
 		}
@@ -133,73 +267,181 @@ func (s AccessScope) GetAccessibility(key string, hierarchySeparator string) con
 
 type FUPScope map[string]any
 
-func (s FUPScope) getIntLimit(index int, pathSegments []string, typedValue int) *int {
-	if index == len(pathSegments)-1 {
-		return &typedValue
+// resolveFUPValue resolves the segments in the nested FUP scopes of value (a segment per nesting level)
+func resolveFUPValue(value any, segments []string) (any, bool) {
+	for _, segment := range segments {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		value, ok = lookupScopeEntry(nested, segment)
+		if !ok {
+			return nil, false
+		}
 	}
-	return nil
+	return value, true
 }
 
-func (s FUPScope) getFloat64Limit(index int, pathSegments []string, typedValue float64) *int {
-	if index == len(pathSegments)-1 {
-		intValue := int(typedValue)
-		return &intValue
+// lookupFUPPathValue resolves the entry of path in the FUP scope and the segments nested in it; the path is matched as a whole
+// (literally or by a pattern key), so it may contain the `.` separator (e.g. file names or gRPC method names)
+func lookupFUPPathValue(scope map[string]any, path string, segments ...string) (any, bool) {
+	value, ok := lookupScopeEntry(scope, path)
+	if !ok {
+		return nil, false
 	}
-	return nil
+	return resolveFUPValue(value, segments)
 }
 
-func (s FUPScope) getFloat32Limit(index int, pathSegments []string, typedValue float32) *int {
-	if index == len(pathSegments)-1 {
+func getFUPLimit(value any) *int {
+	switch typedValue := value.(type) {
+	case int:
+		return &typedValue
+	case float64:
+		intValue := int(typedValue)
+		return &intValue
+	case float32:
 		intValue := int(typedValue)
 		return &intValue
 	}
 	return nil
 }
 
+// GetLimit returns the limit of key, whose segments are separated by `.` (e.g. `/orders.hourly`); use GetPeriodLimit if the path may contain dots
 func (s FUPScope) GetLimit(key string) *int {
-	pathSegments := strings.Split(key, ".")
-	currentScope := map[string]any(s)
-	for index, segment := range pathSegments {
-		value, ok := lookupScopeEntry(currentScope, segment)
-		if !ok {
-			return nil
-		}
-		if nested, ok := value.(map[string]any); ok {
-			currentScope = nested
-			continue
-		}
-		if typedValue, ok := value.(int); ok {
-			return s.getIntLimit(index, pathSegments, typedValue)
-		}
-		if typedValue, ok := value.(float64); ok {
-			return s.getFloat64Limit(index, pathSegments, typedValue)
-		}
-		if typedValue, ok := value.(float32); ok {
-			return s.getFloat32Limit(index, pathSegments, typedValue)
-		}
+	value, ok := resolveFUPValue(map[string]any(s), strings.Split(key, "."))
+	if !ok {
 		return nil
 	}
-	return nil
+	return getFUPLimit(value)
 }
 
-func (s FUPScope) HasLimit(key string) bool {
-	pathSegments := strings.Split(key, ".")
-	currentScope := map[string]any(s)
-	for index, segment := range pathSegments {
-		value, ok := lookupScopeEntry(currentScope, segment)
-		if !ok {
-			return false
+// GetPeriodLimit returns the limit of the period configured for path (e.g. `{"/orders.v1.orderservice/listorders": {"hourly": 100}}`) or nil
+func (s FUPScope) GetPeriodLimit(path string, period constants.Period) *int {
+	value, ok := lookupFUPPathValue(s, path, string(period))
+	if !ok {
+		return nil
+	}
+	return getFUPLimit(value)
+}
+
+// GetTokenBucket returns the token bucket limit configured for key (e.g. `{"/orders": {"bucket": {"rate": 10, "burst": 50}}}`) or nil
+func (s FUPScope) GetTokenBucket(key string) *TokenBucketLimit {
+	value, ok := lookupFUPPathValue(s, key, string(constants.PeriodTokenBucket))
+	if !ok {
+		return nil
+	}
+	bucket, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	rate, rateOk := getFUPNumber(bucket["rate"])
+	burst, burstOk := getFUPNumber(bucket["burst"])
+	if !rateOk || !burstOk || rate <= 0 || burst < 1 {
+		return nil
+	}
+	return &TokenBucketLimit{Rate: rate, Burst: int(burst)}
+}
+
+// GetCost returns the cost of a request configured for key (e.g. `{"/export": {"hourly": 1000, "cost": 100}}`) or nil
+func (s FUPScope) GetCost(key string) *int {
+	value, ok := lookupFUPPathValue(s, key, constants.FUPCostKey)
+	if !ok {
+		return nil
+	}
+	cost, ok := getFUPNumber(value)
+	if !ok || cost < 0 {
+		return nil
+	}
+	intCost := int(cost)
+	return &intCost
+}
+
+// GetConcurrencyLimit returns the maximum number of simultaneous requests configured for key (e.g. `{"/reports": {"concurrent": 5}}`) or nil
+func (s FUPScope) GetConcurrencyLimit(key string) *int {
+	value, ok := lookupFUPPathValue(s, key, string(constants.PeriodConcurrent))
+	if !ok {
+		return nil
+	}
+	limit, ok := getFUPNumber(value)
+	if !ok || limit < 0 {
+		return nil
+	}
+	intLimit := int(limit)
+	return &intLimit
+}
+
+// locationCache memoizes the time zones of the FUP scopes (time.LoadLocation reads the zoneinfo database);
+// a nil location means the time zone is not valid
+var locationCache sync.Map // map[string]*time.Location
+
+// GetLocation returns the time zone the calendar periods of the FUP scope reset in (e.g. `{"timezone": "Europe/Prague"}`);
+// the server-local time zone is used by default
+func (s FUPScope) GetLocation() *time.Location {
+	timezone, ok := s[constants.FUPTimezoneKey].(string)
+	if !ok || "" == timezone {
+		return time.Local
+	}
+	var location *time.Location
+	if cached, ok := locationCache.Load(timezone); ok {
+		location = cached.(*time.Location)
+	} else {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if nil != err {
+			log.Printf("invalid FUP scope time zone %s: %+v", timezone, err)
 		}
-		if nested, ok := value.(map[string]any); ok {
-			if index == len(pathSegments)-1 {
-				return true
-			}
-			currentScope = nested
+		locationCache.Store(timezone, location)
+	}
+	if nil == location {
+		return time.Local
+	}
+	return location
+}
+
+func getFUPNumber(value any) (float64, bool) {
+	switch typedValue := value.(type) {
+	case int:
+		return float64(typedValue), true
+	case float64:
+		return typedValue, true
+	case float32:
+		return float64(typedValue), true
+	}
+	return 0, false
+}
+
+// GetLimitedKeys returns the sorted keys of the FUP scope limiting a single FUP key (the root `*` and the paths, routes etc.);
+// the pattern keys (regex, glob) and the per-IP and per-cookie keys limit many FUP keys, so they are omitted
+func (s FUPScope) GetLimitedKeys() []string {
+	var keys []string
+	for key, value := range s {
+		if _, ok := value.(map[string]any); !ok || isPatternScopeKey(key) || constants.FUPIPKey == key || constants.FUPCookieKey == key {
 			continue
 		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// HasLimit returns true if key, whose segments are separated by `.`, has nested limits; use HasPathLimit if the path may contain dots
+func (s FUPScope) HasLimit(key string) bool {
+	value, ok := resolveFUPValue(map[string]any(s), strings.Split(key, "."))
+	if !ok {
 		return false
 	}
-	return false
+	_, isNested := value.(map[string]any)
+	return isNested
+}
+
+// HasPathLimit returns true if there are limits configured for path (see GetPeriodLimit)
+func (s FUPScope) HasPathLimit(path string) bool {
+	value, ok := lookupFUPPathValue(s, path)
+	if !ok {
+		return false
+	}
+	_, isNested := value.(map[string]any)
+	return isNested
 }
 
 type OneOffToken struct {
@@ -254,3 +496,34 @@ type ApiUserTokenInterface interface {
 	SetApiUser(apiUser ApiUserInterface)
 	GetApiUser() ApiUserInterface
 }
+
+// ClaimsHolderInterface is implemented by api clients/users that carry custom claims (e.g. to be evaluated by policies)
+type ClaimsHolderInterface interface {
+	GetClaims() map[string]any
+}
+
+// KeyIdAwareInterface is implemented by api client keys that have an identifier (other than the key itself)
+type KeyIdAwareInterface interface {
+	GetKeyId() string
+}
+
+// GetKeyId returns the identifier of the given api client key or an empty string if the key is not key-id-aware
+func GetKeyId(entity any) string {
+	if keyIdAware, ok := entity.(KeyIdAwareInterface); ok {
+		return keyIdAware.GetKeyId()
+	}
+	return ""
+}
+
+// ReportOnlyAwareInterface is implemented by api clients that can be switched to report-only mode individually
+type ReportOnlyAwareInterface interface {
+	IsReportOnly() bool
+}
+
+// IsReportOnly returns true if the given entity is in report-only mode (false if the entity is not report-only-aware)
+func IsReportOnly(entity any) bool {
+	if reportOnlyAware, ok := entity.(ReportOnlyAwareInterface); ok {
+		return reportOnlyAware.IsReportOnly()
+	}
+	return false
+}
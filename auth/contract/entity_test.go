@@ -2,9 +2,11 @@ package contract
 
 import (
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"reflect"
 	"regexp"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestAccessScope_GetAccessibility(t *testing.T) {
@@ -339,6 +341,102 @@ func TestAccessScope_GetAccessibility(t *testing.T) {
 			args: args{path: "api|/users/42"},
 			want: constants.ScopeAccessibilityAccessible,
 		},
+		{
+			name:  "Glob: single segment wildcard",
+			scope: AccessScope{"g#/orders/*": true},
+			args:  args{path: "/orders/42"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Glob: single segment wildcard does not match nested segments",
+			scope: AccessScope{"g#/orders/*": true},
+			args:  args{path: "/orders/42/items"},
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Glob: wildcard within segment",
+			scope: AccessScope{"g#*:/orders": true},
+			args:  args{path: "get:/orders"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Glob: double wildcard matches nested segments",
+			scope: AccessScope{"g#/orders/**": "on-behalf"},
+			args:  args{path: "/orders/42/items"},
+			want:  constants.ScopeAccessibilityOnBehalf,
+		},
+		{
+			name:  "Glob: double wildcard matches zero segments",
+			scope: AccessScope{"g#/orders/**": true},
+			args:  args{path: "/orders"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Glob: bare wildcard only matches literally",
+			scope: AccessScope{"*": true},
+			args:  args{path: "orders"},
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Precedence: exact deny beats glob allow",
+			scope: AccessScope{"g#/orders/**": true, "/orders/secret": false},
+			args:  args{path: "/orders/secret"},
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Precedence: more specific glob deny beats less specific allow",
+			scope: AccessScope{"g#/orders/**": true, "g#/orders/*/items": false},
+			args:  args{path: "/orders/42/items"},
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Precedence: more specific glob allow beats less specific deny",
+			scope: AccessScope{"g#/orders/**": false, "g#/orders/*/items": true},
+			args:  args{path: "/orders/42/items"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Precedence: deny beats allow at equal specificity",
+			scope: AccessScope{"g#/orders/4*": true, "g#/orders/*2": false},
+			args:  args{path: "/orders/42"},
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Precedence: regex ties are broken lexicographically",
+			scope: AccessScope{"r#^/orders/[0-4]+$": true, "r#^/orders/[0-9]+$": "false"},
+			args:  args{path: "/orders/42"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Precedence: regex beats glob",
+			scope: AccessScope{"g#/orders/**": false, "r#^/orders/[0-9]+$": true},
+			args:  args{path: "/orders/42"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Precedence: regex beats more specific glob",
+			scope: AccessScope{"g#/orders/42*": false, "r#.*": true},
+			args:  args{path: "/orders/42"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Glob: keys without the glob prefix match literally",
+			scope: AccessScope{"/orders/*": true},
+			args:  args{path: "/orders/42"},
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Glob: keys without the glob prefix match themselves",
+			scope: AccessScope{"/orders/*": true},
+			args:  args{path: "/orders/*"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Glob: nested scope",
+			scope: AccessScope{"g#/api/*": AccessScope{"g#/users/**": true}},
+			args:  args{path: "/api/v1|/users/42"},
+			want:  constants.ScopeAccessibilityAccessible,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -677,3 +775,218 @@ func TestFUPScope_GetLimit_HasLimit(t *testing.T) {
 		})
 	}
 }
+
+func TestFUPScope_GetPeriodLimit_HasPathLimit(t *testing.T) {
+	testValue := 123
+	tests := []struct {
+		name   string
+		scope  FUPScope
+		path   string
+		period constants.Period
+		want   *int
+		has    bool
+	}{
+		{
+			name:   "Path containing dots",
+			scope:  FUPScope{"/orders.v1.orderservice/listorders": map[string]any{"hourly": 123}},
+			path:   "/orders.v1.orderservice/listorders",
+			period: constants.PeriodHourly,
+			want:   &testValue,
+			has:    true,
+		},
+		{
+			name:   "Path containing dots, with glob",
+			scope:  FUPScope{"g#/orders.v1.orderservice/*": map[string]any{"hourly": 123}},
+			path:   "/orders.v1.orderservice/listorders",
+			period: constants.PeriodHourly,
+			want:   &testValue,
+			has:    true,
+		},
+		{
+			name:   "Path containing dots, exact key wins over glob",
+			scope:  FUPScope{"g#/orders.v1.orderservice/*": map[string]any{"hourly": 1}, "/orders.v1.orderservice/listorders": map[string]any{"hourly": 123}},
+			path:   "/orders.v1.orderservice/listorders",
+			period: constants.PeriodHourly,
+			want:   &testValue,
+			has:    true,
+		},
+		{
+			name:   "Path containing dots, prefix of the path is not used",
+			scope:  FUPScope{"/files/report": map[string]any{"pdf": map[string]any{"hourly": 1}}, "/files/report.pdf": map[string]any{"hourly": 123}},
+			path:   "/files/report.pdf",
+			period: constants.PeriodHourly,
+			want:   &testValue,
+			has:    true,
+		},
+		{
+			name:   "Path containing dots, missing period",
+			scope:  FUPScope{"/files/report.pdf": map[string]any{"hourly": 123}},
+			path:   "/files/report.pdf",
+			period: constants.PeriodDaily,
+			want:   nil,
+			has:    true,
+		},
+		{
+			// the period is never matched as a part of the path
+			name:   "Pattern matching the path and the period",
+			scope:  FUPScope{`r#^/files/report\.pdf\.hourly$`: 123, "g#/files/*.hourly": 123},
+			path:   "/files/report.pdf",
+			period: constants.PeriodHourly,
+			want:   nil,
+			has:    false,
+		},
+		{
+			name:   "Pattern key matches the whole path",
+			scope:  FUPScope{`r#^/files/[a-z]+$`: map[string]any{"hourly": 123}},
+			path:   "/files/report.pdf",
+			period: constants.PeriodHourly,
+			want:   nil,
+			has:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scope.GetPeriodLimit(tt.path, tt.period); got != tt.want && (got == nil || tt.want == nil || *got != *tt.want) {
+				t.Errorf("FUPScope.GetPeriodLimit() = %v, want %v", got, tt.want)
+			}
+			if got := tt.scope.HasPathLimit(tt.path); got != tt.has {
+				t.Errorf("FUPScope.HasPathLimit() = %v, want %v", got, tt.has)
+			}
+		})
+	}
+	// the segments of the keys of GetLimit are separated by dots, so the paths containing dots are not found
+	scope := FUPScope{"/files/report.pdf": map[string]any{"hourly": 123}}
+	if got := scope.GetLimit("/files/report.pdf.hourly"); nil != got {
+		t.Errorf("FUPScope.GetLimit() = %v, want nil", *got)
+	}
+}
+
+func TestFUPScope_GetTokenBucket(t *testing.T) {
+	scope := FUPScope{
+		"*":       map[string]any{"bucket": map[string]any{"rate": 10, "burst": 50}},
+		"/orders": map[string]any{"hourly": 100, "bucket": map[string]any{"rate": 0.5, "burst": float64(5)}},
+		"/users":  map[string]any{"bucket": map[string]any{"rate": 10}},
+		"/files":  map[string]any{"bucket": 10},
+	}
+	tests := []struct {
+		name string
+		key  string
+		want *TokenBucketLimit
+	}{
+		{"Root bucket", "*", &TokenBucketLimit{Rate: 10, Burst: 50}},
+		{"Path bucket next to period limits", "/orders", &TokenBucketLimit{Rate: 0.5, Burst: 5}},
+		{"Missing burst", "/users", nil},
+		{"Invalid bucket", "/files", nil},
+		{"No bucket", "/invoices", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scope.GetTokenBucket(tt.key); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FUPScope.GetTokenBucket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func TestFUPScope_GetCost(t *testing.T) {
+	scope := FUPScope{
+		"/export":  map[string]any{"hourly": 1000, "cost": 100},
+		"/reports": map[string]any{"cost": float64(2.5)},
+		"/ping":    map[string]any{"cost": 0},
+		"/invalid": map[string]any{"cost": -1},
+		"/orders":  map[string]any{"hourly": 100},
+	}
+	tests := []struct {
+		name string
+		key  string
+		want *int
+	}{
+		{"Cost next to period limits", "/export", intPointer(100)},
+		{"Float cost", "/reports", intPointer(2)},
+		{"Free path", "/ping", intPointer(0)},
+		{"Negative cost", "/invalid", nil},
+		{"No cost", "/orders", nil},
+		{"No path", "/invoices", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scope.GetCost(tt.key); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FUPScope.GetCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFUPScope_GetConcurrencyLimit(t *testing.T) {
+	scope := FUPScope{
+		"*":        map[string]any{"concurrent": 10},
+		"/reports": map[string]any{"hourly": 100, "concurrent": float64(5)},
+		"/invalid": map[string]any{"concurrent": -1},
+		"/files":   map[string]any{"concurrent": "5"},
+	}
+	tests := []struct {
+		name string
+		key  string
+		want *int
+	}{
+		{"Root limit", "*", intPointer(10)},
+		{"Path limit next to period limits", "/reports", intPointer(5)},
+		{"Negative limit", "/invalid", nil},
+		{"Invalid limit", "/files", nil},
+		{"No limit", "/orders", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scope.GetConcurrencyLimit(tt.key); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FUPScope.GetConcurrencyLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFUPScope_GetLocation(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	if nil != err {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+	tests := []struct {
+		name  string
+		scope FUPScope
+		want  *time.Location
+	}{
+		{"Time zone", FUPScope{"timezone": "Europe/Prague", "*": map[string]any{"daily": 100}}, prague},
+		{"Cached time zone", FUPScope{"timezone": "Europe/Prague"}, prague},
+		{"UTC", FUPScope{"timezone": "UTC"}, time.UTC},
+		{"Invalid time zone", FUPScope{"timezone": "Mars/Olympus_Mons"}, time.Local},
+		{"Invalid value", FUPScope{"timezone": 1}, time.Local},
+		{"No time zone", FUPScope{"*": map[string]any{"daily": 100}}, time.Local},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scope.GetLocation(); got.String() != tt.want.String() {
+				t.Errorf("FUPScope.GetLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFUPScope_GetLimitedKeys(t *testing.T) {
+	scope := FUPScope{
+		"timezone":      "Europe/Prague",
+		"*":             map[string]any{"daily": 1000},
+		"/orders":       map[string]any{"hourly": 100},
+		"g#/files/*":    map[string]any{"hourly": 10},
+		"r#^/users/.*$": map[string]any{"hourly": 10},
+		"per-ip":        map[string]any{"minutely": 10},
+		"per-cookie":    map[string]any{"minutely": 10},
+		"get:/export":   map[string]any{"daily": 5},
+	}
+	want := []string{"*", "/orders", "get:/export"}
+	if got := scope.GetLimitedKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FUPScope.GetLimitedKeys() = %v, want %v", got, want)
+	}
+}
@@ -45,6 +45,17 @@ const (
 	InvalidFUPCookie
 	OneOffTokenNotAllowed
 	ApiKeyExpired
+	InvalidSignedUrl
+	SignedUrlExpired
+	SignedUrlKeyNotConfigured
+	TenantMismatch
+	OrganisationNotFound
+	TokenBucketNotSupported
+	ConcurrencyLimitExceeded
+	ConcurrencyNotSupported
+	FUPAdminNotSupported
+	DataProviderNotBound
+	ClientByIdNotSupported
 )
 
 var AuthErrorCodes = map[AuthErrorCode]string{
@@ -78,6 +89,17 @@ var AuthErrorCodes = map[AuthErrorCode]string{
 	InvalidFUPCookie:          "FUP cookie present, but invalid",
 	OneOffTokenNotAllowed:     "one-off token authentication is not allowed for this endpoint",
 	ApiKeyExpired:             "API key expired",
+	InvalidSignedUrl:          "signed URL is invalid or has been tampered with",
+	SignedUrlExpired:          "signed URL expired",
+	SignedUrlKeyNotConfigured: "signing key needs to be configured for signed URLs to work",
+	TenantMismatch:            "user belongs to a different organisation than the client",
+	OrganisationNotFound:      "organisation not found",
+	TokenBucketNotSupported:   "cache driver doesn't support token bucket FUP limits",
+	ConcurrencyLimitExceeded:  "concurrent request limit exceeded",
+	ConcurrencyNotSupported:   "cache driver doesn't support concurrency FUP limits",
+	FUPAdminNotSupported:      "cache driver doesn't support the administration of FUP entries",
+	DataProviderNotBound:      "data provider is not used by any configuration instance",
+	ClientByIdNotSupported:    "client provider doesn't support loading clients by id",
 }
 
 func NewAuthError(code AuthErrorCode, payload interface{}) *AuthError {
@@ -2,6 +2,7 @@ package contract
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/events-go"
 )
 
@@ -17,6 +18,8 @@ const (
 	ResettingCompletedEventKey                = "api-auth-go.resetting-completed"
 	AuthenticationFailedEventKey              = "api-auth-go.authentication-failed"
 	AuthenticationCompletedEventKey           = "api-auth-go.authentication-completed"
+	WouldHaveDeniedEventKey                   = "api-auth-go.would-have-denied"
+	FUPEntryChangedEventKey                   = "api-auth-go.fup-entry-changed"
 )
 
 type ValidateLoginInformationEvent struct {
@@ -139,7 +142,7 @@ func (event *ResettingCompletedEvent) GetPayload() events.EventPayload {
 
 type AuthenticationFailedEvent struct {
 	Error    AuthError
-	Context  *gin.Context
+	Context  RequestContext
 	Response gin.H
 }
 
@@ -163,3 +166,45 @@ func (event *AuthenticationCompletedEvent) GetKey() events.EventKey {
 func (event *AuthenticationCompletedEvent) GetPayload() events.EventPayload {
 	return event
 }
+
+// WouldHaveDeniedEvent is dispatched in report-only mode for requests that were let through although they would have been denied
+type WouldHaveDeniedEvent struct {
+	ApiClient ApiClientInterface
+	ApiUser   ApiUserInterface
+	Context   RequestContext
+	// Errors: the errors the request would have been denied with
+	Errors []AuthError
+	// Trace: the decision trace of the request (nil if tracing is disabled)
+	Trace *DecisionTrace
+}
+
+func (event *WouldHaveDeniedEvent) GetKey() events.EventKey {
+	return WouldHaveDeniedEventKey
+}
+
+func (event *WouldHaveDeniedEvent) GetPayload() events.EventPayload {
+	return event
+}
+
+// FUPEntryChangedEvent is dispatched when the usage of a FUP entry is reset or adjusted (see fup.ResetEntry and fup.AdjustEntry)
+type FUPEntryChangedEvent struct {
+	// Key: the FUP key (e.g. the client id or the user login)
+	Key string
+	// Id: the id of the entry (e.g. `*` for the root limits, the path or the IP address)
+	Id string
+	// Period: the period of the changed usage (empty if the usage of all the periods is reset)
+	Period constants.Period
+	Action constants.FUPEntryAction
+	// Delta: the change of the usage (adjust only)
+	Delta int
+	// Actor: who made the change (e.g. the client id of the admin)
+	Actor string
+}
+
+func (event *FUPEntryChangedEvent) GetKey() events.EventKey {
+	return FUPEntryChangedEventKey
+}
+
+func (event *FUPEntryChangedEvent) GetPayload() events.EventPayload {
+	return event
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"log"
+	"math"
 	"time"
 )
 
@@ -11,6 +12,8 @@ type FUPLimits struct {
 	Limit  int              `json:"limit"`
 	Used   int              `json:"used"`
 	Period constants.Period `json:"-"`
+	// ResetAt: when the whole limit is available again (for an exceeded limit, when the next request fits into it); the end of the period in the server-local time is used if not set
+	ResetAt time.Time `json:"-"`
 }
 
 type FUPScopeLimits struct {
@@ -31,13 +34,63 @@ func (l *FUPScopeLimits) GetLimitsHeader() string {
 	return string(header)
 }
 
+// GetRemaining returns the remaining quota of the limit
+func (l FUPLimits) GetRemaining() int {
+	return max(l.Limit-l.Used, 0)
+}
+
+// GetResetAfter returns the number of seconds until the limit is available again at now (-1 if unknown, e.g. for concurrency limits)
+func (l FUPLimits) GetResetAfter(now time.Time) int {
+	if l.ResetAt.IsZero() {
+		return -1
+	}
+	return max(int(math.Ceil(l.ResetAt.Sub(now).Seconds())), 0)
+}
+
+// GetMostRestrictiveLimits returns the limit with the lowest remaining quota (the one resetting later on ties) or nil
+func (l *FUPScopeLimits) GetMostRestrictiveLimits() *FUPLimits {
+	var mostRestrictive *FUPLimits
+	for _, limits := range l.Limits {
+		if nil == mostRestrictive || IsMoreRestrictive(limits, *mostRestrictive) {
+			candidate := limits
+			mostRestrictive = &candidate
+		}
+	}
+	return mostRestrictive
+}
+
+// IsMoreRestrictive returns true if the remaining quota of limits is lower than the one of other (or if it resets later on ties)
+func IsMoreRestrictive(limits FUPLimits, other FUPLimits) bool {
+	if limits.GetRemaining() != other.GetRemaining() {
+		return limits.GetRemaining() < other.GetRemaining()
+	}
+	if !limits.ResetAt.Equal(other.ResetAt) {
+		return limits.ResetAt.After(other.ResetAt)
+	}
+	// deterministic choice for limits of different periods resetting at once
+	return limits.Period < other.Period
+}
+
+// GetErrorCode returns the error code of exceeded limits (concurrency limits are reported separately from the request limits)
+func (l *FUPScopeLimits) GetErrorCode() AuthErrorCode {
+	if _, ok := l.Limits[constants.PeriodConcurrent]; ok {
+		return ConcurrencyLimitExceeded
+	}
+	return RequestLimitDepleted
+}
+
+// GetRetryAfter returns the number of seconds until the exceeded limit is available again (-1 if not exceeded);
+// the seconds are rounded up the same way as in the RateLimit headers (see FUPLimits.GetResetAfter)
 func (l *FUPScopeLimits) GetRetryAfter() int {
 	if l.Accessible != constants.ScopeAccessibilityForbidden {
 		return -1
 	}
-	for period := range l.Limits {
+	for period, limit := range l.Limits {
 		// there will always be exactly one limit
-		return int(time.Until(period.GetResetTime()).Seconds())
+		if limit.ResetAt.IsZero() {
+			limit.ResetAt = period.GetResetTime()
+		}
+		return limit.GetResetAfter(time.Now())
 	}
 	return -1
 }
@@ -45,6 +98,8 @@ func (l *FUPScopeLimits) GetRetryAfter() int {
 type FUPCacheEntry struct {
 	UpdatedAt time.Time                `json:"updatedAt"`
 	Used      map[constants.Period]int `json:"used"`
+	// Previous: requests made in the previous window of each period (sliding window algorithm only)
+	Previous map[constants.Period]int `json:"previous,omitempty"`
 }
 
 func (e *FUPCacheEntry) GetUsed(period constants.Period) int {
@@ -54,18 +109,227 @@ func (e *FUPCacheEntry) GetUsed(period constants.Period) int {
 	return e.Used[period]
 }
 
+// GetUsedAt returns the number of requests counted into the period at now using the given algorithm
+// (the counters of a window that is over already are not used anymore)
+func (e *FUPCacheEntry) GetUsedAt(algorithm constants.FUPAlgorithm, period constants.Period, now time.Time) int {
+	if nil == e.Used {
+		return 0
+	}
+	if constants.FUPAlgorithmSlidingWindow == algorithm {
+		return e.GetSlidingWindowUsed(period, now)
+	}
+	if period.GetFormatToCompare(e.UpdatedAt.In(now.Location())) != period.GetFormatToCompare(now) {
+		return 0
+	}
+	return e.Used[period]
+}
+
+// GetResetTimeAt returns the time when the whole limit of the period is available again at now using the given algorithm
+func (e *FUPCacheEntry) GetResetTimeAt(algorithm constants.FUPAlgorithm, period constants.Period, now time.Time) time.Time {
+	if constants.FUPAlgorithmSlidingWindow == algorithm {
+		// all the counted requests slide out of the period
+		return e.GetSlidingWindowResetTime(period, 1, now)
+	}
+	return period.GetResetTimeAt(now)
+}
+
+// IncrementWith counts a request of the given cost made at now using the given algorithm (see IncrementBy and IncrementSlidingWindow)
+func (e *FUPCacheEntry) IncrementWith(algorithm constants.FUPAlgorithm, now time.Time, cost int) {
+	if constants.FUPAlgorithmSlidingWindow == algorithm {
+		e.IncrementSlidingWindow(now, cost)
+		return
+	}
+	e.incrementFixedWindow(now, cost)
+}
+
 func (e *FUPCacheEntry) Increment() {
+	e.IncrementBy(1)
+}
+
+// IncrementBy counts a request of the given cost (e.g. an export counting as 100 simple requests)
+func (e *FUPCacheEntry) IncrementBy(cost int) {
+	e.incrementFixedWindow(time.Now(), cost)
+}
+
+// incrementFixedWindow counts a request of the given cost made at now (the calendar periods use the location of now)
+func (e *FUPCacheEntry) incrementFixedWindow(now time.Time, cost int) {
+	if nil == e.Used {
+		e.Used = make(map[constants.Period]int)
+		e.UpdatedAt = now
+	}
+	for _, period := range constants.FUPScopePeriods {
+		if period.GetFormatToCompare(e.UpdatedAt.In(now.Location())) == period.GetFormatToCompare(now) {
+			e.Used[period] += cost
+			continue
+		}
+		e.Used[period] = cost
+	}
+	e.UpdatedAt = now
+}
+
+// IncrementSlidingWindow counts a request of the given cost made at now using the sliding window counter algorithm
+// (windows are the windows of the fixed window algorithm, e.g. calendar days in the location of now;
+// the previous window is kept to estimate the requests made during the last period)
+func (e *FUPCacheEntry) IncrementSlidingWindow(now time.Time, cost int) {
 	if nil == e.Used {
 		e.Used = make(map[constants.Period]int)
-		e.UpdatedAt = time.Now()
 	}
-	now := time.Now()
+	if nil == e.Previous {
+		e.Previous = make(map[constants.Period]int)
+	}
+	for _, period := range constants.FUPScopePeriods {
+		window := period.GetWindowStart(now)
+		lastWindow := period.GetWindowStart(e.UpdatedAt.In(now.Location()))
+		switch {
+		case window.Equal(lastWindow):
+			e.Used[period] += cost
+		case period.GetPreviousWindowStart(now).Equal(lastWindow):
+			e.Previous[period] = e.Used[period]
+			e.Used[period] = cost
+		default:
+			e.Previous[period] = 0
+			e.Used[period] = cost
+		}
+	}
+	e.UpdatedAt = now
+}
+
+// Refund takes back the cost of a request counted at reservedAt (see IncrementWith);
+// nothing is refunded if the window of the request is over already (the counters are never negative)
+func (e *FUPCacheEntry) Refund(algorithm constants.FUPAlgorithm, reservedAt time.Time, cost int) {
+	if nil == e.Used {
+		return
+	}
 	for _, period := range constants.FUPScopePeriods {
-		if period.GetFormatToCompare(e.UpdatedAt) == period.GetFormatToCompare(now) {
-			e.Used[period]++
+		if constants.FUPAlgorithmSlidingWindow == algorithm {
+			window := period.GetWindowStart(reservedAt)
+			lastWindow := period.GetWindowStart(e.UpdatedAt.In(reservedAt.Location()))
+			switch {
+			case window.Equal(lastWindow):
+				e.Used[period] = max(e.Used[period]-cost, 0)
+			case period.GetNextWindowStart(reservedAt).Equal(lastWindow) && nil != e.Previous:
+				e.Previous[period] = max(e.Previous[period]-cost, 0)
+			}
 			continue
 		}
-		e.Used[period] = 1
+		if period.GetFormatToCompare(e.UpdatedAt.In(reservedAt.Location())) == period.GetFormatToCompare(reservedAt) {
+			e.Used[period] = max(e.Used[period]-cost, 0)
+		}
+	}
+}
+
+// Reset clears the usage of the period at now (including the previous window of the sliding window algorithm)
+func (e *FUPCacheEntry) Reset(algorithm constants.FUPAlgorithm, period constants.Period, now time.Time) {
+	// the windows of all the periods are moved to now first, so that the usage of the other periods is kept
+	e.IncrementWith(algorithm, now, 0)
+	e.Used[period] = 0
+	if nil != e.Previous {
+		e.Previous[period] = 0
+	}
+}
+
+// Adjust adds delta to the usage of the period at now (e.g. a negative delta to give some requests back; the usage is never negative)
+func (e *FUPCacheEntry) Adjust(algorithm constants.FUPAlgorithm, period constants.Period, now time.Time, delta int) {
+	e.IncrementWith(algorithm, now, 0)
+	e.Used[period] = max(e.Used[period]+delta, 0)
+}
+
+// getSlidingWindowCounts returns the requests made in the current and in the previous window of the period at now
+func (e *FUPCacheEntry) getSlidingWindowCounts(period constants.Period, now time.Time) (int, int) {
+	window := period.GetWindowStart(now)
+	lastWindow := period.GetWindowStart(e.UpdatedAt.In(now.Location()))
+	switch {
+	case window.Equal(lastWindow):
+		return e.Used[period], e.Previous[period]
+	case period.GetPreviousWindowStart(now).Equal(lastWindow):
+		return 0, e.Used[period]
+	}
+	return 0, 0
+}
+
+// GetSlidingWindowUsed returns the estimated number of requests made during the last period at now
+// (the requests of the previous window are weighted by the part of the previous window still covered by the period)
+func (e *FUPCacheEntry) GetSlidingWindowUsed(period constants.Period, now time.Time) int {
+	current, previous := e.getSlidingWindowCounts(period, now)
+	window := period.GetWindowStart(now)
+	nextWindow := period.GetNextWindowStart(now)
+	weight := float64(nextWindow.Sub(now)) / float64(nextWindow.Sub(window))
+	return current + int(float64(previous)*weight)
+}
+
+// GetSlidingWindowResetTime returns the time when the next request fits into limit again (see GetSlidingWindowUsed)
+func (e *FUPCacheEntry) GetSlidingWindowResetTime(period constants.Period, limit int, now time.Time) time.Time {
+	current, previous := e.getSlidingWindowCounts(period, now)
+	if 0 == current && 0 == previous {
+		return now
+	}
+	window := period.GetWindowStart(now)
+	nextWindow := period.GetNextWindowStart(now)
+	if limit <= 0 {
+		// the requests never fit into the limit
+		return nextWindow
+	}
+	if previous > 0 && current < limit {
+		// the weight of the previous window decreases until the requests fit into the limit within the current window
+		duration := nextWindow.Sub(window)
+		return window.Add(time.Duration(math.Ceil(float64(duration) * (1 - float64(limit-current)/float64(previous)))))
+	}
+	// the current window becomes the previous one in the next window (which may differ in length, e.g. the next month)
+	duration := period.GetNextWindowStart(nextWindow).Sub(nextWindow)
+	elapsed := time.Duration(math.Ceil(float64(duration) * (1 - float64(limit)/float64(current))))
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return nextWindow.Add(elapsed)
+}
+
+// TokenBucketLimit is a token bucket FUP limit (the bucket holds up to Burst tokens and is refilled at Rate tokens per second; each request takes as many tokens as it costs)
+type TokenBucketLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// TokenBucketEntry is the state of a token bucket stored in the cache
+type TokenBucketEntry struct {
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Take refills the bucket up to now and takes cost tokens if there are enough of them (an empty entry is a full bucket)
+func (e *TokenBucketEntry) Take(limit TokenBucketLimit, now time.Time, cost int) bool {
+	if e.UpdatedAt.IsZero() {
+		e.Tokens = float64(limit.Burst)
+		e.UpdatedAt = now
+	} else if now.After(e.UpdatedAt) {
+		e.Tokens = math.Min(float64(limit.Burst), e.Tokens+now.Sub(e.UpdatedAt).Seconds()*limit.Rate)
+		e.UpdatedAt = now
+	}
+	if e.Tokens < float64(cost) {
+		return false
+	}
+	e.Tokens -= float64(cost)
+	return true
+}
+
+// Return puts tokens taken by a request back into the bucket (up to Burst; an empty entry is a full bucket already)
+func (e *TokenBucketEntry) Return(limit TokenBucketLimit, tokens int) {
+	if e.UpdatedAt.IsZero() {
+		return
+	}
+	e.Tokens = math.Min(float64(limit.Burst), e.Tokens+float64(tokens))
+}
+
+// GetLimits returns the bucket as FUP limits (used tokens out of the burst; ResetAt is the time when cost tokens are available)
+func (e *TokenBucketEntry) GetLimits(limit TokenBucketLimit, cost int) FUPLimits {
+	needed := math.Max(1, float64(cost))
+	limits := FUPLimits{
+		Limit:   limit.Burst,
+		Used:    limit.Burst - int(e.Tokens),
+		Period:  constants.PeriodTokenBucket,
+		ResetAt: e.UpdatedAt,
+	}
+	if e.Tokens < needed {
+		limits.ResetAt = e.UpdatedAt.Add(time.Duration(math.Ceil((needed - e.Tokens) / limit.Rate * float64(time.Second))))
 	}
-	e.UpdatedAt = time.Now()
+	return limits
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"net/http"
+	"slices"
 	"testing"
 	"time"
 )
@@ -99,6 +100,310 @@ func TestFUPCacheEntry_Increment(t *testing.T) {
 	}
 }
 
+func TestFUPCacheEntry_IncrementWith_Cost(t *testing.T) {
+	window := time.Now().Truncate(time.Hour)
+	for _, algorithm := range []constants.FUPAlgorithm{constants.FUPAlgorithmFixedWindow, constants.FUPAlgorithmSlidingWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			e := &FUPCacheEntry{}
+			e.IncrementWith(algorithm, window, 100)
+			e.IncrementWith(algorithm, window, 1)
+			e.IncrementWith(algorithm, window, 0)
+			assert.Equal(t, 101, e.GetUsed(constants.PeriodDaily))
+		})
+	}
+}
+
+func TestFUPCacheEntry_IncrementWith_Timezone(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	if nil != err {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+	// 23:30 and 00:30 UTC are both on March 11 in Prague (UTC+1)
+	evening := time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)
+	morning := evening.Add(time.Hour)
+
+	e := &FUPCacheEntry{}
+	e.IncrementWith(constants.FUPAlgorithmFixedWindow, evening, 1)
+	e.IncrementWith(constants.FUPAlgorithmFixedWindow, morning, 1)
+	assert.Equal(t, 1, e.GetUsed(constants.PeriodDaily))
+
+	e = &FUPCacheEntry{}
+	e.IncrementWith(constants.FUPAlgorithmFixedWindow, evening.In(prague), 1)
+	e.IncrementWith(constants.FUPAlgorithmFixedWindow, morning.In(prague), 1)
+	assert.Equal(t, 2, e.GetUsed(constants.PeriodDaily))
+	e.Refund(constants.FUPAlgorithmFixedWindow, evening.In(prague), 1)
+	assert.Equal(t, 1, e.GetUsed(constants.PeriodDaily))
+
+	// the daily limit resets at midnight in Prague
+	assert.True(t, time.Date(2024, 3, 11, 23, 0, 0, 0, time.UTC).Equal(constants.PeriodDaily.GetResetTimeAt(morning.In(prague)).Add(time.Nanosecond)))
+	assert.True(t, time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC).Equal(constants.PeriodYearly.GetResetTimeAt(morning.In(prague)).Add(time.Nanosecond)))
+}
+
+func TestRegisterPeriod(t *testing.T) {
+	tenSeconds := constants.Period("10s")
+	quarterly := constants.Period("quarterly")
+	if !slices.Contains(constants.FUPScopePeriods, tenSeconds) {
+		constants.RegisterPeriod(tenSeconds, constants.PeriodDefinition{Duration: time.Second * 10})
+		constants.RegisterPeriod(quarterly, constants.PeriodDefinition{
+			Duration: time.Hour * 24 * 91,
+			Start: func(t time.Time) time.Time {
+				return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, t.Location())
+			},
+			End: func(t time.Time) time.Time {
+				return time.Date(t.Year(), t.Month()-(t.Month()-1)%3+3, 1, 0, 0, 0, 0, t.Location()).Add(-time.Nanosecond)
+			},
+		})
+	}
+	for _, period := range []constants.Period{"", "daily", "bucket", "cost", "a.b", tenSeconds} {
+		assert.Panics(t, func() { constants.RegisterPeriod(period, constants.PeriodDefinition{Duration: time.Second}) }, period)
+	}
+	assert.Panics(t, func() { constants.RegisterPeriod("invalid", constants.PeriodDefinition{}) })
+
+	now := time.Date(2024, 5, 20, 10, 0, 7, 0, time.UTC)
+	assert.Equal(t, time.Second*10, tenSeconds.GetDuration())
+	assert.Equal(t, now.Add(time.Second*3), tenSeconds.GetResetTimeAt(now))
+	assert.Equal(t, time.Date(2024, 6, 30, 23, 59, 59, 999999999, time.UTC), quarterly.GetResetTimeAt(now))
+	assert.Equal(t, quarterly.GetFormatToCompare(now), quarterly.GetFormatToCompare(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)))
+	assert.NotEqual(t, quarterly.GetFormatToCompare(now), quarterly.GetFormatToCompare(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)))
+
+	e := &FUPCacheEntry{}
+	e.IncrementWith(constants.FUPAlgorithmFixedWindow, now, 1)
+	e.IncrementWith(constants.FUPAlgorithmFixedWindow, now.Add(time.Second*2), 1)
+	assert.Equal(t, 2, e.GetUsed(tenSeconds))
+	e.IncrementWith(constants.FUPAlgorithmFixedWindow, now.Add(time.Second*3), 1)
+	assert.Equal(t, 1, e.GetUsed(tenSeconds))
+	assert.Equal(t, 3, e.GetUsed(quarterly))
+}
+
+func TestFUPCacheEntry_IncrementSlidingWindow(t *testing.T) {
+	window := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		entry        FUPCacheEntry
+		now          time.Time
+		wantUsed     int
+		wantPrevious int
+	}{
+		{
+			name:         "Empty entry",
+			entry:        FUPCacheEntry{},
+			now:          window,
+			wantUsed:     1,
+			wantPrevious: 0,
+		},
+		{
+			name: "Same window",
+			entry: FUPCacheEntry{
+				UpdatedAt: window.Add(time.Second * 10),
+				Used:      map[constants.Period]int{constants.PeriodMinutely: 3},
+				Previous:  map[constants.Period]int{constants.PeriodMinutely: 5},
+			},
+			now:          window.Add(time.Second * 59),
+			wantUsed:     4,
+			wantPrevious: 5,
+		},
+		{
+			name: "Next window",
+			entry: FUPCacheEntry{
+				UpdatedAt: window.Add(time.Second * 59),
+				Used:      map[constants.Period]int{constants.PeriodMinutely: 3},
+				Previous:  map[constants.Period]int{constants.PeriodMinutely: 5},
+			},
+			now:          window.Add(time.Minute),
+			wantUsed:     1,
+			wantPrevious: 3,
+		},
+		{
+			name: "Later window",
+			entry: FUPCacheEntry{
+				UpdatedAt: window,
+				Used:      map[constants.Period]int{constants.PeriodMinutely: 3},
+				Previous:  map[constants.Period]int{constants.PeriodMinutely: 5},
+			},
+			now:          window.Add(time.Minute * 2),
+			wantUsed:     1,
+			wantPrevious: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.entry
+			e.IncrementSlidingWindow(tt.now, 1)
+			assert.Equal(t, tt.wantUsed, e.Used[constants.PeriodMinutely])
+			assert.Equal(t, tt.wantPrevious, e.Previous[constants.PeriodMinutely])
+			assert.Equal(t, tt.now, e.UpdatedAt)
+		})
+	}
+}
+
+func TestFUPCacheEntry_Refund(t *testing.T) {
+	window := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		algorithm    constants.FUPAlgorithm
+		entry        FUPCacheEntry
+		reservedAt   time.Time
+		wantUsed     int
+		wantPrevious int
+	}{
+		{
+			name:       "Fixed window",
+			algorithm:  constants.FUPAlgorithmFixedWindow,
+			entry:      FUPCacheEntry{UpdatedAt: window.Add(time.Second * 30), Used: map[constants.Period]int{constants.PeriodMinutely: 5}},
+			reservedAt: window.Add(time.Second * 10),
+			wantUsed:   3,
+		},
+		{
+			name:       "Fixed window over",
+			algorithm:  constants.FUPAlgorithmFixedWindow,
+			entry:      FUPCacheEntry{UpdatedAt: window.Add(time.Minute), Used: map[constants.Period]int{constants.PeriodMinutely: 5}},
+			reservedAt: window.Add(time.Second * 10),
+			wantUsed:   5,
+		},
+		{
+			name:       "Never negative",
+			algorithm:  constants.FUPAlgorithmFixedWindow,
+			entry:      FUPCacheEntry{UpdatedAt: window, Used: map[constants.Period]int{constants.PeriodMinutely: 1}},
+			reservedAt: window,
+			wantUsed:   0,
+		},
+		{
+			name:         "Sliding window",
+			algorithm:    constants.FUPAlgorithmSlidingWindow,
+			entry:        FUPCacheEntry{UpdatedAt: window.Add(time.Second * 30), Used: map[constants.Period]int{constants.PeriodMinutely: 5}, Previous: map[constants.Period]int{constants.PeriodMinutely: 7}},
+			reservedAt:   window.Add(time.Second * 10),
+			wantUsed:     3,
+			wantPrevious: 7,
+		},
+		{
+			name:         "Sliding window moved on",
+			algorithm:    constants.FUPAlgorithmSlidingWindow,
+			entry:        FUPCacheEntry{UpdatedAt: window.Add(time.Second * 70), Used: map[constants.Period]int{constants.PeriodMinutely: 5}, Previous: map[constants.Period]int{constants.PeriodMinutely: 7}},
+			reservedAt:   window.Add(time.Second * 10),
+			wantUsed:     5,
+			wantPrevious: 5,
+		},
+		{
+			name:         "Sliding window over",
+			algorithm:    constants.FUPAlgorithmSlidingWindow,
+			entry:        FUPCacheEntry{UpdatedAt: window.Add(time.Minute * 2), Used: map[constants.Period]int{constants.PeriodMinutely: 5}, Previous: map[constants.Period]int{constants.PeriodMinutely: 7}},
+			reservedAt:   window.Add(time.Second * 10),
+			wantUsed:     5,
+			wantPrevious: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.entry
+			e.Refund(tt.algorithm, tt.reservedAt, 2)
+			assert.Equal(t, tt.wantUsed, e.Used[constants.PeriodMinutely])
+			assert.Equal(t, tt.wantPrevious, e.Previous[constants.PeriodMinutely])
+		})
+	}
+}
+
+func TestFUPCacheEntry_GetSlidingWindowUsed(t *testing.T) {
+	window := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	e := &FUPCacheEntry{}
+	for i := 0; i < 10; i++ {
+		e.IncrementSlidingWindow(window.Add(time.Second*59), 1)
+	}
+	assert.Equal(t, 10, e.GetSlidingWindowUsed(constants.PeriodMinutely, window.Add(time.Second*59)))
+
+	// the requests made at the end of the previous window still count at the beginning of the next one
+	e.IncrementSlidingWindow(window.Add(time.Minute), 1)
+	assert.Equal(t, 11, e.GetSlidingWindowUsed(constants.PeriodMinutely, window.Add(time.Minute)))
+	// a half of the previous window is still covered by the last minute
+	assert.Equal(t, 6, e.GetSlidingWindowUsed(constants.PeriodMinutely, window.Add(time.Second*90)))
+	// only the previous window is covered
+	assert.Equal(t, 1, e.GetSlidingWindowUsed(constants.PeriodMinutely, window.Add(time.Minute*2)))
+	assert.Equal(t, 0, e.GetSlidingWindowUsed(constants.PeriodMinutely, window.Add(time.Minute*3)))
+}
+
+func TestFUPCacheEntry_GetSlidingWindowResetTime(t *testing.T) {
+	window := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		entry FUPCacheEntry
+		limit int
+		now   time.Time
+		want  time.Time
+	}{
+		{
+			name:  "Empty entry",
+			entry: FUPCacheEntry{},
+			limit: 10,
+			now:   window,
+			want:  window,
+		},
+		{
+			name: "Previous window weight decreases",
+			entry: FUPCacheEntry{
+				UpdatedAt: window,
+				Used:      map[constants.Period]int{constants.PeriodMinutely: 1},
+				Previous:  map[constants.Period]int{constants.PeriodMinutely: 10},
+			},
+			limit: 10,
+			now:   window,
+			want:  window.Add(time.Second * 6),
+		},
+		{
+			name: "Current window exhausted",
+			entry: FUPCacheEntry{
+				UpdatedAt: window.Add(time.Second * 30),
+				Used:      map[constants.Period]int{constants.PeriodMinutely: 20},
+				Previous:  map[constants.Period]int{constants.PeriodMinutely: 0},
+			},
+			limit: 10,
+			now:   window.Add(time.Second * 30),
+			want:  window.Add(time.Second * 90),
+		},
+		{
+			name: "Zero limit",
+			entry: FUPCacheEntry{
+				UpdatedAt: window,
+				Used:      map[constants.Period]int{constants.PeriodMinutely: 1},
+			},
+			limit: 0,
+			now:   window,
+			want:  window.Add(time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.entry.GetSlidingWindowResetTime(constants.PeriodMinutely, tt.limit, tt.now))
+		})
+	}
+}
+
+func TestFUPCacheEntry_SlidingWindowCalendar(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	if nil != err {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+	// the daily windows start at midnight in the location of now (not at midnight UTC)
+	e := &FUPCacheEntry{}
+	e.IncrementSlidingWindow(time.Date(2024, 3, 4, 23, 30, 0, 0, prague), 10)
+	e.IncrementSlidingWindow(time.Date(2024, 3, 5, 0, 30, 0, 0, prague), 1)
+	assert.Equal(t, 1, e.Used[constants.PeriodDaily])
+	assert.Equal(t, 10, e.Previous[constants.PeriodDaily])
+	// a half of the previous day is still covered by the last day at noon
+	assert.Equal(t, 6, e.GetSlidingWindowUsed(constants.PeriodDaily, time.Date(2024, 3, 5, 12, 0, 0, 0, prague)))
+
+	// a day with a DST change is 23 hours long
+	dstDay := time.Date(2024, 3, 31, 12, 0, 0, 0, prague)
+	assert.Equal(t, time.Hour*23, constants.PeriodDaily.GetNextWindowStart(dstDay).Sub(constants.PeriodDaily.GetWindowStart(dstDay)))
+
+	// the monthly windows are calendar months (February 2024 has 29 days)
+	e = &FUPCacheEntry{}
+	e.IncrementSlidingWindow(time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), 29)
+	assert.Equal(t, 15, e.GetSlidingWindowUsed(constants.PeriodMonthly, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)))
+	e = &FUPCacheEntry{}
+	e.IncrementSlidingWindow(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), 20)
+	// the requests of January fit into the limit once a half of February is over
+	assert.Equal(t, time.Date(2024, 2, 15, 12, 0, 0, 0, time.UTC), e.GetSlidingWindowResetTime(constants.PeriodMonthly, 10, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)))
+}
+
 func TestFUPScopeLimits_GetLimitsHeader(t *testing.T) {
 	type fields struct {
 		Accessible constants.ScopeAccessibility
@@ -215,7 +520,23 @@ func TestFUPScopeLimits_GetRetryAfter(t *testing.T) {
 				},
 				Error: nil,
 			},
-			want: int(time.Until(constants.PeriodHourly.GetResetTime()).Seconds()),
+			want: FUPLimits{ResetAt: constants.PeriodHourly.GetResetTime()}.GetResetAfter(time.Now()),
+		},
+		{
+			name: "Limits with reset time",
+			fields: fields{
+				Accessible: constants.ScopeAccessibilityForbidden,
+				Limits: map[constants.Period]FUPLimits{
+					constants.PeriodHourly: {
+						Limit:   1,
+						Used:    2,
+						ResetAt: time.Now().Add(time.Second * 90),
+					},
+				},
+				Error: nil,
+			},
+			// rounded up as the RateLimit-Reset header
+			want: 90,
 		},
 	}
 	for _, tt := range tests {
@@ -229,3 +550,139 @@ func TestFUPScopeLimits_GetRetryAfter(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenBucketEntry_Take(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	limit := TokenBucketLimit{Rate: 10, Burst: 50}
+	e := &TokenBucketEntry{}
+	for i := 0; i < 50; i++ {
+		assert.Truef(t, e.Take(limit, now, 1), "Take() #%d", i)
+	}
+	assert.False(t, e.Take(limit, now, 1))
+	assert.Equal(t, FUPLimits{
+		Limit:   50,
+		Used:    50,
+		Period:  constants.PeriodTokenBucket,
+		ResetAt: now.Add(time.Millisecond * 100),
+	}, e.GetLimits(limit, 1))
+
+	// the bucket is refilled at the sustained rate
+	assert.True(t, e.Take(limit, now.Add(time.Millisecond*100), 1))
+	assert.False(t, e.Take(limit, now.Add(time.Millisecond*150), 1))
+	assert.True(t, e.Take(limit, now.Add(time.Millisecond*200), 1))
+
+	// the bucket is never refilled over the burst capacity
+	assert.True(t, e.Take(limit, now.Add(time.Hour), 1))
+	assert.Equal(t, 49.0, e.Tokens)
+	assert.Equal(t, now.Add(time.Hour), e.GetLimits(limit, 1).ResetAt)
+}
+
+func TestTokenBucketEntry_TakeCost(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	limit := TokenBucketLimit{Rate: 10, Burst: 50}
+	e := &TokenBucketEntry{}
+	assert.True(t, e.Take(limit, now, 20))
+	assert.True(t, e.Take(limit, now, 20))
+	assert.False(t, e.Take(limit, now, 20))
+	assert.Equal(t, 10.0, e.Tokens)
+	// the whole cost is available in a second
+	assert.Equal(t, now.Add(time.Second), e.GetLimits(limit, 20).ResetAt)
+
+	e.Return(limit, 20)
+	assert.Equal(t, 30.0, e.Tokens)
+	// the bucket never holds more than the burst
+	e.Return(limit, 40)
+	assert.Equal(t, 50.0, e.Tokens)
+}
+
+func TestFUPScopeLimits_GetErrorCode(t *testing.T) {
+	limits := &FUPScopeLimits{
+		Accessible: constants.ScopeAccessibilityForbidden,
+		Limits:     map[constants.Period]FUPLimits{constants.PeriodHourly: {Limit: 1, Used: 2}},
+	}
+	assert.Equal(t, RequestLimitDepleted, limits.GetErrorCode())
+	limits.Limits = map[constants.Period]FUPLimits{constants.PeriodConcurrent: {Limit: 1, Used: 1}}
+	assert.Equal(t, ConcurrencyLimitExceeded, limits.GetErrorCode())
+}
+
+func TestFUPScopeLimits_GetMostRestrictiveLimits(t *testing.T) {
+	now := time.Now()
+	assert.Nil(t, (&FUPScopeLimits{}).GetMostRestrictiveLimits())
+
+	minutely := FUPLimits{Limit: 10, Used: 5, Period: constants.PeriodMinutely, ResetAt: now.Add(time.Second * 30)}
+	hourly := FUPLimits{Limit: 100, Used: 95, Period: constants.PeriodHourly, ResetAt: now.Add(time.Minute * 30)}
+	daily := FUPLimits{Limit: 1000, Used: 100, Period: constants.PeriodDaily, ResetAt: now.Add(time.Hour * 12)}
+	limits := &FUPScopeLimits{Limits: map[constants.Period]FUPLimits{
+		constants.PeriodMinutely: minutely,
+		constants.PeriodHourly:   hourly,
+		constants.PeriodDaily:    daily,
+	}}
+	// the same remaining quota, the hourly limit resets later
+	assert.Equal(t, hourly, *limits.GetMostRestrictiveLimits())
+
+	minutely.Used = 9
+	limits.Limits[constants.PeriodMinutely] = minutely
+	assert.Equal(t, minutely, *limits.GetMostRestrictiveLimits())
+
+	// the remaining quota is never negative
+	exceeded := FUPLimits{Limit: 10, Used: 12, Period: constants.PeriodMinutely}
+	assert.Equal(t, 0, exceeded.GetRemaining())
+}
+
+func TestFUPLimits_GetResetAfter(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, -1, FUPLimits{Limit: 5, Period: constants.PeriodConcurrent}.GetResetAfter(now))
+	assert.Equal(t, 30, FUPLimits{ResetAt: now.Add(time.Millisecond * 29500)}.GetResetAfter(now))
+	assert.Equal(t, 0, FUPLimits{ResetAt: now.Add(-time.Second)}.GetResetAfter(now))
+}
+
+func TestFUPCacheEntry_GetUsedAt(t *testing.T) {
+	window := time.Now().Truncate(time.Hour)
+	e := &FUPCacheEntry{}
+	assert.Equal(t, 0, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window))
+	e.IncrementWith(constants.FUPAlgorithmFixedWindow, window.Add(time.Minute), 4)
+	assert.Equal(t, 4, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Minute*30)))
+	// the window is over
+	assert.Equal(t, 0, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Hour)))
+	assert.Equal(t, constants.PeriodHourly.GetResetTimeAt(window), e.GetResetTimeAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window))
+
+	e = &FUPCacheEntry{}
+	e.IncrementWith(constants.FUPAlgorithmSlidingWindow, window.Add(time.Minute), 4)
+	// a half of the previous window is still covered by the last hour
+	assert.Equal(t, 2, e.GetUsedAt(constants.FUPAlgorithmSlidingWindow, constants.PeriodHourly, window.Add(time.Minute*90)))
+	// the estimated usage drops to 0 once less than a quarter of the previous window is covered
+	assert.Equal(t, window.Add(time.Minute*105), e.GetResetTimeAt(constants.FUPAlgorithmSlidingWindow, constants.PeriodHourly, window.Add(time.Minute*90)))
+}
+
+func TestFUPCacheEntry_Reset(t *testing.T) {
+	window := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	e := &FUPCacheEntry{}
+	e.IncrementWith(constants.FUPAlgorithmSlidingWindow, window.Add(-time.Minute), 4)
+	e.IncrementWith(constants.FUPAlgorithmSlidingWindow, window.Add(time.Minute), 2)
+	e.Reset(constants.FUPAlgorithmSlidingWindow, constants.PeriodHourly, window.Add(time.Minute*2))
+	// the requests of the previous window are cleared too
+	assert.Equal(t, 0, e.GetUsedAt(constants.FUPAlgorithmSlidingWindow, constants.PeriodHourly, window.Add(time.Minute*2)))
+	// the other periods are kept
+	assert.Equal(t, 6, e.GetUsedAt(constants.FUPAlgorithmSlidingWindow, constants.PeriodWeekly, window.Add(time.Minute*2)))
+
+	// an empty entry can be reset
+	e = &FUPCacheEntry{}
+	e.Reset(constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, window)
+	assert.Equal(t, 0, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, window))
+}
+
+func TestFUPCacheEntry_Adjust(t *testing.T) {
+	window := time.Now().Truncate(time.Hour)
+	e := &FUPCacheEntry{}
+	e.Adjust(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window, 5)
+	assert.Equal(t, 5, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Minute)))
+	assert.Equal(t, 0, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodDaily, window.Add(time.Minute)))
+	e.Adjust(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Minute), -2)
+	assert.Equal(t, 3, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Minute)))
+	// the usage is never negative
+	e.Adjust(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Minute), -10)
+	assert.Equal(t, 0, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Minute)))
+	// the usage of a past window is not adjusted
+	e.Adjust(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Hour), 1)
+	assert.Equal(t, 1, e.GetUsedAt(constants.FUPAlgorithmFixedWindow, constants.PeriodHourly, window.Add(time.Hour)))
+}
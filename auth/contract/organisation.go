@@ -0,0 +1,20 @@
+package contract
+
+// ApiOrganisationInterface represents an organisation (tenant) that api clients and users belong to
+type ApiOrganisationInterface interface {
+	GetID() string
+	GetFUPScope() *FUPScope
+}
+
+// TenantAwareInterface is implemented by api clients/users that belong to an organisation (tenant)
+type TenantAwareInterface interface {
+	GetTenantId() string
+}
+
+// GetTenantId returns the tenant id of the given entity or an empty string if the entity is not tenant-aware
+func GetTenantId(entity any) string {
+	if tenantAware, ok := entity.(TenantAwareInterface); ok {
+		return tenantAware.GetTenantId()
+	}
+	return ""
+}
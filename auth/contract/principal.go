@@ -0,0 +1,64 @@
+package contract
+
+import (
+	"context"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"time"
+)
+
+// Principal describes the authenticated request (who is calling and how they authenticated)
+type Principal struct {
+	// ApiClient: the authenticated api client
+	ApiClient ApiClientInterface
+	// ApiUser: the authenticated api user (nil if the request is not made on behalf of a user)
+	ApiUser ApiUserInterface
+	// Method: how the api client authenticated (client id + secret, api key, additional api key, one-off token, signed URL)
+	Method constants.AuthenticationMethod
+	// KeyId: the identifier of the additional api key used to authenticate (see KeyIdAwareInterface; empty otherwise)
+	KeyId string
+	// TenantId: the organisation (tenant) of the request (empty if the client and the user don't belong to any)
+	TenantId string
+	// EffectiveScope: the access scope the request was authorized against (client scope combined with user scope, see ScopeCombination; nil if the scope access model is disabled)
+	EffectiveScope *AccessScope
+	// ClientFUPLimits, UserFUPLimits, OrganisationFUPLimits: the FUP limits checked for the request (nil if not checked)
+	ClientFUPLimits       map[constants.Period]FUPLimits
+	UserFUPLimits         map[constants.Period]FUPLimits
+	OrganisationFUPLimits map[constants.Period]FUPLimits
+	// ExpiresAt: expiration of the credentials used (the api user token or the additional api key; nil if they don't expire)
+	ExpiresAt *time.Time
+}
+
+// GetApiClient returns the authenticated api client (nil if the principal is nil, i.e. the request is not authenticated)
+func (p *Principal) GetApiClient() ApiClientInterface {
+	if nil == p {
+		return nil
+	}
+	return p.ApiClient
+}
+
+// GetApiUser returns the authenticated api user (nil if the principal is nil or the request is not made on behalf of a user)
+func (p *Principal) GetApiUser() ApiUserInterface {
+	if nil == p {
+		return nil
+	}
+	return p.ApiUser
+}
+
+// GetPrincipal returns the principal of the authenticated request or nil if the request is not authenticated
+func GetPrincipal(c ValueGetter) *Principal {
+	if isNilGetter(c) {
+		return nil
+	}
+	value, ok := c.Get(constants.Principal)
+	if !ok {
+		return nil
+	}
+	principal, _ := value.(*Principal)
+	return principal
+}
+
+// PrincipalFromContext returns the principal stored in ctx (see NewAuthContext) or nil
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey).(*Principal)
+	return principal
+}
@@ -0,0 +1,23 @@
+package contract
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"testing"
+)
+
+func TestGetPrincipal(t *testing.T) {
+	c := &gin.Context{}
+	assert.Nil(t, GetPrincipal(nil))
+	assert.Nil(t, GetPrincipal(c))
+	assert.Nil(t, GetPrincipal(c).GetApiClient())
+	assert.Nil(t, GetPrincipal(c).GetApiUser())
+	assert.Nil(t, PrincipalFromContext(NewAuthContext(context.Background(), c)))
+
+	principal := &Principal{Method: constants.AuthenticationMethodApiKey, TenantId: "tenant"}
+	c.Set(constants.Principal, principal)
+	assert.Equal(t, principal, GetPrincipal(c))
+	assert.Equal(t, principal, PrincipalFromContext(NewAuthContext(context.Background(), c)))
+}
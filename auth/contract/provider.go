@@ -5,6 +5,18 @@ type ApiClientProviderInterface[T ApiClientInterface] interface {
 	ProvideByApiKey(apiKey string) (ApiClientInterface, *AuthError)
 	Save(client ApiClientInterface) *AuthError
 }
+
+// ApiClientByIdProviderInterface is implemented by client providers that can load a client by its id only
+// (required by the signed URL authentication mode and the FUP administration of clients)
+type ApiClientByIdProviderInterface interface {
+	ProvideByClientId(id string) (ApiClientInterface, *AuthError)
+}
+type ApiOrganisationProviderInterface interface {
+	ProvideById(id string) (ApiOrganisationInterface, *AuthError)
+}
+type ApiRoleProviderInterface interface {
+	ProvideByNames(names []string) ([]ApiRoleInterface, *AuthError)
+}
 type ApiUserProviderInterface[T ApiUserInterface] interface {
 	ProvideByLoginAndPassword(login string, password string) (ApiUserInterface, *AuthError)
 	ProvideByLogin(login string) (ApiUserInterface, *AuthError)
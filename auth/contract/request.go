@@ -0,0 +1,281 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// ResponseWriter is the writer of the response of a RequestContext (the status is needed for the post-handler FUP accounting)
+type ResponseWriter interface {
+	http.ResponseWriter
+	// Status returns the status code of the response (200 if not written yet)
+	Status() int
+}
+
+// RequestContext is the request/response abstraction the authentication pipeline (security, checkers, FUP checkers, routes) works with;
+// it is implemented for gin (see NewGinContext) and for net/http (see NewHttpContext)
+type RequestContext interface {
+	// GetRequest returns the HTTP request (may be nil)
+	GetRequest() *http.Request
+	// GetWriter returns the response writer (may be nil)
+	GetWriter() ResponseWriter
+	// ClientIP returns the IP address of the client
+	ClientIP() string
+	// FullPath returns the template of the matched route (e.g. `/orders/:id`) or an empty string
+	FullPath() string
+	// Param returns the value of the route parameter (e.g. `id` of `/orders/:id`) or an empty string
+	Param(key string) string
+	// Cookie returns the (unescaped) value of the request cookie or http.ErrNoCookie
+	Cookie(name string) (string, error)
+	// Header sets a response header (an empty value removes the header)
+	Header(key string, value string)
+	// ShouldBindJSON decodes and validates the JSON request body (see the `binding` tags)
+	ShouldBindJSON(obj any) error
+	// JSON writes obj as the JSON response with the status code
+	JSON(code int, obj any)
+	// Get and Set access the values of the request (the authenticated client and user, the FUP charges etc.)
+	Get(key string) (any, bool)
+	Set(key string, value any)
+}
+
+// ValueGetter reads the values of the request; it is implemented by RequestContext and *gin.Context
+type ValueGetter interface {
+	Get(key string) (any, bool)
+}
+
+// ValueSetter stores the values of the request; it is implemented by RequestContext and *gin.Context
+type ValueSetter interface {
+	Set(key string, value any)
+}
+
+// isNilGetter returns true if c is nil (including a nil *gin.Context, which can't be read)
+func isNilGetter(c ValueGetter) bool {
+	if nil == c {
+		return true
+	}
+	ginContext, ok := c.(*gin.Context)
+	return ok && nil == ginContext
+}
+
+// GetValue returns the value stored in c under key (false if c is nil or the value is not set)
+func GetValue(c ValueGetter, key string) (any, bool) {
+	if isNilGetter(c) {
+		return nil, false
+	}
+	return c.Get(key)
+}
+
+// GetString returns the string value stored in c under key or an empty string
+func GetString(c ValueGetter, key string) string {
+	value, _ := GetValue(c, key)
+	stringValue, _ := value.(string)
+	return stringValue
+}
+
+// ginContext is the gin implementation of the RequestContext (the values are stored in the gin context, so gin handlers can read them)
+type ginContext struct {
+	*gin.Context
+}
+
+func (c ginContext) GetRequest() *http.Request {
+	return c.Context.Request
+}
+
+func (c ginContext) GetWriter() ResponseWriter {
+	if nil == c.Context.Writer {
+		return nil
+	}
+	return c.Context.Writer
+}
+
+// NewGinContext returns the RequestContext of the gin context (nil if c is nil)
+func NewGinContext(c *gin.Context) RequestContext {
+	if nil == c {
+		return nil
+	}
+	return ginContext{Context: c}
+}
+
+// GetGinContext returns the gin context of the RequestContext or nil if the request is not handled by gin
+func GetGinContext(c RequestContext) *gin.Context {
+	if typedContext, ok := c.(ginContext); ok {
+		return typedContext.Context
+	}
+	return nil
+}
+
+// statusWriter records the status code written to the wrapped writer
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if 0 == w.status {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(data []byte) (int, error) {
+	if 0 == w.status {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *statusWriter) Status() int {
+	if 0 == w.status {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush supports streaming responses of the wrapped writer
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the wrapped writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// httpValues are the values of a request shared by the HttpContexts of the request (e.g. of the middleware and of the routes)
+type httpValues struct {
+	lock   sync.RWMutex
+	values map[string]any
+}
+
+type httpContextKey struct{}
+
+// HttpContext is the net/http implementation of the RequestContext
+type HttpContext struct {
+	writer  *statusWriter
+	request *http.Request
+	values  *httpValues
+	// Route is the template of the matched route (see FullPath) and Params are the values of its parameters (see Param)
+	Route  string
+	Params map[string]string
+}
+
+// NewHttpContext returns the RequestContext of the net/http request; the values are shared with the HttpContext
+// the request has been passed on by (see HttpContext.Request), so that e.g. the routes see the client authenticated by the middleware
+// NOTE: ClientIP returns the host of http.Request.RemoteAddr, use a middleware setting it from trusted proxy headers if needed
+func NewHttpContext(w http.ResponseWriter, r *http.Request) *HttpContext {
+	c := &HttpContext{request: r, values: &httpValues{values: make(map[string]any)}}
+	if nil != r {
+		if parent, ok := r.Context().Value(httpContextKey{}).(*HttpContext); ok {
+			c.values = parent.values
+		}
+	}
+	if writer, ok := w.(*statusWriter); ok {
+		c.writer = writer
+	} else if nil != w {
+		c.writer = &statusWriter{ResponseWriter: w}
+	}
+	return c
+}
+
+// Request returns the request to pass to the next handler: its context carries the HttpContext (see NewHttpContext)
+// and the principal, client, user, tenant and effective scope of the request (see NewAuthContext)
+func (c *HttpContext) Request() *http.Request {
+	ctx := context.WithValue(NewAuthContext(c.request.Context(), c), httpContextKey{}, c)
+	return c.request.WithContext(ctx)
+}
+
+func (c *HttpContext) GetRequest() *http.Request {
+	return c.request
+}
+
+func (c *HttpContext) GetWriter() ResponseWriter {
+	if nil == c.writer {
+		return nil
+	}
+	return c.writer
+}
+
+func (c *HttpContext) ClientIP() string {
+	if nil == c.request {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(c.request.RemoteAddr)
+	if nil != err {
+		return c.request.RemoteAddr
+	}
+	return host
+}
+
+func (c *HttpContext) FullPath() string {
+	return c.Route
+}
+
+func (c *HttpContext) Param(key string) string {
+	return c.Params[key]
+}
+
+func (c *HttpContext) Cookie(name string) (string, error) {
+	if nil == c.request {
+		return "", http.ErrNoCookie
+	}
+	cookie, err := c.request.Cookie(name)
+	if nil != err {
+		return "", err
+	}
+	return url.QueryUnescape(cookie.Value)
+}
+
+func (c *HttpContext) Header(key string, value string) {
+	if nil == c.writer {
+		return
+	}
+	if "" == value {
+		c.writer.Header().Del(key)
+		return
+	}
+	c.writer.Header().Set(key, value)
+}
+
+func (c *HttpContext) ShouldBindJSON(obj any) error {
+	return binding.JSON.Bind(c.request, obj)
+}
+
+// JSON writes the response (the response is dropped if the context has no writer, e.g. a context of a gRPC call)
+func (c *HttpContext) JSON(code int, obj any) {
+	if nil == c.writer {
+		return
+	}
+	body, err := json.Marshal(obj)
+	if nil != err {
+		log.Printf("can't marshal the response: %v", err)
+		code = http.StatusInternalServerError
+		body = nil
+	}
+	c.writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	c.writer.WriteHeader(code)
+	if _, err = c.writer.Write(body); nil != err {
+		log.Printf("can't write the response: %v", err)
+	}
+}
+
+func (c *HttpContext) Get(key string) (any, bool) {
+	c.values.lock.RLock()
+	defer c.values.lock.RUnlock()
+	value, ok := c.values.values[key]
+	return value, ok
+}
+
+func (c *HttpContext) Set(key string, value any) {
+	c.values.lock.Lock()
+	defer c.values.lock.Unlock()
+	c.values.values[key] = value
+}
@@ -0,0 +1,82 @@
+package contract
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHttpContext(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.AddCookie(&http.Cookie{Name: "fup", Value: "visitor%201"})
+	c := NewHttpContext(recorder, request)
+	c.Route = "/orders/:id"
+	c.Params = map[string]string{"id": "42"}
+
+	assert.Equal(t, "10.0.0.1", c.ClientIP())
+	assert.Equal(t, "/orders/:id", c.FullPath())
+	assert.Equal(t, "42", c.Param("id"))
+	assert.Equal(t, "", c.Param("unknown"))
+	cookie, err := c.Cookie("fup")
+	assert.Nil(t, err)
+	assert.Equal(t, "visitor 1", cookie)
+	_, err = c.Cookie("unknown")
+	assert.Equal(t, http.ErrNoCookie, err)
+
+	// the values are shared with the contexts of the request passed on
+	c.Set(constants.TenantId, "tenant")
+	next := NewHttpContext(c.GetWriter(), c.Request())
+	assert.Equal(t, "tenant", GetString(next, constants.TenantId))
+	assert.Equal(t, "tenant", TenantIdFromContext(c.Request().Context()))
+	next.Set(constants.FUPCost, 5)
+	value, ok := c.Get(constants.FUPCost)
+	assert.True(t, ok)
+	assert.Equal(t, 5, value)
+
+	c.Header("X-Test", "value")
+	c.Header("X-Removed", "value")
+	c.Header("X-Removed", "")
+	assert.Equal(t, http.StatusOK, c.GetWriter().Status())
+	next.JSON(http.StatusTeapot, map[string]string{"status": "ok"})
+	assert.Equal(t, http.StatusTeapot, c.GetWriter().Status())
+	assert.Equal(t, http.StatusTeapot, recorder.Code)
+	assert.Equal(t, "value", recorder.Header().Get("X-Test"))
+	assert.Equal(t, "", recorder.Header().Get("X-Removed"))
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `{"status":"ok"}`, recorder.Body.String())
+}
+
+func TestHttpContext_WithoutWriter(t *testing.T) {
+	c := NewHttpContext(nil, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Nil(t, c.GetWriter())
+	// the response of a denied request is dropped
+	assert.NotPanics(t, func() {
+		c.Header("X-Test", "value")
+		c.JSON(http.StatusUnauthorized, map[string]string{"status": "denied"})
+	})
+}
+
+func TestGinContext(t *testing.T) {
+	assert.Nil(t, NewGinContext(nil))
+	assert.Equal(t, "", GetString(nil, constants.TenantId))
+	assert.Equal(t, "", GetString((*gin.Context)(nil), constants.TenantId))
+
+	recorder := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	c := NewGinContext(ginContext)
+	assert.Equal(t, ginContext, GetGinContext(c))
+	assert.Nil(t, GetGinContext(NewHttpContext(nil, nil)))
+
+	// the values are stored in the gin context
+	c.Set(constants.TenantId, "tenant")
+	assert.Equal(t, "tenant", ginContext.GetString(constants.TenantId))
+	assert.Equal(t, ginContext.Request, c.GetRequest())
+	c.GetWriter().WriteHeader(http.StatusAccepted)
+	assert.Equal(t, http.StatusAccepted, c.GetWriter().Status())
+}
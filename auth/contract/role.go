@@ -0,0 +1,173 @@
+package contract
+
+import "github.com/wernerdweight/api-auth-go/v2/auth/constants"
+
+type ApiRoleInterface interface {
+	GetName() string
+	GetAccessScope() *AccessScope
+	GetFUPScope() *FUPScope
+}
+
+// RoleHolderInterface is implemented by api clients/users that can be assigned roles
+type RoleHolderInterface interface {
+	GetRoles() []string
+}
+
+// RoleScopes holds the scopes merged from a set of roles
+type RoleScopes struct {
+	AccessScope *AccessScope `json:"accessScope"`
+	FUPScope    *FUPScope    `json:"fupScope"`
+}
+
+func asScopeMap(value any) (map[string]any, bool) {
+	switch typedValue := value.(type) {
+	case AccessScope:
+		return typedValue, true
+	case FUPScope:
+		return typedValue, true
+	case map[string]any:
+		return typedValue, true
+	}
+	return nil, false
+}
+
+func getAccessRank(value any) int {
+	switch typedValue := value.(type) {
+	case bool:
+		if typedValue {
+			return 2
+		}
+	case string:
+		if typedValue == string(constants.ScopeAccessibilityAccessible) {
+			return 2
+		}
+		if typedValue == string(constants.ScopeAccessibilityOnBehalf) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func getNumber(value any) (float64, bool) {
+	switch typedValue := value.(type) {
+	case int:
+		return float64(typedValue), true
+	case float64:
+		return typedValue, true
+	case float32:
+		return float64(typedValue), true
+	}
+	return 0, false
+}
+
+func getLimitRank(value any) float64 {
+	limit, ok := getNumber(value)
+	if !ok {
+		return 0
+	}
+	if limit < 0 {
+		// negative limit means unlimited
+		return float64(^uint(0) >> 1)
+	}
+	return limit
+}
+
+// isMorePermissiveAccess returns true if the access value of a role is more permissive than the current one
+func isMorePermissiveAccess(key string, current any, value any) bool {
+	return getAccessRank(value) > getAccessRank(current)
+}
+
+// isMorePermissiveFUP returns true if the FUP value of a role is more permissive than the current one
+// (a higher limit, a lower cost; the time zone of the first role is kept)
+func isMorePermissiveFUP(key string, current any, value any) bool {
+	switch key {
+	case constants.FUPCostKey:
+		valueCost, valueOk := getNumber(value)
+		currentCost, currentOk := getNumber(current)
+		return valueOk && (!currentOk || valueCost < currentCost)
+	case constants.FUPTimezoneKey:
+		return false
+	}
+	return getLimitRank(value) > getLimitRank(current)
+}
+
+// mergeScopeMaps merges source into target; on leaf conflicts, override decides whether source replaces target
+// (override=true) or whether the more permissive value is kept (override=false)
+func mergeScopeMaps(target map[string]any, source map[string]any, override bool, isMorePermissive func(key string, current any, value any) bool) map[string]any {
+	if nil == target {
+		target = map[string]any{}
+	}
+	for key, value := range source {
+		current, exists := target[key]
+		if !exists {
+			target[key] = copyScopeValue(value)
+			continue
+		}
+		currentMap, currentIsMap := asScopeMap(current)
+		valueMap, valueIsMap := asScopeMap(value)
+		if currentIsMap && valueIsMap {
+			target[key] = mergeScopeMaps(copyScopeMap(currentMap), valueMap, override, isMorePermissive)
+			continue
+		}
+		if override || currentIsMap || valueIsMap || isMorePermissive(key, current, value) {
+			target[key] = copyScopeValue(value)
+		}
+	}
+	return target
+}
+
+func copyScopeMap(source map[string]any) map[string]any {
+	target := make(map[string]any, len(source))
+	for key, value := range source {
+		target[key] = copyScopeValue(value)
+	}
+	return target
+}
+
+func copyScopeValue(value any) any {
+	if typedValue, ok := asScopeMap(value); ok {
+		return copyScopeMap(typedValue)
+	}
+	return value
+}
+
+// MergeAccessScopes computes the effective access scope of an entity from its own scope and the scopes of its roles.
+// Roles are merged first: nested scopes are merged recursively and for conflicting values the most permissive one
+// wins (true > on-behalf > false). The entity's own scope is applied last and always takes precedence over roles.
+func MergeAccessScopes(own *AccessScope, roles ...*AccessScope) *AccessScope {
+	var merged map[string]any
+	for _, role := range roles {
+		if nil != role {
+			merged = mergeScopeMaps(merged, *role, false, isMorePermissiveAccess)
+		}
+	}
+	if nil == merged {
+		return own
+	}
+	if nil != own {
+		merged = mergeScopeMaps(merged, *own, true, isMorePermissiveAccess)
+	}
+	result := AccessScope(merged)
+	return &result
+}
+
+// MergeFUPScopes computes the effective FUP scope of an entity from its own scope and the scopes of its roles.
+// Roles are merged first: for conflicting limits the highest one wins (a negative limit meaning unlimited),
+// for conflicting costs the lowest one wins and the time zone of the first role having one is kept.
+// The entity's own scope is applied last and always takes precedence over roles.
+func MergeFUPScopes(own *FUPScope, roles ...*FUPScope) *FUPScope {
+	var merged map[string]any
+	for _, role := range roles {
+		if nil != role {
+			merged = mergeScopeMaps(merged, *role, false, isMorePermissiveFUP)
+		}
+	}
+	if nil == merged {
+		return own
+	}
+	if nil != own {
+		merged = mergeScopeMaps(merged, *own, true, isMorePermissiveFUP)
+	}
+	result := FUPScope(merged)
+	return &result
+}
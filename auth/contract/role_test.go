@@ -0,0 +1,125 @@
+package contract
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMergeAccessScopes(t *testing.T) {
+	type args struct {
+		own   *AccessScope
+		roles []*AccessScope
+	}
+	tests := []struct {
+		name string
+		args args
+		want *AccessScope
+	}{
+		{
+			name: "No roles",
+			args: args{own: &AccessScope{"path": true}, roles: nil},
+			want: &AccessScope{"path": true},
+		},
+		{
+			name: "Nil own scope",
+			args: args{own: nil, roles: []*AccessScope{{"path": true}}},
+			want: &AccessScope{"path": true},
+		},
+		{
+			name: "Roles are combined",
+			args: args{own: nil, roles: []*AccessScope{{"path": true}, {"other": "on-behalf"}}},
+			want: &AccessScope{"path": true, "other": "on-behalf"},
+		},
+		{
+			name: "Most permissive role wins",
+			args: args{own: nil, roles: []*AccessScope{{"path": "on-behalf", "other": true}, {"path": true, "other": false}}},
+			want: &AccessScope{"path": true, "other": true},
+		},
+		{
+			name: "Own scope takes precedence",
+			args: args{own: &AccessScope{"path": false}, roles: []*AccessScope{{"path": true, "other": true}}},
+			want: &AccessScope{"path": false, "other": true},
+		},
+		{
+			name: "Nested scopes are merged",
+			args: args{
+				own:   &AccessScope{"path": map[string]any{"own": true}},
+				roles: []*AccessScope{{"path": map[string]any{"role": true}}},
+			},
+			want: &AccessScope{"path": map[string]any{"own": true, "role": true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MergeAccessScopes(tt.args.own, tt.args.roles...))
+		})
+	}
+}
+
+func TestMergeAccessScopes_DoesNotModifyRoles(t *testing.T) {
+	role := &AccessScope{"path": map[string]any{"role": true}}
+	MergeAccessScopes(&AccessScope{"path": map[string]any{"own": true}}, role)
+	assert.Equal(t, &AccessScope{"path": map[string]any{"role": true}}, role)
+}
+
+func TestMergeFUPScopes(t *testing.T) {
+	type args struct {
+		own   *FUPScope
+		roles []*FUPScope
+	}
+	tests := []struct {
+		name string
+		args args
+		want *FUPScope
+	}{
+		{
+			name: "No roles",
+			args: args{own: &FUPScope{"path": map[string]any{"daily": 10}}, roles: nil},
+			want: &FUPScope{"path": map[string]any{"daily": 10}},
+		},
+		{
+			name: "Highest limit wins",
+			args: args{own: nil, roles: []*FUPScope{{"path": map[string]any{"daily": 10}}, {"path": map[string]any{"daily": 20, "hourly": 5}}}},
+			want: &FUPScope{"path": map[string]any{"daily": 20, "hourly": 5}},
+		},
+		{
+			name: "Negative limit means unlimited",
+			args: args{own: nil, roles: []*FUPScope{{"path": map[string]any{"daily": -1}}, {"path": map[string]any{"daily": 20}}}},
+			want: &FUPScope{"path": map[string]any{"daily": -1}},
+		},
+		{
+			name: "Own scope takes precedence",
+			args: args{own: &FUPScope{"path": map[string]any{"daily": 1}}, roles: []*FUPScope{{"path": map[string]any{"daily": 20}}}},
+			want: &FUPScope{"path": map[string]any{"daily": 1}},
+		},
+		{
+			name: "Lowest cost wins",
+			args: args{own: nil, roles: []*FUPScope{{"/export": map[string]any{"hourly": 1000, "cost": 10}}, {"/export": map[string]any{"hourly": 100, "cost": 100}}}},
+			want: &FUPScope{"/export": map[string]any{"hourly": 1000, "cost": 10}},
+		},
+		{
+			name: "Time zone of the first role is kept",
+			args: args{own: nil, roles: []*FUPScope{{"timezone": "Europe/Prague"}, {"timezone": "Asia/Tokyo"}, {"timezone": "UTC"}}},
+			want: &FUPScope{"timezone": "Europe/Prague"},
+		},
+		{
+			name: "Own time zone takes precedence",
+			args: args{own: &FUPScope{"timezone": "UTC"}, roles: []*FUPScope{{"timezone": "Europe/Prague"}}},
+			want: &FUPScope{"timezone": "UTC"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MergeFUPScopes(tt.args.own, tt.args.roles...))
+		})
+	}
+}
+
+func TestMergeFUPScopes_DoesNotModifyRoles(t *testing.T) {
+	role := &FUPScope{"path": map[string]any{"daily": 10}}
+	own := &FUPScope{"path": map[string]any{"daily": 1, "hourly": 1}}
+	merged := MergeFUPScopes(own, role)
+	assert.Equal(t, &FUPScope{"path": map[string]any{"daily": 1, "hourly": 1}}, merged)
+	assert.Equal(t, &FUPScope{"path": map[string]any{"daily": 10}}, role)
+	assert.Equal(t, &FUPScope{"path": map[string]any{"daily": 1, "hourly": 1}}, own)
+}
@@ -0,0 +1,110 @@
+package contract
+
+import (
+	"encoding/json"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"strings"
+)
+
+const (
+	TraceMatchExact = "exact"
+	TraceMatchRegex = "regex"
+	TraceMatchGlob  = "glob"
+	TraceMatchNone  = "none"
+	TraceTypeAccess = "access"
+	TraceTypeFUP    = "fup"
+)
+
+// TraceStep describes the lookup of a single segment of a scope key
+type TraceStep struct {
+	Segment    string `json:"segment"`
+	MatchType  string `json:"matchType"`
+	MatchedKey string `json:"matchedKey,omitempty"`
+	Value      any    `json:"value,omitempty"`
+}
+
+// ScopeTrace describes a single access scope or FUP check
+type ScopeTrace struct {
+	Subject string                         `json:"subject"`
+	Type    string                         `json:"type"`
+	Key     string                         `json:"key"`
+	Steps   []TraceStep                    `json:"steps"`
+	Result  string                         `json:"result"`
+	Limits  map[constants.Period]FUPLimits `json:"limits,omitempty"`
+}
+
+func (t *ScopeTrace) addStep(segment string, match scopeMatch) {
+	step := TraceStep{Segment: segment, MatchType: match.matchType, MatchedKey: match.key}
+	if TraceMatchNone == match.matchType {
+		step.MatchedKey = ""
+	}
+	if _, isNested := asScopeMap(match.value); !isNested {
+		// nested scopes are represented by the following steps
+		step.Value = match.value
+	}
+	t.Steps = append(t.Steps, step)
+}
+
+// DecisionTrace collects the access scope and FUP checks made while authenticating a request
+type DecisionTrace struct {
+	// Subject is the entity currently being checked (api-client, api-user, organisation)
+	Subject string       `json:"-"`
+	Checks  []ScopeTrace `json:"checks"`
+	Error   string       `json:"error,omitempty"`
+	// Truncated is set if some checks are left out (see MarshalLimited)
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// MarshalLimited returns the JSON encoding of the trace of at most maxLength bytes; the last checks are left out
+// of longer traces (and Truncated is set). Nil is returned if even the trace without checks is longer.
+func (t *DecisionTrace) MarshalLimited(maxLength int) ([]byte, error) {
+	limited := *t
+	for {
+		data, err := json.Marshal(limited)
+		if nil != err {
+			return nil, err
+		}
+		if len(data) <= maxLength {
+			return data, nil
+		}
+		if 0 == len(limited.Checks) {
+			return nil, nil
+		}
+		limited.Checks = limited.Checks[:len(limited.Checks)-1]
+		limited.Truncated = true
+	}
+}
+
+// TraceFUP records the lookup of the FUP limits of key (nested FUP scopes are separated by `.`) and their result
+func (t *DecisionTrace) TraceFUP(scope *FUPScope, key string, limits FUPScopeLimits) {
+	scopeTrace := ScopeTrace{Subject: t.Subject, Type: TraceTypeFUP, Key: key, Result: string(limits.Accessible), Limits: limits.Limits}
+	if nil != limits.Error {
+		scopeTrace.Result = limits.Error.Err.Error()
+	}
+	if nil != scope {
+		currentScope := map[string]any(*scope)
+		for _, segment := range strings.Split(key, ".") {
+			match, ok := findScopeEntry(currentScope, segment)
+			scopeTrace.addStep(segment, match)
+			nested, isNested := asScopeMap(match.value)
+			if !ok || !isNested {
+				break
+			}
+			currentScope = nested
+		}
+	}
+	t.Checks = append(t.Checks, scopeTrace)
+}
+
+// GetDecisionTrace returns the decision trace of the request or nil if tracing is not enabled
+func GetDecisionTrace(c ValueGetter) *DecisionTrace {
+	if isNilGetter(c) {
+		return nil
+	}
+	value, ok := c.Get(constants.DecisionTrace)
+	if !ok {
+		return nil
+	}
+	trace, _ := value.(*DecisionTrace)
+	return trace
+}
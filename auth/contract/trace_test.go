@@ -0,0 +1,106 @@
+package contract
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"testing"
+)
+
+func TestAccessScope_CheckAccessibility(t *testing.T) {
+	scope := AccessScope{
+		"g#/orders/**": map[string]any{"get": true},
+		"/admin":       false,
+	}
+
+	c := &gin.Context{}
+	assert.Equal(t, constants.ScopeAccessibilityAccessible, scope.CheckAccessibility("/orders/42|get", "|", c))
+	assert.Nil(t, GetDecisionTrace(c))
+
+	trace := &DecisionTrace{Subject: constants.ApiClient}
+	c.Set(constants.DecisionTrace, trace)
+	assert.Equal(t, constants.ScopeAccessibilityAccessible, scope.CheckAccessibility("/orders/42|get", "|", c))
+	assert.Equal(t, constants.ScopeAccessibilityForbidden, scope.CheckAccessibility("/admin", "|", c))
+	assert.Equal(t, constants.ScopeAccessibilityForbidden, scope.CheckAccessibility("/invoices", "|", c))
+	assert.Equal(t, []ScopeTrace{
+		{
+			Subject: constants.ApiClient,
+			Type:    TraceTypeAccess,
+			Key:     "/orders/42|get",
+			Steps: []TraceStep{
+				{Segment: "/orders/42", MatchType: TraceMatchGlob, MatchedKey: "g#/orders/**"},
+				{Segment: "get", MatchType: TraceMatchExact, MatchedKey: "get", Value: true},
+			},
+			Result: string(constants.ScopeAccessibilityAccessible),
+		},
+		{
+			Subject: constants.ApiClient,
+			Type:    TraceTypeAccess,
+			Key:     "/admin",
+			Steps:   []TraceStep{{Segment: "/admin", MatchType: TraceMatchExact, MatchedKey: "/admin", Value: false}},
+			Result:  string(constants.ScopeAccessibilityForbidden),
+		},
+		{
+			Subject: constants.ApiClient,
+			Type:    TraceTypeAccess,
+			Key:     "/invoices",
+			Steps:   []TraceStep{{Segment: "/invoices", MatchType: TraceMatchNone}},
+			Result:  string(constants.ScopeAccessibilityForbidden),
+		},
+	}, trace.Checks)
+}
+
+func TestDecisionTrace_TraceFUP(t *testing.T) {
+	limits := map[constants.Period]FUPLimits{constants.PeriodMinutely: {Used: 1, Limit: 10}}
+	trace := &DecisionTrace{Subject: constants.ApiUser}
+	trace.TraceFUP(&FUPScope{"r#^/orders/[0-9]+$": map[string]any{"minutely": 10}}, "/orders/42.minutely", FUPScopeLimits{
+		Accessible: constants.ScopeAccessibilityAccessible,
+		Limits:     limits,
+	})
+	trace.TraceFUP(nil, "/orders/42.minutely", FUPScopeLimits{Accessible: constants.ScopeAccessibilityAccessible})
+	assert.Equal(t, []ScopeTrace{
+		{
+			Subject: constants.ApiUser,
+			Type:    TraceTypeFUP,
+			Key:     "/orders/42.minutely",
+			Steps: []TraceStep{
+				{Segment: "/orders/42", MatchType: TraceMatchRegex, MatchedKey: "r#^/orders/[0-9]+$"},
+				{Segment: "minutely", MatchType: TraceMatchExact, MatchedKey: "minutely", Value: 10},
+			},
+			Result: string(constants.ScopeAccessibilityAccessible),
+			Limits: limits,
+		},
+		{
+			Subject: constants.ApiUser,
+			Type:    TraceTypeFUP,
+			Key:     "/orders/42.minutely",
+			Result:  string(constants.ScopeAccessibilityAccessible),
+		},
+	}, trace.Checks)
+}
+
+func TestDecisionTrace_MarshalLimited(t *testing.T) {
+	trace := &DecisionTrace{Checks: []ScopeTrace{
+		{Subject: constants.ApiClient, Type: TraceTypeAccess, Key: "/orders", Result: "true"},
+		{Subject: constants.ApiClient, Type: TraceTypeAccess, Key: "/invoices", Result: "false"},
+	}}
+	full, err := trace.MarshalLimited(4096)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(full), "truncated")
+
+	// the last checks are left out of longer traces
+	limited, err := trace.MarshalLimited(len(full) - 1)
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, len(limited), len(full)-1)
+	assert.Contains(t, string(limited), "/orders")
+	assert.NotContains(t, string(limited), "/invoices")
+	assert.Contains(t, string(limited), `"truncated":true`)
+	// the trace itself is not modified
+	assert.Len(t, trace.Checks, 2)
+	assert.False(t, trace.Truncated)
+
+	// nothing is returned if even the trace without checks is too long
+	limited, err = trace.MarshalLimited(10)
+	assert.Nil(t, err)
+	assert.Nil(t, limited)
+}
@@ -16,6 +16,9 @@ type GormApiClient struct {
 	CurrentKey     *GormApiClientKey     `gorm:"-" json:"currentKey" groups:"internal,credentials"`
 	AccessScope    *contract.AccessScope `gorm:"type:jsonb;serializer:json" json:"clientScope" groups:"internal,public"`
 	FUPScope       *contract.FUPScope    `gorm:"type:jsonb;serializer:json" json:"fupConfig" groups:"internal"`
+	Roles          []string              `gorm:"type:jsonb;serializer:json" json:"roles" groups:"internal,public"`
+	OrganisationID *uuid.UUID            `gorm:"type:uuid;index" json:"organisationId" groups:"internal,public"`
+	ReportOnly     bool                  `gorm:"not null;default:false" json:"reportOnly" groups:"internal"`
 	CreatedAt      time.Time             `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt" groups:"internal"`
 }
 
@@ -68,6 +71,21 @@ func (c *GormApiClient) GetFUPScope() *contract.FUPScope {
 	return c.FUPScope
 }
 
+func (c *GormApiClient) GetRoles() []string {
+	return c.Roles
+}
+
+func (c *GormApiClient) GetTenantId() string {
+	if nil == c.OrganisationID {
+		return ""
+	}
+	return c.OrganisationID.String()
+}
+
+func (c *GormApiClient) IsReportOnly() bool {
+	return c.ReportOnly
+}
+
 // GormApiClientKey is a struct that implements ApiClientKeyInterface for GORM
 type GormApiClientKey struct {
 	ID             uuid.UUID             `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id" groups:"internal,public,id"`
@@ -84,6 +102,10 @@ func (k *GormApiClientKey) TableName() string {
 	return "api_client_key"
 }
 
+func (k *GormApiClientKey) GetKeyId() string {
+	return k.ID.String()
+}
+
 func (k *GormApiClientKey) GetKey() string {
 	return k.Key
 }
@@ -111,6 +133,8 @@ type GormApiUser struct {
 	Password                string                         `json:"password" groups:"internal"`
 	AccessScope             *contract.AccessScope          `gorm:"type:jsonb;serializer:json" json:"userScope" groups:"internal,public"`
 	FUPScope                *contract.FUPScope             `gorm:"type:jsonb;serializer:json" json:"fupConfig" groups:"internal"`
+	Roles                   []string                       `gorm:"type:jsonb;serializer:json" json:"roles" groups:"internal,public"`
+	OrganisationID          *uuid.UUID                     `gorm:"type:uuid;index" json:"organisationId" groups:"internal,public"`
 	LastLoginAt             *time.Time                     `json:"lastLoginAt" groups:"internal,public"`
 	CurrentToken            contract.ApiUserTokenInterface `gorm:"-" json:"token" groups:"internal,public,credentials"`
 	ApiTokens               []GormApiUserToken             `gorm:"foreignKey:ApiUserID" json:"-"`
@@ -215,6 +239,17 @@ func (u *GormApiUser) GetID() string {
 	return u.ID.String()
 }
 
+func (u *GormApiUser) GetRoles() []string {
+	return u.Roles
+}
+
+func (u *GormApiUser) GetTenantId() string {
+	if nil == u.OrganisationID {
+		return ""
+	}
+	return u.OrganisationID.String()
+}
+
 // GormApiUserToken is a struct that implements ApiUserTokenInterface for GORM
 type GormApiUserToken struct {
 	ID             uuid.UUID    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id" groups:"internal"`
@@ -252,3 +287,48 @@ func (t *GormApiUserToken) SetApiUser(apiUser contract.ApiUserInterface) {
 func (t *GormApiUserToken) GetApiUser() contract.ApiUserInterface {
 	return t.ApiUser
 }
+
+// GormApiRole is a struct that implements ApiRoleInterface for GORM
+type GormApiRole struct {
+	ID          uuid.UUID             `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id" groups:"internal,public,id"`
+	Name        string                `gorm:"uniqueIndex;not null" json:"name" groups:"internal,public"`
+	AccessScope *contract.AccessScope `gorm:"type:jsonb;serializer:json" json:"accessScope" groups:"internal,public"`
+	FUPScope    *contract.FUPScope    `gorm:"type:jsonb;serializer:json" json:"fupConfig" groups:"internal"`
+	CreatedAt   time.Time             `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt" groups:"internal"`
+}
+
+func (r *GormApiRole) TableName() string {
+	return "api_role"
+}
+
+func (r *GormApiRole) GetName() string {
+	return r.Name
+}
+
+func (r *GormApiRole) GetAccessScope() *contract.AccessScope {
+	return r.AccessScope
+}
+
+func (r *GormApiRole) GetFUPScope() *contract.FUPScope {
+	return r.FUPScope
+}
+
+// GormApiOrganisation is a struct that implements ApiOrganisationInterface for GORM
+type GormApiOrganisation struct {
+	ID        uuid.UUID          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id" groups:"internal,public,id"`
+	Name      string             `gorm:"not null" json:"name" groups:"internal,public"`
+	FUPScope  *contract.FUPScope `gorm:"type:jsonb;serializer:json" json:"fupConfig" groups:"internal"`
+	CreatedAt time.Time          `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt" groups:"internal"`
+}
+
+func (o *GormApiOrganisation) TableName() string {
+	return "api_organisation"
+}
+
+func (o *GormApiOrganisation) GetID() string {
+	return o.ID.String()
+}
+
+func (o *GormApiOrganisation) GetFUPScope() *contract.FUPScope {
+	return o.FUPScope
+}
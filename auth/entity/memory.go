@@ -14,6 +14,9 @@ type MemoryApiClient struct {
 	CurrentApiKey  *MemoryApiClientKey   `json:"-"`
 	AccessScope    *contract.AccessScope `json:"clientScope" groups:"internal,public"`
 	FUPScope       *contract.FUPScope    `json:"fupConfig" groups:"internal"`
+	Roles          []string              `json:"roles" groups:"internal,public"`
+	OrganisationId string                `json:"organisationId" groups:"internal,public"`
+	ReportOnly     bool                  `json:"reportOnly" groups:"internal"`
 }
 
 func (c *MemoryApiClient) GetClientId() string {
@@ -53,6 +56,18 @@ func (c *MemoryApiClient) GetFUPScope() *contract.FUPScope {
 	return c.FUPScope
 }
 
+func (c *MemoryApiClient) GetRoles() []string {
+	return c.Roles
+}
+
+func (c *MemoryApiClient) GetTenantId() string {
+	return c.OrganisationId
+}
+
+func (c *MemoryApiClient) IsReportOnly() bool {
+	return c.ReportOnly
+}
+
 // MemoryApiClientKey is the simplest struct that implements ApiClientKeyInterface
 type MemoryApiClientKey struct {
 	Key            string                `json:"key" groups:"internal,public"`
@@ -92,6 +107,8 @@ type MemoryApiUser struct {
 	ConfirmationToken string                `json:"confirmationToken" groups:"internal"`
 	ResetToken        string                `json:"resetToken" groups:"internal"`
 	FUPScope          *contract.FUPScope    `json:"fupConfig" groups:"internal"`
+	Roles             []string              `json:"roles" groups:"internal,public"`
+	OrganisationId    string                `json:"organisationId" groups:"internal,public"`
 }
 
 func (u *MemoryApiUser) AddApiToken(apiToken contract.ApiUserTokenInterface) {
@@ -188,6 +205,14 @@ func (u *MemoryApiUser) GetID() string {
 	return u.Id
 }
 
+func (u *MemoryApiUser) GetRoles() []string {
+	return u.Roles
+}
+
+func (u *MemoryApiUser) GetTenantId() string {
+	return u.OrganisationId
+}
+
 // MemoryApiUserToken is the simplest struct that implements ApiUserTokenInterface
 type MemoryApiUserToken struct {
 	Token          string         `json:"token" groups:"internal,credentials,public"`
@@ -218,3 +243,37 @@ func (t *MemoryApiUserToken) SetApiUser(apiUser contract.ApiUserInterface) {
 func (t *MemoryApiUserToken) GetApiUser() contract.ApiUserInterface {
 	return t.ApiUser
 }
+
+// MemoryApiRole is the simplest struct that implements ApiRoleInterface
+type MemoryApiRole struct {
+	Name        string                `json:"name" groups:"internal,public"`
+	AccessScope *contract.AccessScope `json:"accessScope" groups:"internal,public"`
+	FUPScope    *contract.FUPScope    `json:"fupConfig" groups:"internal"`
+}
+
+func (r *MemoryApiRole) GetName() string {
+	return r.Name
+}
+
+func (r *MemoryApiRole) GetAccessScope() *contract.AccessScope {
+	return r.AccessScope
+}
+
+func (r *MemoryApiRole) GetFUPScope() *contract.FUPScope {
+	return r.FUPScope
+}
+
+// MemoryApiOrganisation is the simplest struct that implements ApiOrganisationInterface
+type MemoryApiOrganisation struct {
+	Id       string             `json:"id" groups:"internal,public"`
+	Name     string             `json:"name" groups:"internal,public"`
+	FUPScope *contract.FUPScope `json:"fupConfig" groups:"internal"`
+}
+
+func (o *MemoryApiOrganisation) GetID() string {
+	return o.Id
+}
+
+func (o *MemoryApiOrganisation) GetFUPScope() *contract.FUPScope {
+	return o.FUPScope
+}
@@ -0,0 +1,139 @@
+package fup
+
+import (
+	"fmt"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/events-go"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entry is the usage of a FUP entry (the requests of a FUP key counted for the root limits, a path, an IP address etc.)
+type Entry struct {
+	// Id: `*` for the root limits, the path (or route etc.) with `/` replaced by `-`, the IP address or the FUP cookie
+	Id    string                          `json:"id"`
+	Usage map[constants.Period]EntryUsage `json:"usage"`
+}
+
+type EntryUsage struct {
+	Used    int       `json:"used"`
+	ResetAt time.Time `json:"resetAt"`
+}
+
+// getAdminCacheDriver returns the cache driver if it supports the administration of FUP entries
+func getAdminCacheDriver(configProvider *config.Provider) (contract.FUPAdminCacheDriverInterface, *contract.AuthError) {
+	if !configProvider.IsCacheEnabled() {
+		return nil, contract.NewInternalError(contract.FUPCacheDisabled, nil)
+	}
+	cacheDriver, ok := configProvider.GetCacheDriver().(contract.FUPAdminCacheDriverInterface)
+	if !ok {
+		return nil, contract.NewInternalError(contract.FUPAdminNotSupported, nil)
+	}
+	return cacheDriver, nil
+}
+
+// getAdminTime returns the current time in the time zone of the FUP scope (optional), so that the windows of the calendar periods match the checkers
+func getAdminTime(scope *contract.FUPScope) time.Time {
+	if nil == scope {
+		return time.Now()
+	}
+	return time.Now().In(scope.GetLocation())
+}
+
+func validatePeriod(period constants.Period) *contract.AuthError {
+	if !slices.Contains(constants.FUPScopePeriods, period) {
+		return contract.NewAuthError(contract.InvalidRequest, map[string]string{"details": fmt.Sprintf("unknown FUP period %s", period)})
+	}
+	return nil
+}
+
+// ListEntries returns the FUP entries of the FUP key (e.g. the client id, `<client id>:<api key>`, the user login or `organisation:<tenant id>`)
+// with the usage of the periods used at the moment; scope (optional) is the FUP scope of the key, its time zone is used for the calendar periods
+func ListEntries(configProvider *config.Provider, scope *contract.FUPScope, key string) ([]Entry, *contract.AuthError) {
+	cacheDriver, err := getAdminCacheDriver(configProvider)
+	if nil != err {
+		return nil, err
+	}
+	prefix := getCacheKey(key, "")
+	cacheKeys, err := cacheDriver.ListFUPKeys(prefix)
+	if nil != err {
+		return nil, err
+	}
+	algorithm := configProvider.GetFUPAlgorithm()
+	now := getAdminTime(scope)
+	entries := make([]Entry, 0, len(cacheKeys))
+	for _, cacheKey := range cacheKeys {
+		cacheEntry, err := queryEntry(configProvider.GetCacheDriver(), cacheKey, algorithm, now)
+		if nil != err {
+			return nil, err
+		}
+		entry := Entry{Id: strings.TrimPrefix(cacheKey, prefix), Usage: make(map[constants.Period]EntryUsage)}
+		for _, period := range constants.FUPScopePeriods {
+			if used := cacheEntry.GetUsedAt(algorithm, period, now); used > 0 {
+				entry.Usage[period] = EntryUsage{Used: used, ResetAt: cacheEntry.GetResetTimeAt(algorithm, period, now)}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ResetEntry clears the usage of the FUP entry id (e.g. `*`, the path or the IP address) of the FUP key in the period (all the periods if empty)
+// and dispatches the FUPEntryChangedEvent; actor is who made the change (e.g. the client id of the admin)
+func ResetEntry(configProvider *config.Provider, scope *contract.FUPScope, key string, id string, period constants.Period, actor string) *contract.AuthError {
+	periods := constants.FUPScopePeriods
+	if "" != period {
+		if err := validatePeriod(period); nil != err {
+			return err
+		}
+		periods = []constants.Period{period}
+	}
+	cacheDriver, err := getAdminCacheDriver(configProvider)
+	if nil != err {
+		return err
+	}
+	now := getAdminTime(scope)
+	for _, resetPeriod := range periods {
+		err = cacheDriver.ResetFUPEntry(getCacheKey(key, id), configProvider.GetFUPAlgorithm(), resetPeriod, now)
+		if nil != err {
+			return err
+		}
+	}
+	events.GetEventHub().DispatchAsync(&contract.FUPEntryChangedEvent{
+		Key:    key,
+		Id:     id,
+		Period: period,
+		Action: constants.FUPEntryActionReset,
+		Actor:  actor,
+	})
+	return nil
+}
+
+// AdjustEntry adds delta to the usage of the FUP entry id (e.g. `*`, the path or the IP address) of the FUP key in the period
+// (e.g. a negative delta to give some requests back; the usage is never negative) and dispatches the FUPEntryChangedEvent;
+// actor is who made the change (e.g. the client id of the admin)
+func AdjustEntry(configProvider *config.Provider, scope *contract.FUPScope, key string, id string, period constants.Period, delta int, actor string) *contract.AuthError {
+	if err := validatePeriod(period); nil != err {
+		return err
+	}
+	cacheDriver, err := getAdminCacheDriver(configProvider)
+	if nil != err {
+		return err
+	}
+	err = cacheDriver.AdjustFUPEntry(getCacheKey(key, id), configProvider.GetFUPAlgorithm(), period, getAdminTime(scope), delta)
+	if nil != err {
+		return err
+	}
+	events.GetEventHub().DispatchAsync(&contract.FUPEntryChangedEvent{
+		Key:    key,
+		Id:     id,
+		Period: period,
+		Action: constants.FUPEntryActionAdjust,
+		Delta:  delta,
+		Actor:  actor,
+	})
+	return nil
+}
@@ -0,0 +1,90 @@
+package fup
+
+import (
+	"fmt"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"strings"
+	"time"
+)
+
+// TokenBucketFUPChecker is an implementation of the FUPCheckerInterface for token bucket limits (rate and burst) of the URL path-based access model
+// Use ChainFUPChecker to combine it with the period limits (e.g. ChainFUPChecker{Checkers: []contract.FUPCheckerInterface{TokenBucketFUPChecker{}, PathFUPChecker{}}})
+type TokenBucketFUPChecker struct {
+}
+
+func takeToken(c contract.RequestContext, cacheDriver contract.TokenBucketCacheDriverInterface, key string, cacheId string, limit *contract.TokenBucketLimit, now time.Time, cost int) (*contract.FUPLimits, *contract.FUPScopeLimits) {
+	cacheKey := fmt.Sprintf("%s_%s", getCacheKey(key, cacheId), constants.PeriodTokenBucket)
+	entry, allowed, err := cacheDriver.TakeToken(cacheKey, *limit, now, cost)
+	if nil != err {
+		return nil, &contract.FUPScopeLimits{
+			Error: err,
+		}
+	}
+	limits := entry.GetLimits(*limit, cost)
+	if !allowed {
+		return nil, &contract.FUPScopeLimits{
+			Accessible: constants.ScopeAccessibilityForbidden,
+			Limits:     map[constants.Period]contract.FUPLimits{constants.PeriodTokenBucket: limits},
+			Error:      nil,
+		}
+	}
+	// the tokens are returned if the request isn't charged after the handler (see Settle)
+	addCharge(c, charge{cacheKey: cacheKey, cost: cost, reservedAt: now, bucket: limit})
+	return &limits, nil
+}
+
+func checkTokenBuckets(path string, scope *contract.FUPScope, key string, c contract.RequestContext) contract.FUPScopeLimits {
+	rootLimit := scope.GetTokenBucket("*")
+	pathLimit := scope.GetTokenBucket(path)
+	if nil == rootLimit && nil == pathLimit {
+		// no limitations by default
+		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
+	}
+
+	configProvider := config.GetProvider(c)
+	if !configProvider.IsCacheEnabled() {
+		return contract.FUPScopeLimits{
+			Error: contract.NewInternalError(contract.FUPCacheDisabled, nil),
+		}
+	}
+	cacheDriver, ok := configProvider.GetCacheDriver().(contract.TokenBucketCacheDriverInterface)
+	if !ok {
+		return contract.FUPScopeLimits{
+			Error: contract.NewInternalError(contract.TokenBucketNotSupported, nil),
+		}
+	}
+
+	now := time.Now()
+	cost := getCost(scope, path, configProvider)
+	var limits map[constants.Period]contract.FUPLimits
+	for _, bucket := range []struct {
+		cacheId string
+		limit   *contract.TokenBucketLimit
+	}{{"*", rootLimit}, {path, pathLimit}} {
+		if nil == bucket.limit {
+			continue
+		}
+		bucketLimits, scopeLimits := takeToken(c, cacheDriver, key, bucket.cacheId, bucket.limit, now, cost)
+		if nil != scopeLimits {
+			return *scopeLimits
+		}
+		limits = mergeLimits(limits, map[constants.Period]contract.FUPLimits{constants.PeriodTokenBucket: *bucketLimits})
+	}
+
+	return contract.FUPScopeLimits{
+		Accessible: constants.ScopeAccessibilityAccessible,
+		Limits:     limits,
+		Error:      nil,
+	}
+}
+
+func (ch TokenBucketFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	if nil == scope || nil == c || nil == c.GetRequest() || nil == c.GetRequest().URL {
+		// no limitations by default
+		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
+	}
+	path := strings.ToLower(c.GetRequest().URL.Path)
+	return traceLimits(c, fmt.Sprintf("%s.%s", path, constants.PeriodTokenBucket), scope, checkTokenBuckets(path, scope, key, c))
+}
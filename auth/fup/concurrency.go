@@ -0,0 +1,151 @@
+package fup
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"log"
+	"strings"
+	"time"
+)
+
+const defaultLease = time.Minute
+
+// ConcurrencyFUPChecker is an implementation of the FUPCheckerInterface for concurrency limits (simultaneous requests) of the URL path-based access model
+// Use ChainFUPChecker to combine it with the other limits (e.g. ChainFUPChecker{Checkers: []contract.FUPCheckerInterface{ConcurrencyFUPChecker{}, PathFUPChecker{}}})
+type ConcurrencyFUPChecker struct {
+	// Lease: how long a slot is held at most (if the instance crashes or the request takes longer, the slot is freed after the lease) - defaults to 1 minute
+	Lease time.Duration
+}
+
+// lease is a slot of a concurrency limit held by the request (see Settle)
+type lease struct {
+	cacheDriver contract.ConcurrencyCacheDriverInterface
+	cacheKey    string
+	leaseId     string
+}
+
+func (l lease) release() {
+	err := l.cacheDriver.ReleaseSlot(l.cacheKey, l.leaseId)
+	if nil != err {
+		log.Printf("can't release the concurrency slot: %+v", err)
+	}
+}
+
+func addLease(c contract.RequestContext, l lease) {
+	var leases []lease
+	if value, ok := c.Get(constants.FUPLeases); ok {
+		leases, _ = value.([]lease)
+	}
+	c.Set(constants.FUPLeases, append(leases, l))
+}
+
+// DetachLeases removes the concurrency slots held by the request from c and returns a function releasing them
+// (for callers that outlive the request context, e.g. the gRPC interceptors)
+func DetachLeases(c contract.RequestContext) func() {
+	value, ok := c.Get(constants.FUPLeases)
+	if !ok {
+		return func() {}
+	}
+	c.Set(constants.FUPLeases, []lease(nil))
+	leases, _ := value.([]lease)
+	return func() {
+		for _, l := range leases {
+			l.release()
+		}
+	}
+}
+
+func acquireSlot(cacheDriver contract.ConcurrencyCacheDriverInterface, key string, cacheId string, limit int, duration time.Duration) (*lease, contract.FUPLimits, *contract.FUPScopeLimits) {
+	cacheKey := fmt.Sprintf("%s_%s", getCacheKey(key, cacheId), constants.PeriodConcurrent)
+	l := lease{cacheDriver: cacheDriver, cacheKey: cacheKey, leaseId: uuid.NewString()}
+	now := time.Now()
+	inFlight, acquired, err := cacheDriver.AcquireSlot(cacheKey, l.leaseId, limit, duration, now)
+	if nil != err {
+		return nil, contract.FUPLimits{}, &contract.FUPScopeLimits{
+			Error: err,
+		}
+	}
+	limits := contract.FUPLimits{
+		Limit:  limit,
+		Used:   inFlight,
+		Period: constants.PeriodConcurrent,
+	}
+	if !acquired {
+		// there is no telling when a slot is released, so a short retry is suggested
+		limits.ResetAt = now.Add(time.Second)
+		return nil, limits, &contract.FUPScopeLimits{
+			Accessible: constants.ScopeAccessibilityForbidden,
+			Limits:     map[constants.Period]contract.FUPLimits{constants.PeriodConcurrent: limits},
+			Error:      nil,
+		}
+	}
+	return &l, limits, nil
+}
+
+func checkConcurrency(c contract.RequestContext, path string, scope *contract.FUPScope, key string, duration time.Duration) contract.FUPScopeLimits {
+	rootLimit := scope.GetConcurrencyLimit("*")
+	pathLimit := scope.GetConcurrencyLimit(path)
+	if nil == rootLimit && nil == pathLimit {
+		// no limitations by default
+		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
+	}
+
+	configProvider := config.GetProvider(c)
+	if !configProvider.IsCacheEnabled() {
+		return contract.FUPScopeLimits{
+			Error: contract.NewInternalError(contract.FUPCacheDisabled, nil),
+		}
+	}
+	cacheDriver, ok := configProvider.GetCacheDriver().(contract.ConcurrencyCacheDriverInterface)
+	if !ok {
+		return contract.FUPScopeLimits{
+			Error: contract.NewInternalError(contract.ConcurrencyNotSupported, nil),
+		}
+	}
+
+	var leases []lease
+	var limits map[constants.Period]contract.FUPLimits
+	for _, semaphore := range []struct {
+		cacheId string
+		limit   *int
+	}{{"*", rootLimit}, {path, pathLimit}} {
+		if nil == semaphore.limit {
+			continue
+		}
+		acquired, slotLimits, scopeLimits := acquireSlot(cacheDriver, key, semaphore.cacheId, *semaphore.limit, duration)
+		if nil != scopeLimits {
+			// the slots acquired already are not needed
+			for _, l := range leases {
+				l.release()
+			}
+			return *scopeLimits
+		}
+		leases = append(leases, *acquired)
+		limits = mergeLimits(limits, map[constants.Period]contract.FUPLimits{constants.PeriodConcurrent: slotLimits})
+	}
+	for _, l := range leases {
+		addLease(c, l)
+	}
+
+	return contract.FUPScopeLimits{
+		Accessible: constants.ScopeAccessibilityAccessible,
+		Limits:     limits,
+		Error:      nil,
+	}
+}
+
+func (ch ConcurrencyFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	if nil == scope || nil == c || nil == c.GetRequest() || nil == c.GetRequest().URL {
+		// no limitations by default
+		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
+	}
+	duration := ch.Lease
+	if duration <= 0 {
+		duration = defaultLease
+	}
+	path := strings.ToLower(c.GetRequest().URL.Path)
+	return traceLimits(c, fmt.Sprintf("%s.%s", path, constants.PeriodConcurrent), scope, checkConcurrency(c, path, scope, key, duration))
+}
@@ -1,24 +1,28 @@
 package fup
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/config"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
 	"net/http"
 )
 
+const defaultCookieName = "api-auth-go-fup"
+
 // CookieFUPChecker is an implementation of the FUPCheckerInterface for the URL path-based access model
 type CookieFUPChecker struct {
 	CookieName string
 }
 
-func (ch CookieFUPChecker) Check(scope *contract.FUPScope, c *gin.Context, key string) contract.FUPScopeLimits {
-	name := "api-auth-go-fup"
+func (ch CookieFUPChecker) getCookieName() string {
 	if "" != ch.CookieName {
-		name = ch.CookieName
+		return ch.CookieName
 	}
-	cookie, err := c.Cookie(name)
+	return defaultCookieName
+}
+
+func (ch CookieFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	cookie, err := c.Cookie(ch.getCookieName())
 	if nil != err && http.ErrNoCookie != err {
 		return contract.FUPScopeLimits{
 			Accessible: constants.ScopeAccessibilityForbidden,
@@ -29,22 +33,22 @@ func (ch CookieFUPChecker) Check(scope *contract.FUPScope, c *gin.Context, key s
 		// no limitations by default
 		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
 	}
-	if !scope.HasLimit(constants.FUPCookieKey) {
+	if !scope.HasPathLimit(constants.FUPCookieKey) {
 		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
 	}
-	if !config.ProviderInstance.IsCacheEnabled() {
+	configProvider := config.GetProvider(c)
+	if !configProvider.IsCacheEnabled() {
 		return contract.FUPScopeLimits{
 			Error: contract.NewInternalError(contract.FUPCacheDisabled, nil),
 		}
 	}
-	cacheDriver := config.ProviderInstance.GetCacheDriver()
-	cookieLimits, scopeLimits := checkLimits(scope, key, cookie, constants.FUPCookieKey, cacheDriver)
+	cookieLimits, scopeLimits := checkLimits(c, scope, key, cookie, constants.FUPCookieKey, getRequestCost(scope, c, configProvider))
 	if nil != scopeLimits {
-		return *scopeLimits
+		return traceLimits(c, constants.FUPCookieKey, scope, *scopeLimits)
 	}
-	return contract.FUPScopeLimits{
+	return traceLimits(c, constants.FUPCookieKey, scope, contract.FUPScopeLimits{
 		Accessible: constants.ScopeAccessibilityAccessible,
 		Limits:     cookieLimits,
 		Error:      nil,
-	}
+	})
 }
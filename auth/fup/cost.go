@@ -0,0 +1,132 @@
+package fup
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// charge is the cost of the request counted into a FUP cache entry at reservedAt (see Settle);
+// bucket is set if the cost was taken from a token bucket
+type charge struct {
+	cacheKey   string
+	cost       int
+	reservedAt time.Time
+	bucket     *contract.TokenBucketLimit
+}
+
+func addCharge(c contract.RequestContext, ch charge) {
+	var charges []charge
+	if value, ok := c.Get(constants.FUPCharges); ok {
+		charges, _ = value.([]charge)
+	}
+	c.Set(constants.FUPCharges, append(charges, ch))
+}
+
+// getCost returns the cost of a request to path (the `cost` set in the FUP scope, the cost configured in FUPConfig.Costs or 1)
+func getCost(scope *contract.FUPScope, path string, configProvider *config.Provider) int {
+	if cost := scope.GetCost(path); nil != cost {
+		return *cost
+	}
+	return configProvider.GetFUPCost(path)
+}
+
+// getRequestCost returns the cost of the requested URL path (used by the checkers not keyed by the path, e.g. IPFUPChecker)
+func getRequestCost(scope *contract.FUPScope, c contract.RequestContext, configProvider *config.Provider) int {
+	if nil == c.GetRequest() || nil == c.GetRequest().URL {
+		return 1
+	}
+	return getCost(scope, strings.ToLower(c.GetRequest().URL.Path), configProvider)
+}
+
+// SetCost reports the actual cost of the request from the handler (e.g. the number of rows returned);
+// use it instead of the response header configured in FUPConfig.CostHeader (see Settle);
+// c is the *gin.Context of the handler or the contract.HttpContext of the net/http request
+func SetCost(c contract.ValueSetter, cost int) {
+	c.Set(constants.FUPCost, cost)
+}
+
+// returnTokens puts the tokens of a token bucket charge back into the bucket
+func returnTokens(cacheDriver contract.CacheDriverInterface, ch charge) *contract.AuthError {
+	bucketCacheDriver, ok := cacheDriver.(contract.TokenBucketCacheDriverInterface)
+	if !ok {
+		return contract.NewInternalError(contract.TokenBucketNotSupported, nil)
+	}
+	return bucketCacheDriver.ReturnTokens(ch.cacheKey, *ch.bucket, ch.cost)
+}
+
+func getReportedCost(c contract.RequestContext, configProvider *config.Provider) (int, bool) {
+	if value, ok := c.Get(constants.FUPCost); ok {
+		cost, ok := value.(int)
+		return cost, ok
+	}
+	header := configProvider.GetFUPCostHeader()
+	if "" == header || nil == c.GetWriter() {
+		return 0, false
+	}
+	value := c.GetWriter().Header().Get(header)
+	if "" == value {
+		return 0, false
+	}
+	cost, err := strconv.Atoi(value)
+	if nil != err {
+		log.Printf("invalid FUP cost reported in the %s header: %+v", header, err)
+		return 0, false
+	}
+	return cost, true
+}
+
+// Settle releases the concurrency slots and settles the FUP charges of the request after the handler;
+// the auth middlewares call it after the handler (and after rejecting the request).
+// In the post-handler accounting mode, the charges are refunded if the response status is not charged (see FUPConfig.ChargedStatusCodes);
+// otherwise the difference between the cost reported by the handler (see SetCost and FUPConfig.CostHeader) and the charged cost is charged.
+// NOTE: the response (including the FUP limits headers) is sent already, so the settlement shows in the following requests
+func Settle(c contract.RequestContext) {
+	DetachLeases(c)()
+	value, ok := c.Get(constants.FUPCharges)
+	if !ok {
+		return
+	}
+	// the charges are settled only once (the middlewares may be nested)
+	c.Set(constants.FUPCharges, []charge(nil))
+	charges, _ := value.([]charge)
+	if 0 == len(charges) {
+		return
+	}
+	configProvider := config.GetProvider(c)
+	if constants.FUPAccountingPostHandler == configProvider.GetFUPAccounting() && nil != c.GetWriter() && !configProvider.ShouldChargeFUPStatus(c.GetWriter().Status()) {
+		for _, ch := range charges {
+			if nil != ch.bucket {
+				if err := returnTokens(configProvider.GetCacheDriver(), ch); nil != err {
+					log.Printf("can't return the FUP tokens: %+v", err)
+				}
+				continue
+			}
+			err := refundEntry(configProvider.GetCacheDriver(), ch.cacheKey, configProvider.GetFUPAlgorithm(), ch.reservedAt, ch.cost)
+			if nil != err {
+				log.Printf("can't refund the FUP cost: %+v", err)
+			}
+		}
+		return
+	}
+	cost, ok := getReportedCost(c, configProvider)
+	if !ok {
+		return
+	}
+	for _, ch := range charges {
+		if cost <= ch.cost || nil != ch.bucket {
+			// the tokens are only taken before the handler
+			continue
+		}
+		// the charge keeps the time zone of the FUP scope
+		now := time.Now().In(ch.reservedAt.Location())
+		_, err := incrementEntry(configProvider.GetCacheDriver(), ch.cacheKey, configProvider.GetFUPAlgorithm(), now, cost-ch.cost)
+		if nil != err {
+			log.Printf("can't charge the reported FUP cost: %+v", err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package fup
+
+import (
+	"fmt"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimit is the most restrictive limit of a RateLimit policy (the client, the user or the organisation)
+type rateLimit struct {
+	policy string
+	limits contract.FUPLimits
+}
+
+// addRateLimit stores the most restrictive limit of the policy in c (replacing the previous one of the policy, e.g. in nested middlewares)
+func addRateLimit(c contract.RequestContext, policy string, limits contract.FUPLimits) []rateLimit {
+	var rateLimits []rateLimit
+	if value, ok := c.Get(constants.FUPRateLimits); ok {
+		rateLimits, _ = value.([]rateLimit)
+	}
+	updated := make([]rateLimit, 0, len(rateLimits)+1)
+	for _, r := range rateLimits {
+		if policy != r.policy {
+			updated = append(updated, r)
+		}
+	}
+	updated = append(updated, rateLimit{policy: policy, limits: limits})
+	c.Set(constants.FUPRateLimits, updated)
+	return updated
+}
+
+func getWindow(limits contract.FUPLimits) int {
+	return int(limits.Period.GetDuration().Seconds())
+}
+
+func formatPolicy(name string, limits contract.FUPLimits) string {
+	policy := fmt.Sprintf("%q;q=%d", name, limits.Limit)
+	if window := getWindow(limits); window > 0 {
+		policy += fmt.Sprintf(";w=%d", window)
+	}
+	return policy
+}
+
+func formatRateLimit(name string, limits contract.FUPLimits, now time.Time) string {
+	value := fmt.Sprintf("%q;r=%d", name, limits.GetRemaining())
+	if resetAfter := limits.GetResetAfter(now); resetAfter >= 0 {
+		value += fmt.Sprintf(";t=%d", resetAfter)
+	}
+	return value
+}
+
+func setSingleLimitHeaders(c contract.RequestContext, prefix string, limits contract.FUPLimits, now time.Time) {
+	c.Header(prefix+constants.RateLimitLimitHeader, strconv.Itoa(limits.Limit))
+	c.Header(prefix+constants.RateLimitRemainingHeader, strconv.Itoa(limits.GetRemaining()))
+	if resetAfter := limits.GetResetAfter(now); resetAfter >= 0 {
+		c.Header(prefix+constants.RateLimitResetHeader, strconv.Itoa(resetAfter))
+		return
+	}
+	// the reset of a less restrictive limit set before is not valid anymore (an empty value removes the header)
+	c.Header(prefix+constants.RateLimitResetHeader, "")
+}
+
+// SetRateLimitHeaders sets the RateLimit headers (draft-ietf-httpapi-ratelimit-headers) of the most restrictive limit of the policy
+// (the client, the user or the organisation) if enabled (see FUPConfig.LimitsHeaders and FUPConfig.RateLimitHeaderNaming)
+func SetRateLimitHeaders(c contract.RequestContext, policy string, fupLimits contract.FUPScopeLimits) {
+	configProvider := config.GetProvider(c)
+	if !configProvider.ShouldSendRateLimitHeaders() {
+		return
+	}
+	mostRestrictive := fupLimits.GetMostRestrictiveLimits()
+	if nil == mostRestrictive {
+		return
+	}
+	rateLimits := addRateLimit(c, policy, *mostRestrictive)
+	now := time.Now()
+
+	naming := configProvider.GetRateLimitHeaderNaming()
+	if constants.RateLimitHeaderNamingIETF == naming {
+		policies := make([]string, 0, len(rateLimits))
+		remaining := make([]string, 0, len(rateLimits))
+		for _, r := range rateLimits {
+			policies = append(policies, formatPolicy(r.policy, r.limits))
+			remaining = append(remaining, formatRateLimit(r.policy, r.limits, now))
+		}
+		c.Header(constants.RateLimitPolicyHeader, strings.Join(policies, ", "))
+		c.Header(constants.RateLimitHeader, strings.Join(remaining, ", "))
+		return
+	}
+
+	// the headers with a single limit contain the most restrictive limit of all the policies
+	limits := rateLimits[0].limits
+	for _, r := range rateLimits[1:] {
+		if contract.IsMoreRestrictive(r.limits, limits) {
+			limits = r.limits
+		}
+	}
+	if constants.RateLimitHeaderNamingXPrefixed == naming {
+		setSingleLimitHeaders(c, "X-", limits, now)
+		return
+	}
+	setSingleLimitHeaders(c, "", limits, now)
+	legacyPolicy := strconv.Itoa(limits.Limit)
+	if window := getWindow(limits); window > 0 {
+		legacyPolicy += fmt.Sprintf(";w=%d", window)
+	}
+	c.Header(constants.RateLimitPolicyHeader, legacyPolicy)
+}
+
+// SetLimitsHeaders sets the FUP limits headers of the policy (the client, the user or the organisation):
+// the JSON header (e.g. `X-Client-FUP-Limits`) and/or the RateLimit headers (see FUPConfig.LimitsHeaders)
+func SetLimitsHeaders(c contract.RequestContext, policy string, jsonHeader string, fupLimits contract.FUPScopeLimits) {
+	if config.GetProvider(c).ShouldSendFUPLimitsJSONHeaders() {
+		if header := fupLimits.GetLimitsHeader(); "" != header {
+			c.Header(jsonHeader, header)
+		}
+	}
+	SetRateLimitHeaders(c, policy, fupLimits)
+}
@@ -1,7 +1,6 @@
 package fup
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/config"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
@@ -11,28 +10,28 @@ import (
 type IPFUPChecker struct {
 }
 
-func (ch IPFUPChecker) Check(scope *contract.FUPScope, c *gin.Context, key string) contract.FUPScopeLimits {
+func (ch IPFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
 	ip := c.ClientIP()
 	if nil == scope || "" == ip {
 		// no limitations by default
 		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
 	}
-	if !scope.HasLimit(constants.FUPIPKey) {
+	if !scope.HasPathLimit(constants.FUPIPKey) {
 		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
 	}
-	if !config.ProviderInstance.IsCacheEnabled() {
+	configProvider := config.GetProvider(c)
+	if !configProvider.IsCacheEnabled() {
 		return contract.FUPScopeLimits{
 			Error: contract.NewInternalError(contract.FUPCacheDisabled, nil),
 		}
 	}
-	cacheDriver := config.ProviderInstance.GetCacheDriver()
-	ipLimits, scopeLimits := checkLimits(scope, key, ip, constants.FUPIPKey, cacheDriver)
+	ipLimits, scopeLimits := checkLimits(c, scope, key, ip, constants.FUPIPKey, getRequestCost(scope, c, configProvider))
 	if nil != scopeLimits {
-		return *scopeLimits
+		return traceLimits(c, constants.FUPIPKey, scope, *scopeLimits)
 	}
-	return contract.FUPScopeLimits{
+	return traceLimits(c, constants.FUPIPKey, scope, contract.FUPScopeLimits{
 		Accessible: constants.ScopeAccessibilityAccessible,
 		Limits:     ipLimits,
 		Error:      nil,
-	}
+	})
 }
@@ -6,49 +6,95 @@ import (
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
 	"strings"
+	"time"
 )
 
-func checkLimits(scope *contract.FUPScope, key string, cacheId string, path string, cacheDriver contract.CacheDriverInterface) (map[constants.Period]contract.FUPLimits, *contract.FUPScopeLimits) {
-	limits := make(map[constants.Period]contract.FUPLimits)
-	cacheKey := fmt.Sprintf("%s_%s", key, strings.Replace(cacheId, "/", "-", -1))
+// incrementEntry counts the request of the given cost (atomically if the cache driver implements AtomicFUPCacheDriverInterface) and returns the updated counters
+func incrementEntry(cacheDriver contract.CacheDriverInterface, cacheKey string, algorithm constants.FUPAlgorithm, now time.Time, cost int) (*contract.FUPCacheEntry, *contract.AuthError) {
+	if atomicCacheDriver, ok := cacheDriver.(contract.AtomicFUPCacheDriverInterface); ok {
+		return atomicCacheDriver.IncrementFUPEntry(cacheKey, algorithm, now, cost)
+	}
 	cacheEntry, err := cacheDriver.GetFUPEntry(cacheKey)
 	if nil != err {
-		return nil, &contract.FUPScopeLimits{
-			Error: err,
-		}
+		return nil, err
 	}
-	cacheEntry.Increment()
+	cacheEntry.IncrementWith(algorithm, now, cost)
 	err = cacheDriver.SetFUPEntry(cacheKey, cacheEntry)
+	if nil != err {
+		return nil, err
+	}
+	return cacheEntry, nil
+}
+
+// refundEntry takes back the cost of a request counted at reservedAt (atomically if the cache driver implements AtomicFUPCacheDriverInterface)
+func refundEntry(cacheDriver contract.CacheDriverInterface, cacheKey string, algorithm constants.FUPAlgorithm, reservedAt time.Time, cost int) *contract.AuthError {
+	if atomicCacheDriver, ok := cacheDriver.(contract.AtomicFUPCacheDriverInterface); ok {
+		return atomicCacheDriver.RefundFUPEntry(cacheKey, algorithm, reservedAt, cost)
+	}
+	cacheEntry, err := cacheDriver.GetFUPEntry(cacheKey)
+	if nil != err {
+		return err
+	}
+	cacheEntry.Refund(algorithm, reservedAt, cost)
+	return cacheDriver.SetFUPEntry(cacheKey, cacheEntry)
+}
+
+// cacheKeyEscaper escapes the `_` separating the FUP key from the cache id, so that the entries of a key (e.g. `bob`)
+// can't be mistaken for the entries of another key it is a prefix of (e.g. `bob_smith`); keys without `_` and `%` are kept as they are
+var cacheKeyEscaper = strings.NewReplacer("%", "%25", "_", "%5F")
+
+// getCacheKey returns the key of the FUP entry of the FUP key (e.g. the client id) and the cache id (e.g. `*`, the path or the IP address)
+func getCacheKey(key string, cacheId string) string {
+	return fmt.Sprintf("%s_%s", cacheKeyEscaper.Replace(key), strings.Replace(cacheId, "/", "-", -1))
+}
+
+func checkLimits(c contract.RequestContext, scope *contract.FUPScope, key string, cacheId string, path string, cost int) (map[constants.Period]contract.FUPLimits, *contract.FUPScopeLimits) {
+	configProvider := config.GetProvider(c)
+	limits := make(map[constants.Period]contract.FUPLimits)
+	cacheKey := getCacheKey(key, cacheId)
+	// the calendar periods reset in the time zone of the FUP scope
+	now := time.Now().In(scope.GetLocation())
+	algorithm := configProvider.GetFUPAlgorithm()
+	slidingWindow := constants.FUPAlgorithmSlidingWindow == algorithm
+	cacheEntry, err := incrementEntry(configProvider.GetCacheDriver(), cacheKey, algorithm, now, cost)
 	if nil != err {
 		return nil, &contract.FUPScopeLimits{
 			Error: err,
 		}
 	}
+	// the request is charged even if it exceeds the limits (unless refunded after the handler, see Settle)
+	addCharge(c, charge{cacheKey: cacheKey, cost: cost, reservedAt: now})
 
 	for _, period := range constants.FUPScopePeriods {
-		limit := scope.GetLimit(fmt.Sprintf("%s.%s", path, period))
+		limit := scope.GetPeriodLimit(path, period)
 		if nil == limit || *limit < 0 {
 			// no limitations by default
 			continue
 		}
-		used := cacheEntry.GetUsed(period)
+		used := cacheEntry.GetUsedAt(algorithm, period, now)
+		resetAt := cacheEntry.GetResetTimeAt(algorithm, period, now)
 		if *limit < used {
+			exceeded := contract.FUPLimits{
+				Limit:   *limit,
+				Used:    used,
+				Period:  period,
+				ResetAt: resetAt,
+			}
+			if slidingWindow {
+				// the request fits into the limit again once the estimated usage drops to the limit minus its cost
+				exceeded.ResetAt = cacheEntry.GetSlidingWindowResetTime(period, *limit-max(cost, 1)+1, now)
+			}
 			return nil, &contract.FUPScopeLimits{
 				Accessible: constants.ScopeAccessibilityForbidden,
-				Limits: map[constants.Period]contract.FUPLimits{
-					period: {
-						Limit:  *limit,
-						Used:   used,
-						Period: period,
-					},
-				},
-				Error: nil,
+				Limits:     map[constants.Period]contract.FUPLimits{period: exceeded},
+				Error:      nil,
 			}
 		}
 		limits[period] = contract.FUPLimits{
-			Limit:  *limit,
-			Used:   used,
-			Period: period,
+			Limit:   *limit,
+			Used:    used,
+			Period:  period,
+			ResetAt: resetAt,
 		}
 	}
 	return limits, nil
@@ -72,24 +118,37 @@ func mergeLimits(limits map[constants.Period]contract.FUPLimits, pathLimits map[
 	return limits
 }
 
-func check(path string, scope *contract.FUPScope, key string) contract.FUPScopeLimits {
-	hasRootLimit := scope.HasLimit("*")
-	hasPathLimit := scope.HasLimit(path)
+// traceLimits records the FUP check into the decision trace of the request (if tracing is enabled)
+func traceLimits(c contract.RequestContext, path string, scope *contract.FUPScope, limits contract.FUPScopeLimits) contract.FUPScopeLimits {
+	if trace := contract.GetDecisionTrace(c); nil != trace {
+		trace.TraceFUP(scope, path, limits)
+	}
+	return limits
+}
+
+func check(path string, scope *contract.FUPScope, key string, c contract.RequestContext) contract.FUPScopeLimits {
+	return traceLimits(c, path, scope, checkPath(path, scope, key, c))
+}
+
+func checkPath(path string, scope *contract.FUPScope, key string, c contract.RequestContext) contract.FUPScopeLimits {
+	hasRootLimit := scope.HasPathLimit("*")
+	hasPathLimit := scope.HasPathLimit(path)
 	if !hasRootLimit && !hasPathLimit {
 		// no limitations by default
 		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
 	}
 
-	if !config.ProviderInstance.IsCacheEnabled() {
+	configProvider := config.GetProvider(c)
+	if !configProvider.IsCacheEnabled() {
 		return contract.FUPScopeLimits{
 			Error: contract.NewInternalError(contract.FUPCacheDisabled, nil),
 		}
 	}
-	cacheDriver := config.ProviderInstance.GetCacheDriver()
-
+	// the root limits are consumed by the cost of the path too
+	cost := getCost(scope, path, configProvider)
 	var limits map[constants.Period]contract.FUPLimits
 	if hasRootLimit {
-		rootLimits, scopeLimits := checkLimits(scope, key, "*", "*", cacheDriver)
+		rootLimits, scopeLimits := checkLimits(c, scope, key, "*", "*", cost)
 		if nil != scopeLimits {
 			return *scopeLimits
 		}
@@ -97,7 +156,7 @@ func check(path string, scope *contract.FUPScope, key string) contract.FUPScopeL
 	}
 
 	if hasPathLimit {
-		pathLimits, scopeLimits := checkLimits(scope, key, path, path, cacheDriver)
+		pathLimits, scopeLimits := checkLimits(c, scope, key, path, path, cost)
 		if nil != scopeLimits {
 			return *scopeLimits
 		}
@@ -1,10 +1,19 @@
 package fup
 
 import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/cache"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/events-go"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_mergeLimits(t *testing.T) {
@@ -121,3 +130,737 @@ func Test_mergeLimits(t *testing.T) {
 		})
 	}
 }
+
+func TestPathFUPChecker_Check_ConfigProviders(t *testing.T) {
+	t.Parallel()
+	newContext := func(configProvider *config.Provider) contract.RequestContext {
+		c := &gin.Context{Request: httptest.NewRequest(http.MethodGet, "/orders", nil)}
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	newConfigProvider := func() *config.Provider {
+		return config.NewProvider(contract.Config{
+			Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+		})
+	}
+	scope := &contract.FUPScope{"/orders": map[string]any{"hourly": 1}}
+	public := newConfigProvider()
+	admin := newConfigProvider()
+
+	checker := PathFUPChecker{}
+	if got := checker.Check(scope, newContext(public), "client").Accessible; constants.ScopeAccessibilityAccessible != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityAccessible)
+	}
+	if got := checker.Check(scope, newContext(public), "client").Accessible; constants.ScopeAccessibilityForbidden != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityForbidden)
+	}
+	// the admin configuration uses its own cache, so the same key is counted separately
+	if got := checker.Check(scope, newContext(admin), "client").Accessible; constants.ScopeAccessibilityAccessible != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityAccessible)
+	}
+	// the default configuration has no cache
+	if got := checker.Check(scope, contract.NewGinContext(&gin.Context{Request: httptest.NewRequest(http.MethodGet, "/orders", nil)}), "client").Error; nil == got || contract.FUPCacheDisabled != got.Code {
+		t.Errorf("Check() error = %v, want %v", got, contract.FUPCacheDisabled)
+	}
+}
+
+func TestPathFUPChecker_Check_SlidingWindow(t *testing.T) {
+	slidingWindow := constants.FUPAlgorithmSlidingWindow
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+		FUP:   &contract.FUPConfig{Algorithm: &slidingWindow},
+	})
+	c := contract.NewGinContext(&gin.Context{Request: httptest.NewRequest(http.MethodGet, "/orders", nil)})
+	configProvider.BindTo(c)
+	scope := &contract.FUPScope{"/orders": map[string]any{"minutely": 1}}
+
+	checker := PathFUPChecker{}
+	if got := checker.Check(scope, c, "client").Accessible; constants.ScopeAccessibilityAccessible != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityAccessible)
+	}
+	limits := checker.Check(scope, c, "client")
+	if constants.ScopeAccessibilityForbidden != limits.Accessible {
+		t.Errorf("Check() = %v, want %v", limits.Accessible, constants.ScopeAccessibilityForbidden)
+	}
+	// the retry is computed from the sliding window (the requests fit into the limit within two minutes at most)
+	if retryAfter := limits.GetRetryAfter(); retryAfter < 0 || retryAfter > 120 {
+		t.Errorf("GetRetryAfter() = %v, want 0-120", retryAfter)
+	}
+	if limits.Limits[constants.PeriodMinutely].ResetAt.IsZero() {
+		t.Errorf("Check() reset time is not set")
+	}
+}
+
+func TestTokenBucketFUPChecker_Check(t *testing.T) {
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+	})
+	newContext := func(path string) contract.RequestContext {
+		c := &gin.Context{Request: httptest.NewRequest(http.MethodGet, path, nil)}
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{
+		"*":       map[string]any{"bucket": map[string]any{"rate": 1, "burst": 4}},
+		"/orders": map[string]any{"hourly": 10, "bucket": map[string]any{"rate": 1, "burst": 2}},
+	}
+	checker := ChainFUPChecker{Checkers: []contract.FUPCheckerInterface{TokenBucketFUPChecker{}, PathFUPChecker{}}}
+
+	limits := checker.Check(scope, newContext("/orders"), "client")
+	if constants.ScopeAccessibilityAccessible != limits.Accessible {
+		t.Errorf("Check() = %v, want %v", limits.Accessible, constants.ScopeAccessibilityAccessible)
+	}
+	if got := limits.Limits[constants.PeriodTokenBucket]; 2 != got.Limit || 1 != got.Used {
+		t.Errorf("Check() bucket limits = %v, want the path bucket", got)
+	}
+	if got := limits.Limits[constants.PeriodHourly]; 10 != got.Limit || 1 != got.Used {
+		t.Errorf("Check() hourly limits = %v, want the path limits", got)
+	}
+
+	checker.Check(scope, newContext("/orders"), "client")
+	limits = checker.Check(scope, newContext("/orders"), "client")
+	if constants.ScopeAccessibilityForbidden != limits.Accessible {
+		t.Errorf("Check() = %v, want %v", limits.Accessible, constants.ScopeAccessibilityForbidden)
+	}
+	if retryAfter := limits.GetRetryAfter(); retryAfter < 0 || retryAfter > 1 {
+		t.Errorf("GetRetryAfter() = %v, want 0-1", retryAfter)
+	}
+
+	// the root bucket still has a token left
+	if got := checker.Check(scope, newContext("/users"), "client").Accessible; constants.ScopeAccessibilityForbidden == got {
+		t.Errorf("Check() = %v, want accessible", got)
+	}
+	if got := checker.Check(scope, newContext("/users"), "client").Accessible; constants.ScopeAccessibilityForbidden != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityForbidden)
+	}
+}
+
+// plainCacheDriver exposes the required cache driver methods only (e.g. it doesn't support token buckets)
+type plainCacheDriver struct {
+	contract.CacheDriverInterface
+}
+
+func TestChainFUPChecker_Check_Error(t *testing.T) {
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: plainCacheDriver{cache.NewMemoryCacheDriver()}},
+	})
+	c := &gin.Context{Request: httptest.NewRequest(http.MethodGet, "/orders", nil)}
+	configProvider.Bind(c)
+	scope := &contract.FUPScope{"*": map[string]any{"hourly": 10, "bucket": map[string]any{"rate": 1, "burst": 2}}}
+	checker := ChainFUPChecker{Checkers: []contract.FUPCheckerInterface{TokenBucketFUPChecker{}, PathFUPChecker{}}}
+
+	// the bucket limit is not skipped silently
+	limits := checker.Check(scope, contract.NewGinContext(c), "client")
+	if nil == limits.Error {
+		t.Fatalf("Check() error = nil, want %v", contract.TokenBucketNotSupported)
+	}
+	if contract.TokenBucketNotSupported != limits.Error.Code {
+		t.Errorf("Check() error = %v, want %v", limits.Error.Code, contract.TokenBucketNotSupported)
+	}
+}
+
+func TestPathFUPChecker_Check_Cost(t *testing.T) {
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+		FUP:   &contract.FUPConfig{Costs: map[string]int{"/reports": 10}},
+	})
+	newContext := func(path string) contract.RequestContext {
+		c := &gin.Context{Request: httptest.NewRequest(http.MethodGet, path, nil)}
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{
+		"*":        map[string]any{"hourly": 150},
+		"/export":  map[string]any{"hourly": 1000, "cost": 100},
+		"/reports": map[string]any{"hourly": 1000},
+	}
+
+	checker := PathFUPChecker{}
+	limits := checker.Check(scope, newContext("/export"), "client")
+	if constants.ScopeAccessibilityAccessible != limits.Accessible {
+		t.Errorf("Check() = %v, want %v", limits.Accessible, constants.ScopeAccessibilityAccessible)
+	}
+	// the root limits are the most restrictive ones
+	if got := limits.Limits[constants.PeriodHourly]; 150 != got.Limit || 100 != got.Used {
+		t.Errorf("Check() hourly limits = %v, want the root limits consumed by the cost", got)
+	}
+	// the cost configured in the config is used if the scope doesn't set any
+	limits = checker.Check(scope, newContext("/reports"), "client")
+	if got := limits.Limits[constants.PeriodHourly]; 110 != got.Used {
+		t.Errorf("Check() hourly used = %v, want %v", got.Used, 110)
+	}
+	limits = checker.Check(scope, newContext("/export"), "client")
+	if constants.ScopeAccessibilityForbidden != limits.Accessible {
+		t.Errorf("Check() = %v, want %v", limits.Accessible, constants.ScopeAccessibilityForbidden)
+	}
+	if got := limits.Limits[constants.PeriodHourly]; 210 != got.Used {
+		t.Errorf("Check() hourly used = %v, want %v", got.Used, 210)
+	}
+}
+
+func TestPathFUPChecker_Check_SlidingWindowCost(t *testing.T) {
+	slidingWindow := constants.FUPAlgorithmSlidingWindow
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+		FUP:   &contract.FUPConfig{Algorithm: &slidingWindow},
+	})
+	c := contract.NewGinContext(&gin.Context{Request: httptest.NewRequest(http.MethodGet, "/export", nil)})
+	configProvider.BindTo(c)
+	scope := &contract.FUPScope{"/export": map[string]any{"minutely": 15, "cost": 10}}
+
+	checker := PathFUPChecker{}
+	checker.Check(scope, c, "client")
+	limits := checker.Check(scope, c, "client")
+	if constants.ScopeAccessibilityForbidden != limits.Accessible {
+		t.Errorf("Check() = %v, want %v", limits.Accessible, constants.ScopeAccessibilityForbidden)
+	}
+	// the next request fits into the limit once the usage drops to 5 (not to 15)
+	exceeded := limits.Limits[constants.PeriodMinutely]
+	resetAt := (&contract.FUPCacheEntry{
+		UpdatedAt: time.Now(),
+		Used:      map[constants.Period]int{constants.PeriodMinutely: 20},
+	}).GetSlidingWindowResetTime(constants.PeriodMinutely, 6, time.Now())
+	if exceeded.ResetAt.Sub(resetAt).Abs() > time.Second {
+		t.Errorf("Check() reset time = %v, want %v", exceeded.ResetAt, resetAt)
+	}
+}
+
+func TestPathFUPChecker_Check_Timezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if nil != err {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+	})
+	c := contract.NewGinContext(&gin.Context{Request: httptest.NewRequest(http.MethodGet, "/orders", nil)})
+	configProvider.BindTo(c)
+	scope := &contract.FUPScope{"timezone": "Asia/Tokyo", "/orders": map[string]any{"daily": 1}}
+
+	checker := PathFUPChecker{}
+	checker.Check(scope, c, "client")
+	limits := checker.Check(scope, c, "client")
+	if constants.ScopeAccessibilityForbidden != limits.Accessible {
+		t.Fatalf("Check() = %v, want %v", limits.Accessible, constants.ScopeAccessibilityForbidden)
+	}
+	// the daily limit resets at midnight in Tokyo
+	resetAt := constants.PeriodDaily.GetResetTimeAt(time.Now().In(tokyo))
+	if exceeded := limits.Limits[constants.PeriodDaily]; exceeded.ResetAt.Sub(resetAt).Abs() > time.Second {
+		t.Errorf("Check() reset time = %v, want %v", exceeded.ResetAt, resetAt)
+	}
+}
+
+func TestSettle(t *testing.T) {
+	costHeader := "X-Rows-Returned"
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+		FUP:   &contract.FUPConfig{CostHeader: &costHeader},
+	})
+	newContext := func() contract.RequestContext {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{"/orders": map[string]any{"hourly": 1000}}
+	checker := PathFUPChecker{}
+	getUsed := func(c contract.RequestContext) int {
+		return checker.Check(scope, c, "client").Limits[constants.PeriodHourly].Used
+	}
+
+	// the handler reports the cost in the response header
+	c := newContext()
+	getUsed(c)
+	c.Header(costHeader, "30")
+	Settle(c)
+	// the charges are settled only once
+	Settle(c)
+	if got := getUsed(newContext()); 31 != got {
+		t.Errorf("Check() hourly used = %v, want %v", got, 31)
+	}
+
+	// the cost set by the handler takes precedence over the header
+	c = newContext()
+	getUsed(c)
+	c.Header(costHeader, "30")
+	SetCost(c, 10)
+	Settle(c)
+	if got := getUsed(newContext()); 42 != got {
+		t.Errorf("Check() hourly used = %v, want %v", got, 42)
+	}
+
+	// a lower cost is not refunded, invalid costs are ignored
+	c = newContext()
+	getUsed(c)
+	c.Header(costHeader, "invalid")
+	Settle(c)
+	if got := getUsed(newContext()); 44 != got {
+		t.Errorf("Check() hourly used = %v, want %v", got, 44)
+	}
+}
+
+func TestSettle_PostHandler(t *testing.T) {
+	postHandler := constants.FUPAccountingPostHandler
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+		FUP:   &contract.FUPConfig{Accounting: &postHandler},
+	})
+	newContext := func() contract.RequestContext {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{
+		"*":       map[string]any{"hourly": 1000},
+		"/orders": map[string]any{"hourly": 2},
+	}
+	checker := PathFUPChecker{}
+
+	for _, status := range []int{http.StatusInternalServerError, http.StatusNotModified, http.StatusUnprocessableEntity} {
+		c := newContext()
+		// the quota is reserved before the handler (the headers show it)
+		if got := checker.Check(scope, c, "client").Limits[constants.PeriodHourly].Used; 1 != got {
+			t.Errorf("Check() hourly used = %v, want %v", got, 1)
+		}
+		c.GetWriter().WriteHeader(status)
+		Settle(c)
+	}
+
+	c := newContext()
+	checker.Check(scope, c, "client")
+	c.GetWriter().WriteHeader(http.StatusOK)
+	Settle(c)
+	c = newContext()
+	if got := checker.Check(scope, c, "client"); constants.ScopeAccessibilityAccessible != got.Accessible || 2 != got.Limits[constants.PeriodHourly].Used {
+		t.Errorf("Check() = %v, want the successful requests only", got)
+	}
+	c.GetWriter().WriteHeader(http.StatusOK)
+	Settle(c)
+
+	// rejected requests are refunded too (from both the root and the path limits)
+	c = newContext()
+	if got := checker.Check(scope, c, "client").Accessible; constants.ScopeAccessibilityForbidden != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityForbidden)
+	}
+	c.GetWriter().WriteHeader(http.StatusTooManyRequests)
+	Settle(c)
+	cacheEntry, _ := configProvider.GetCacheDriver().GetFUPEntry("client_*")
+	if got := cacheEntry.GetUsed(constants.PeriodHourly); 2 != got {
+		t.Errorf("root hourly used = %v, want %v", got, 2)
+	}
+}
+
+func TestSettle_PostHandlerTokenBucket(t *testing.T) {
+	postHandler := constants.FUPAccountingPostHandler
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+		FUP:   &contract.FUPConfig{Accounting: &postHandler},
+	})
+	newContext := func() contract.RequestContext {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/export", nil)
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{
+		"*":       map[string]any{"bucket": map[string]any{"rate": 1, "burst": 300}},
+		"/export": map[string]any{"cost": 100},
+	}
+	checker := TokenBucketFUPChecker{}
+
+	// each request takes its cost from the bucket
+	c := newContext()
+	if got := checker.Check(scope, c, "client").Limits[constants.PeriodTokenBucket].Used; 100 != got {
+		t.Errorf("Check() bucket used = %v, want %v", got, 100)
+	}
+	// the tokens of a failed request are returned
+	c.GetWriter().WriteHeader(http.StatusInternalServerError)
+	Settle(c)
+
+	for i := 0; i < 3; i++ {
+		c = newContext()
+		if got := checker.Check(scope, c, "client").Accessible; constants.ScopeAccessibilityAccessible != got {
+			t.Errorf("Check() #%d = %v, want %v", i, got, constants.ScopeAccessibilityAccessible)
+		}
+		c.GetWriter().WriteHeader(http.StatusOK)
+		Settle(c)
+	}
+	if got := checker.Check(scope, newContext(), "client").Accessible; constants.ScopeAccessibilityForbidden != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityForbidden)
+	}
+}
+
+func TestConcurrencyFUPChecker_Check(t *testing.T) {
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+	})
+	newContext := func(path string) contract.RequestContext {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{
+		"*":        map[string]any{"concurrent": 2},
+		"/reports": map[string]any{"concurrent": 1},
+	}
+	checker := ConcurrencyFUPChecker{}
+
+	report := newContext("/reports")
+	limits := checker.Check(scope, report, "client")
+	if got := limits.Limits[constants.PeriodConcurrent]; constants.ScopeAccessibilityAccessible != limits.Accessible || 1 != got.Limit || 1 != got.Used {
+		t.Errorf("Check() = %v, want the path limit", limits)
+	}
+	limits = checker.Check(scope, newContext("/reports"), "client")
+	if constants.ScopeAccessibilityForbidden != limits.Accessible || contract.ConcurrencyLimitExceeded != limits.GetErrorCode() {
+		t.Errorf("Check() = %v, want %v", limits.Accessible, constants.ScopeAccessibilityForbidden)
+	}
+	// the root slot acquired by the rejected request is released
+	orders := newContext("/orders")
+	if got := checker.Check(scope, orders, "client").Accessible; constants.ScopeAccessibilityAccessible != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityAccessible)
+	}
+	if got := checker.Check(scope, newContext("/orders"), "client").Accessible; constants.ScopeAccessibilityForbidden != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityForbidden)
+	}
+
+	// the slots are released after the handler
+	Settle(report)
+	Settle(orders)
+	if got := checker.Check(scope, newContext("/reports"), "client").Accessible; constants.ScopeAccessibilityAccessible != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityAccessible)
+	}
+
+	// the cache driver must support the semaphores
+	c := newContext("/reports")
+	config.NewProvider(contract.Config{Cache: &contract.CacheConfig{Driver: &basicCacheDriver{}}}).BindTo(c)
+	if got := checker.Check(scope, c, "client").Error; nil == got || contract.ConcurrencyNotSupported != got.Code {
+		t.Errorf("Check() error = %v, want %v", got, contract.ConcurrencyNotSupported)
+	}
+}
+
+// basicCacheDriver is a cache driver without any of the optional capabilities
+type basicCacheDriver struct {
+	contract.CacheDriverInterface
+}
+
+func TestSetLimitsHeaders(t *testing.T) {
+	now := time.Now()
+	clientLimits := contract.FUPScopeLimits{Limits: map[constants.Period]contract.FUPLimits{
+		constants.PeriodMinutely: {Limit: 10, Used: 2, Period: constants.PeriodMinutely, ResetAt: now.Add(time.Second * 30)},
+		constants.PeriodDaily:    {Limit: 1000, Used: 995, Period: constants.PeriodDaily, ResetAt: now.Add(time.Hour)},
+	}}
+	userLimits := contract.FUPScopeLimits{Limits: map[constants.Period]contract.FUPLimits{
+		constants.PeriodConcurrent: {Limit: 3, Used: 1, Period: constants.PeriodConcurrent},
+	}}
+	tests := []struct {
+		name    string
+		headers constants.FUPLimitsHeaders
+		naming  constants.RateLimitHeaderNaming
+		want    map[string]string
+	}{
+		{
+			name:    "JSON",
+			headers: constants.FUPLimitsHeadersJSON,
+			naming:  constants.RateLimitHeaderNamingIETF,
+			want: map[string]string{
+				constants.ClientFUPLimitsHeader: clientLimits.GetLimitsHeader(),
+				constants.UserFUPLimitsHeader:   userLimits.GetLimitsHeader(),
+				constants.RateLimitHeader:       "",
+			},
+		},
+		{
+			name:    "IETF",
+			headers: constants.FUPLimitsHeadersRateLimit,
+			naming:  constants.RateLimitHeaderNamingIETF,
+			want: map[string]string{
+				constants.ClientFUPLimitsHeader: "",
+				constants.RateLimitPolicyHeader: `"client";q=1000;w=86400, "user";q=3`,
+				constants.RateLimitHeader:       `"client";r=5;t=3600, "user";r=2`,
+			},
+		},
+		{
+			name:    "IETF legacy",
+			headers: constants.FUPLimitsHeadersBoth,
+			naming:  constants.RateLimitHeaderNamingIETFLegacy,
+			want: map[string]string{
+				constants.ClientFUPLimitsHeader:    clientLimits.GetLimitsHeader(),
+				constants.RateLimitPolicyHeader:    "3",
+				constants.RateLimitLimitHeader:     "3",
+				constants.RateLimitRemainingHeader: "2",
+				constants.RateLimitResetHeader:     "",
+			},
+		},
+		{
+			name:    "X-prefixed",
+			headers: constants.FUPLimitsHeadersRateLimit,
+			naming:  constants.RateLimitHeaderNamingXPrefixed,
+			want: map[string]string{
+				"X-" + constants.RateLimitLimitHeader:     "3",
+				"X-" + constants.RateLimitRemainingHeader: "2",
+				constants.RateLimitPolicyHeader:           "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configProvider := config.NewProvider(contract.Config{
+				FUP: &contract.FUPConfig{LimitsHeaders: &tt.headers, RateLimitHeaderNaming: &tt.naming},
+			})
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			configProvider.Bind(c)
+			SetLimitsHeaders(contract.NewGinContext(c), "client", constants.ClientFUPLimitsHeader, clientLimits)
+			SetLimitsHeaders(contract.NewGinContext(c), "user", constants.UserFUPLimitsHeader, userLimits)
+			for header, want := range tt.want {
+				if got := recorder.Header().Get(header); want != got {
+					t.Errorf("SetLimitsHeaders() %s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetRateLimitHeaders_Exceeded(t *testing.T) {
+	rateLimit := constants.FUPLimitsHeadersRateLimit
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+		FUP:   &contract.FUPConfig{LimitsHeaders: &rateLimit},
+	})
+	recorder := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	configProvider.Bind(ginContext)
+	c := contract.NewGinContext(ginContext)
+	scope := &contract.FUPScope{"/orders": map[string]any{"hourly": 1}}
+
+	checker := PathFUPChecker{}
+	checker.Check(scope, c, "client")
+	SetRateLimitHeaders(c, "client", checker.Check(scope, c, "client"))
+	var resetAfter int
+	got := recorder.Header().Get(constants.RateLimitHeader)
+	if _, err := fmt.Sscanf(got, `"client";r=0;t=%d`, &resetAfter); nil != err {
+		t.Fatalf("SetRateLimitHeaders() = %q, want an exceeded limit", got)
+	}
+	// the limit resets at the end of the hour
+	if want := time.Until(constants.PeriodHourly.GetResetTime()).Seconds(); math.Abs(float64(resetAfter)-want) > 1 {
+		t.Errorf("SetRateLimitHeaders() reset = %d, want %v", resetAfter, want)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+	})
+	newContext := func(path string) contract.RequestContext {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		c.Request.AddCookie(&http.Cookie{Name: "fup", Value: "visitor"})
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{
+		"*":          map[string]any{"daily": 1000, "concurrent": 5},
+		"/orders":    map[string]any{"hourly": 100, "minutely": -1},
+		"/reports":   map[string]any{"concurrent": 2},
+		"per-ip":     map[string]any{"minutely": 10},
+		"per-cookie": map[string]any{"minutely": 20},
+	}
+	checker := ChainFUPChecker{Checkers: []contract.FUPCheckerInterface{PathFUPChecker{}, IPFUPChecker{}, CookieFUPChecker{CookieName: "fup"}}}
+	checker.Check(scope, newContext("/orders"), "client")
+	checker.Check(scope, newContext("/orders"), "client")
+	checker.Check(scope, newContext("/files"), "client")
+
+	for i := 0; i < 2; i++ {
+		// the query doesn't count a request
+		usage, err := Query(newContext("/fup/usage"), checker, scope, "client")
+		if nil != err {
+			t.Fatalf("Query() error = %v", err)
+		}
+		want := map[string]map[constants.Period]int{
+			"*":                    {constants.PeriodDaily: 3},
+			"/orders":              {constants.PeriodHourly: 2},
+			constants.FUPIPKey:     {constants.PeriodMinutely: 3},
+			constants.FUPCookieKey: {constants.PeriodMinutely: 3},
+		}
+		if len(want) != len(usage) {
+			t.Errorf("Query() = %v, want %v", usage, want)
+		}
+		for key, periods := range want {
+			if len(periods) != len(usage[key]) {
+				t.Errorf("Query() %s = %v, want %v", key, usage[key], periods)
+			}
+			for period, used := range periods {
+				if got := usage[key][period]; used != got.Used || got.ResetAt.IsZero() {
+					t.Errorf("Query() %s %s = %v, want %d used", key, period, got, used)
+				}
+			}
+		}
+	}
+	// the cookie is only used with the CookieFUPChecker
+	usage, _ := Query(newContext("/fup/usage"), PathFUPChecker{}, scope, "client")
+	if _, ok := usage[constants.FUPCookieKey]; ok {
+		t.Errorf("Query() = %v, want no cookie limits", usage)
+	}
+}
+
+type fupEntryChangedSubscriber struct {
+	events chan *contract.FUPEntryChangedEvent
+}
+
+func (s *fupEntryChangedSubscriber) Handle(event events.Event[events.EventPayload]) error {
+	s.events <- event.GetPayload().(*contract.FUPEntryChangedEvent)
+	return nil
+}
+
+func (s *fupEntryChangedSubscriber) GetKey() events.EventKey {
+	return contract.FUPEntryChangedEventKey
+}
+
+func (s *fupEntryChangedSubscriber) GetPriority() int {
+	return 0
+}
+
+func TestAdminEntries(t *testing.T) {
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+	})
+	subscriber := &fupEntryChangedSubscriber{events: make(chan *contract.FUPEntryChangedEvent, 1)}
+	events.GetEventHub().Subscribe(subscriber)
+	newContext := func(path string) contract.RequestContext {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{
+		"*":       map[string]any{"daily": 1000},
+		"/orders": map[string]any{"hourly": 100},
+	}
+	for i := 0; i < 3; i++ {
+		PathFUPChecker{}.Check(scope, newContext("/orders"), "client")
+	}
+	PathFUPChecker{}.Check(scope, newContext("/orders"), "other-client")
+
+	entries, err := ListEntries(configProvider, scope, "client")
+	if nil != err {
+		t.Fatalf("ListEntries() error = %v", err)
+	}
+	if 2 != len(entries) || "*" != entries[0].Id || "-orders" != entries[1].Id {
+		t.Fatalf("ListEntries() = %v, want the entries * and -orders", entries)
+	}
+	if got := entries[1].Usage[constants.PeriodHourly]; 3 != got.Used || got.ResetAt.IsZero() {
+		t.Errorf("ListEntries() -orders hourly = %v, want %d used", got, 3)
+	}
+
+	if err := AdjustEntry(configProvider, scope, "client", "/orders", constants.PeriodHourly, -2, "admin"); nil != err {
+		t.Fatalf("AdjustEntry() error = %v", err)
+	}
+	want := &contract.FUPEntryChangedEvent{Key: "client", Id: "/orders", Period: constants.PeriodHourly, Action: constants.FUPEntryActionAdjust, Delta: -2, Actor: "admin"}
+	if got := <-subscriber.events; !reflect.DeepEqual(want, got) {
+		t.Errorf("AdjustEntry() event = %v, want %v", got, want)
+	}
+	entries, _ = ListEntries(configProvider, scope, "client")
+	if got := entries[1].Usage[constants.PeriodHourly].Used; 1 != got {
+		t.Errorf("AdjustEntry() used = %d, want %d", got, 1)
+	}
+
+	if err := ResetEntry(configProvider, scope, "client", "-orders", "", "admin"); nil != err {
+		t.Fatalf("ResetEntry() error = %v", err)
+	}
+	want = &contract.FUPEntryChangedEvent{Key: "client", Id: "-orders", Action: constants.FUPEntryActionReset, Actor: "admin"}
+	if got := <-subscriber.events; !reflect.DeepEqual(want, got) {
+		t.Errorf("ResetEntry() event = %v, want %v", got, want)
+	}
+	entries, _ = ListEntries(configProvider, scope, "client")
+	if 0 != len(entries[1].Usage) {
+		t.Errorf("ResetEntry() usage = %v, want no usage", entries[1].Usage)
+	}
+	// the entries of the other keys are kept
+	entries, _ = ListEntries(configProvider, scope, "other-client")
+	if got := entries[1].Usage[constants.PeriodHourly].Used; 1 != got {
+		t.Errorf("ResetEntry() other-client used = %d, want %d", got, 1)
+	}
+
+	if err := ResetEntry(configProvider, scope, "client", "*", "fortnightly", "admin"); nil == err || contract.InvalidRequest != err.Code {
+		t.Errorf("ResetEntry() error = %v, want %v", err, contract.InvalidRequest)
+	}
+	disabledProvider := config.NewProvider(contract.Config{})
+	if _, err := ListEntries(disabledProvider, scope, "client"); nil == err || contract.FUPCacheDisabled != err.Code {
+		t.Errorf("ListEntries() error = %v, want %v", err, contract.FUPCacheDisabled)
+	}
+}
+
+func TestAdminEntries_KeyPrefix(t *testing.T) {
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+	})
+	newContext := func(path string) contract.RequestContext {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{"*": map[string]any{"daily": 1000}}
+	PathFUPChecker{}.Check(scope, newContext("/orders"), "bob")
+	for i := 0; i < 2; i++ {
+		PathFUPChecker{}.Check(scope, newContext("/orders"), "bob_smith")
+	}
+
+	// the entries of bob_smith are not listed as the entries of bob
+	entries, err := ListEntries(configProvider, scope, "bob")
+	if nil != err {
+		t.Fatalf("ListEntries() error = %v", err)
+	}
+	if 1 != len(entries) || "*" != entries[0].Id || 1 != entries[0].Usage[constants.PeriodDaily].Used {
+		t.Fatalf("ListEntries() = %v, want the entry * used once", entries)
+	}
+	for _, entry := range entries {
+		if err := ResetEntry(configProvider, scope, "bob", entry.Id, "", "admin"); nil != err {
+			t.Fatalf("ResetEntry() error = %v", err)
+		}
+	}
+	entries, _ = ListEntries(configProvider, scope, "bob_smith")
+	if 1 != len(entries) || "*" != entries[0].Id || 2 != entries[0].Usage[constants.PeriodDaily].Used {
+		t.Errorf("ListEntries() bob_smith = %v, want the entry * used twice", entries)
+	}
+}
+
+func TestTemplateFUPChecker_Check(t *testing.T) {
+	configProvider := config.NewProvider(contract.Config{
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+	})
+	newContext := func(version string) contract.RequestContext {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+		c.Request.Header.Set("X-Api-Version", version)
+		configProvider.Bind(c)
+		return contract.NewGinContext(c)
+	}
+	scope := &contract.FUPScope{
+		"get:1%2e2:/orders": map[string]any{"hourly": 1},
+		"get:2:/orders":     map[string]any{"hourly": 2},
+	}
+	checker := TemplateFUPChecker{Template: "{method}:{header:X-Api-Version}:{path}"}
+
+	// the version containing a dot is looked up as a single key
+	limits := checker.Check(scope, newContext("1.2"), "client")
+	if got := limits.Limits[constants.PeriodHourly]; constants.ScopeAccessibilityAccessible != limits.Accessible || 1 != got.Limit || 1 != got.Used {
+		t.Errorf("Check() = %v, want the 1.2 limits", limits)
+	}
+	if got := checker.Check(scope, newContext("1.2"), "client").Accessible; constants.ScopeAccessibilityForbidden != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityForbidden)
+	}
+	// the other versions are counted separately
+	if got := checker.Check(scope, newContext("2"), "client"); constants.ScopeAccessibilityAccessible != got.Accessible || 2 != got.Limits[constants.PeriodHourly].Limit {
+		t.Errorf("Check() = %v, want the 2 limits", got)
+	}
+	if got := checker.Check(scope, newContext("3"), "client").Accessible; constants.ScopeAccessibilityForbidden == got {
+		t.Errorf("Check() = %v, want no limits", got)
+	}
+	if got := checker.Check(scope, nil, "client").Accessible; constants.ScopeAccessibilityUnlimited != got {
+		t.Errorf("Check() = %v, want %v", got, constants.ScopeAccessibilityUnlimited)
+	}
+}
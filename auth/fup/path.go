@@ -1,7 +1,6 @@
 package fup
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
 	"strings"
@@ -11,11 +10,11 @@ import (
 type PathFUPChecker struct {
 }
 
-func (ch PathFUPChecker) Check(scope *contract.FUPScope, c *gin.Context, key string) contract.FUPScopeLimits {
-	if nil == scope || nil == c || nil == c.Request || nil == c.Request.URL {
+func (ch PathFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	if nil == scope || nil == c || nil == c.GetRequest() || nil == c.GetRequest().URL {
 		// no limitations by default
 		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
 	}
-	path := strings.ToLower(c.Request.URL.Path)
-	return check(path, scope, key)
+	path := strings.ToLower(c.GetRequest().URL.Path)
+	return check(path, scope, key, c)
 }
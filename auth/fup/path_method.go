@@ -2,7 +2,6 @@ package fup
 
 import (
 	"fmt"
-	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
 	"strings"
@@ -12,13 +11,13 @@ import (
 type PathAndMethodFUPChecker struct {
 }
 
-func (ch PathAndMethodFUPChecker) Check(scope *contract.FUPScope, c *gin.Context, key string) contract.FUPScopeLimits {
-	if nil == scope || nil == c || nil == c.Request || nil == c.Request.URL {
+func (ch PathAndMethodFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	if nil == scope || nil == c || nil == c.GetRequest() || nil == c.GetRequest().URL {
 		// no limitations by default
 		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
 	}
-	path := strings.ToLower(c.Request.URL.Path)
-	method := strings.ToLower(c.Request.Method)
+	path := strings.ToLower(c.GetRequest().URL.Path)
+	method := strings.ToLower(c.GetRequest().Method)
 	combinedPath := fmt.Sprintf("%s:%s", method, path)
-	return check(combinedPath, scope, key)
+	return check(combinedPath, scope, key, c)
 }
@@ -0,0 +1,21 @@
+package fup
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"strings"
+)
+
+// RouteFUPChecker is an implementation of the FUPCheckerInterface for the route template-based access model
+// (limits apply per logical endpoint, e.g. `/orders/:id`, rather than per requested URL path)
+type RouteFUPChecker struct {
+}
+
+func (ch RouteFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	if nil == scope || nil == c || "" == c.FullPath() {
+		// no limitations by default
+		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
+	}
+	route := strings.ToLower(c.FullPath())
+	return check(route, scope, key, c)
+}
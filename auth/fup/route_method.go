@@ -0,0 +1,23 @@
+package fup
+
+import (
+	"fmt"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"strings"
+)
+
+// RouteAndMethodFUPChecker is an implementation of the FUPCheckerInterface for the route template and method-based access model
+type RouteAndMethodFUPChecker struct {
+}
+
+func (ch RouteAndMethodFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	if nil == scope || nil == c || nil == c.GetRequest() || "" == c.FullPath() {
+		// no limitations by default
+		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
+	}
+	route := strings.ToLower(c.FullPath())
+	method := strings.ToLower(c.GetRequest().Method)
+	combinedRoute := fmt.Sprintf("%s:%s", method, route)
+	return check(combinedRoute, scope, key, c)
+}
@@ -0,0 +1,21 @@
+package fup
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/checker"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+)
+
+// TemplateFUPChecker is an implementation of the FUPCheckerInterface for the access model
+// keyed by a configurable template (see checker.RenderKeyTemplate)
+type TemplateFUPChecker struct {
+	Template string
+}
+
+func (ch TemplateFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	if nil == scope || nil == c || nil == c.GetRequest() || nil == c.GetRequest().URL {
+		// no limitations by default
+		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
+	}
+	return check(checker.RenderKeyTemplate(ch.Template, c), scope, key, c)
+}
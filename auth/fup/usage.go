@@ -0,0 +1,121 @@
+package fup
+
+import (
+	"fmt"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"strings"
+	"time"
+)
+
+// GetClientKey returns the FUP key of the client (the API key is a part of the key if an additional API key is used, as it might have different limits)
+func GetClientKey(apiClient contract.ApiClientInterface) string {
+	if nil != apiClient.GetCurrentApiKey() {
+		return fmt.Sprintf("%s:%s", apiClient.GetClientId(), apiClient.GetCurrentApiKey().GetKey())
+	}
+	return apiClient.GetClientId()
+}
+
+// GetOrganisationKey returns the FUP key of the organisation (tenant) with the given id
+func GetOrganisationKey(tenantId string) string {
+	return fmt.Sprintf("organisation:%s", tenantId)
+}
+
+// getCookieName returns the name of the cookie used by the CookieFUPChecker among the checkers (or an empty string)
+func getCookieName(checker contract.FUPCheckerInterface) string {
+	switch typedChecker := checker.(type) {
+	case CookieFUPChecker:
+		return typedChecker.getCookieName()
+	case ChainFUPChecker:
+		for _, chainedChecker := range typedChecker.Checkers {
+			if name := getCookieName(chainedChecker); "" != name {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// queryEntry reads the counters without counting a request (atomically if the cache driver implements AtomicFUPCacheDriverInterface)
+func queryEntry(cacheDriver contract.CacheDriverInterface, cacheKey string, algorithm constants.FUPAlgorithm, now time.Time) (*contract.FUPCacheEntry, *contract.AuthError) {
+	if atomicCacheDriver, ok := cacheDriver.(contract.AtomicFUPCacheDriverInterface); ok {
+		return atomicCacheDriver.QueryFUPEntry(cacheKey, algorithm, now)
+	}
+	return cacheDriver.GetFUPEntry(cacheKey)
+}
+
+func queryLimits(configProvider *config.Provider, scope *contract.FUPScope, key string, cacheId string, path string, now time.Time) (map[constants.Period]contract.FUPLimits, *contract.AuthError) {
+	var periods []constants.Period
+	for _, period := range constants.FUPScopePeriods {
+		if limit := scope.GetPeriodLimit(path, period); nil != limit && *limit >= 0 {
+			periods = append(periods, period)
+		}
+	}
+	if 0 == len(periods) {
+		return nil, nil
+	}
+	algorithm := configProvider.GetFUPAlgorithm()
+	cacheKey := getCacheKey(key, cacheId)
+	cacheEntry, err := queryEntry(configProvider.GetCacheDriver(), cacheKey, algorithm, now)
+	if nil != err {
+		return nil, err
+	}
+	limits := make(map[constants.Period]contract.FUPLimits)
+	for _, period := range periods {
+		limits[period] = contract.FUPLimits{
+			Limit:   *scope.GetPeriodLimit(path, period),
+			Used:    cacheEntry.GetUsedAt(algorithm, period, now),
+			Period:  period,
+			ResetAt: cacheEntry.GetResetTimeAt(algorithm, period, now),
+		}
+	}
+	return limits, nil
+}
+
+// Query returns the usage of the period limits of the FUP scope counted under key without counting a request
+// (e.g. `{"*": {"daily": {...}}, "/orders": {"hourly": {...}}, "per-ip": {"minutely": {...}}}`);
+// the limits of the paths (or routes, templates etc.) set in the scope, the root limits (`*`) and the limits of the IP address and the FUP cookie of the request are returned
+// NOTE: the pattern (regex, glob) scope keys match many paths, so their usage can't be returned
+func Query(c contract.RequestContext, checker contract.FUPCheckerInterface, scope *contract.FUPScope, key string) (map[string]map[constants.Period]contract.FUPLimits, *contract.AuthError) {
+	usage := make(map[string]map[constants.Period]contract.FUPLimits)
+	if nil == scope {
+		return usage, nil
+	}
+	configProvider := config.GetProvider(c)
+	if !configProvider.IsCacheEnabled() {
+		return nil, contract.NewInternalError(contract.FUPCacheDisabled, nil)
+	}
+	// the calendar periods reset in the time zone of the FUP scope
+	now := time.Now().In(scope.GetLocation())
+
+	addLimits := func(name string, cacheId string, path string) *contract.AuthError {
+		limits, err := queryLimits(configProvider, scope, key, cacheId, path, now)
+		if nil != err {
+			return err
+		}
+		if nil != limits {
+			usage[name] = limits
+		}
+		return nil
+	}
+	for _, scopeKey := range scope.GetLimitedKeys() {
+		path := strings.ToLower(scopeKey)
+		if err := addLimits(path, path, path); nil != err {
+			return nil, err
+		}
+	}
+	if ip := c.ClientIP(); "" != ip {
+		if err := addLimits(constants.FUPIPKey, ip, constants.FUPIPKey); nil != err {
+			return nil, err
+		}
+	}
+	if name := getCookieName(checker); "" != name {
+		if cookie, err := c.Cookie(name); nil == err && "" != cookie {
+			if err := addLimits(constants.FUPCookieKey, cookie, constants.FUPCookieKey); nil != err {
+				return nil, err
+			}
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,245 @@
+// Package grpcauth provides gRPC server interceptors running the same authentication pipeline as the gin middleware.
+// The credentials are read from the gRPC metadata and the scopes are checked against the full method name
+// (e.g. `/orders.v1.orderservice/createorder` - use a path-based checker; paths are lowercased).
+package grpcauth
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/fup"
+	"github.com/wernerdweight/api-auth-go/v2/auth/security"
+	"github.com/wernerdweight/events-go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// credentialHeaders are copied from the gRPC metadata (lowercase keys) to the authenticated request
+var credentialHeaders = []string{
+	constants.ClientIdHeader,
+	constants.ClientSecretHeader,
+	constants.ApiUserTokenHeader,
+	constants.ApiKeyHeader,
+	constants.OneOffTokenHeader,
+}
+
+// httpStatuses maps the gRPC status codes returned by the handlers to the HTTP status codes the FUP charges are settled with
+// (see FUPConfig.ChargedStatusCodes); unknown codes map to 500
+var httpStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// requestContextKey is the key of the request context of the call stored in the authenticated context (see SetCost)
+type requestContextKey struct{}
+
+// authentication is the result of authenticating a single call
+type authentication struct {
+	ctx    context.Context
+	header http.Header
+	err    *contract.AuthError
+	// settle settles the FUP charges and releases the concurrency slots of the call with the error returned by the handler (see fup.Settle)
+	settle func(err error)
+}
+
+// headerWriter is a http.ResponseWriter that only keeps the headers (the response body is never used)
+type headerWriter struct {
+	header http.Header
+}
+
+func (w *headerWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *headerWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *headerWriter) WriteHeader(int) {
+}
+
+func authenticate(configProvider *config.Provider, ctx context.Context, fullMethod string) (*authentication, *contract.AuthError) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fullMethod, nil)
+	if nil != err {
+		return nil, contract.NewAuthError(contract.InvalidRequest, map[string]string{"details": err.Error()})
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, header := range credentialHeaders {
+			for _, value := range md.Get(strings.ToLower(header)) {
+				request.Header.Add(header, value)
+			}
+		}
+	}
+	if callPeer, ok := peer.FromContext(ctx); ok && nil != callPeer.Addr {
+		request.RemoteAddr = callPeer.Addr.String()
+	}
+
+	writer := &headerWriter{header: http.Header{}}
+	c := contract.NewHttpContext(writer, request)
+	configProvider.BindTo(c)
+	result := &authentication{ctx: ctx, header: writer.header}
+	result.err = security.Authenticate(c)
+	if nil == result.err {
+		result.ctx = context.WithValue(contract.NewAuthContext(ctx, c), requestContextKey{}, contract.RequestContext(c))
+		// the slots are held and the charges are kept until the call is handled
+		result.settle = func(err error) {
+			c.GetWriter().WriteHeader(getHttpStatus(err))
+			fup.Settle(c)
+		}
+		return result, nil
+	}
+	c.GetWriter().WriteHeader(result.err.Status)
+	fup.Settle(c)
+	events.GetEventHub().DispatchAsync(&contract.AuthenticationFailedEvent{
+		Error:   *result.err,
+		Context: c,
+		Response: gin.H{
+			"code":    result.err.Code,
+			"message": result.err.Err.Error(),
+			"payload": result.err.Payload,
+		},
+	})
+	return result, nil
+}
+
+// toMetadata converts the response headers set during the authentication (e.g. FUP limits) to gRPC metadata
+func toMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		md.Append(strings.ToLower(key), values...)
+	}
+	return md
+}
+
+// ToStatus maps an AuthError to a gRPC status error (FUP depletion maps to ResourceExhausted with retry info)
+func ToStatus(err *contract.AuthError, retryAfter int) error {
+	code := codes.Unauthenticated
+	switch {
+	case http.StatusTooManyRequests == err.Status:
+		code = codes.ResourceExhausted
+	case http.StatusInternalServerError == err.Status:
+		code = codes.Internal
+	case contract.ClientForbidden == err.Code || contract.UserForbidden == err.Code || contract.TenantMismatch == err.Code:
+		code = codes.PermissionDenied
+	}
+	callStatus := status.New(code, err.Err.Error())
+	if codes.ResourceExhausted == code && retryAfter >= 0 {
+		withDetails, detailsErr := callStatus.WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(time.Duration(retryAfter) * time.Second),
+		})
+		if nil != detailsErr {
+			log.Printf("can't add retry info to the status: %v", detailsErr)
+		} else {
+			callStatus = withDetails
+		}
+	}
+	return callStatus.Err()
+}
+
+// getHttpStatus returns the HTTP status code of the error returned by the handler (200 if nil)
+func getHttpStatus(err error) int {
+	if httpStatus, ok := httpStatuses[status.Code(err)]; ok {
+		return httpStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// SetCost reports the actual cost of the call from the handler (e.g. the number of rows returned, see fup.SetCost);
+// ctx is the context passed to the handler (or the context of the stream)
+func SetCost(ctx context.Context, cost int) {
+	if c, ok := ctx.Value(requestContextKey{}).(contract.RequestContext); ok {
+		fup.SetCost(c, cost)
+	}
+}
+
+func getRetryAfter(header http.Header) int {
+	retryAfter, err := strconv.Atoi(header.Get(constants.RetryAfterHeader))
+	if nil != err {
+		return -1
+	}
+	return retryAfter
+}
+
+// authenticateCall authenticates the call and returns the context carrying the authenticated client and user
+// and a function settling the FUP charges of the call (to be called with the error returned by the handler once the call is handled)
+func authenticateCall(configProvider *config.Provider, ctx context.Context, fullMethod string, setHeader func(metadata.MD) error) (context.Context, func(error), error) {
+	result, err := authenticate(configProvider, ctx, fullMethod)
+	if nil != err {
+		return nil, nil, ToStatus(err, -1)
+	}
+	if md := toMetadata(result.header); md.Len() > 0 {
+		if headerErr := setHeader(md); nil != headerErr {
+			log.Printf("can't set response metadata: %v", headerErr)
+		}
+	}
+	if nil != result.err {
+		return nil, nil, ToStatus(result.err, getRetryAfter(result.header))
+	}
+	return result.ctx, result.settle, nil
+}
+
+// UnaryServerInterceptor returns an interceptor authenticating unary calls based on the given configuration instance (see auth.New);
+// the authenticated client and user are available through contract.ApiClientFromContext and contract.ApiUserFromContext
+func UnaryServerInterceptor(configProvider *config.Provider) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (response any, err error) {
+		authCtx, settle, err := authenticateCall(configProvider, ctx, info.FullMethod, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		})
+		if nil != err {
+			return nil, err
+		}
+		defer func() {
+			settle(err)
+		}()
+		return handler(authCtx, req)
+	}
+}
+
+// serverStream overrides the context of the wrapped stream with the authenticated one
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamServerInterceptor returns an interceptor authenticating streaming calls based on the given configuration instance (see auth.New);
+// the authenticated client and user are available through contract.ApiClientFromContext and contract.ApiUserFromContext
+func StreamServerInterceptor(configProvider *config.Provider) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		authCtx, settle, err := authenticateCall(configProvider, ss.Context(), info.FullMethod, ss.SetHeader)
+		if nil != err {
+			return err
+		}
+		defer func() {
+			settle(err)
+		}()
+		return handler(srv, &serverStream{ServerStream: ss, ctx: authCtx})
+	}
+}
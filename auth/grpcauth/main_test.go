@@ -0,0 +1,223 @@
+package grpcauth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth"
+	"github.com/wernerdweight/api-auth-go/v2/auth/cache"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/entity"
+	"github.com/wernerdweight/api-auth-go/v2/auth/fup"
+	"github.com/wernerdweight/api-auth-go/v2/auth/provider"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+const (
+	listOrders  = "/orders.v1.OrderService/ListOrders"
+	createOrder = "/orders.v1.OrderService/CreateOrder"
+)
+
+func newConfigProvider() *config.Provider {
+	enabled := true
+	return auth.New(contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{
+				{
+					Id:     "client",
+					Secret: "secret",
+					// scopes are checked against the lowercased full method name
+					AccessScope: &contract.AccessScope{"/orders.v1.orderservice/listorders": true},
+					FUPScope:    &contract.FUPScope{"/orders.v1.orderservice/listorders": map[string]any{"minutely": 1}},
+				},
+			}),
+			UseScopeAccessModel: &enabled,
+			FUPChecker:          fup.PathFUPChecker{},
+		},
+		Cache: &contract.CacheConfig{
+			Driver: cache.NewMemoryCacheDriver(),
+		},
+	})
+}
+
+func newIncomingContext(withCredentials bool) context.Context {
+	if !withCredentials {
+		return metadata.NewIncomingContext(context.Background(), metadata.MD{})
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-client-id", "client", "x-client-secret", "secret"))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor(newConfigProvider())
+	handler := func(ctx context.Context, req any) (any, error) {
+		return contract.ApiClientFromContext(ctx).GetClientId(), nil
+	}
+	call := func(ctx context.Context, fullMethod string) (any, error) {
+		return interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod}, handler)
+	}
+
+	response, err := call(newIncomingContext(true), listOrders)
+	assert.NoError(t, err)
+	assert.Equal(t, "client", response)
+
+	_, err = call(newIncomingContext(false), listOrders)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = call(newIncomingContext(true), createOrder)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = call(newIncomingContext(true), listOrders)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	details := status.Convert(err).Details()
+	if assert.Len(t, details, 1) {
+		assert.IsType(t, &errdetails.RetryInfo{}, details[0])
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *mockServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *mockServerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor(newConfigProvider())
+	var apiClient contract.ApiClientInterface
+	handler := func(srv any, stream grpc.ServerStream) error {
+		apiClient = contract.ApiClientFromContext(stream.Context())
+		return nil
+	}
+
+	stream := &mockServerStream{ctx: newIncomingContext(true)}
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: listOrders}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "client", apiClient.GetClientId())
+	assert.NotEmpty(t, stream.header.Get("x-client-fup-limits"))
+
+	err = interceptor(nil, &mockServerStream{ctx: newIncomingContext(false)}, &grpc.StreamServerInfo{FullMethod: listOrders}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *contract.AuthError
+		want codes.Code
+	}{
+		{name: "Authentication error", err: contract.NewAuthError(contract.ClientNotFound, nil), want: codes.Unauthenticated},
+		{name: "Forbidden", err: contract.NewAuthError(contract.UserForbidden, nil), want: codes.PermissionDenied},
+		{name: "Tenant mismatch", err: contract.NewAuthError(contract.TenantMismatch, nil), want: codes.PermissionDenied},
+		{name: "FUP depleted", err: contract.NewFUPError(contract.RequestLimitDepleted, nil), want: codes.ResourceExhausted},
+		{name: "Internal error", err: contract.NewInternalError(contract.DatabaseError, nil), want: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ToStatus(tt.err, -1)
+			assert.Equal(t, tt.want, status.Code(err))
+			assert.Equal(t, tt.err.Err.Error(), status.Convert(err).Message())
+			assert.Empty(t, status.Convert(err).Details())
+		})
+	}
+}
+
+func TestUnaryServerInterceptor_Concurrency(t *testing.T) {
+	enabled := true
+	interceptor := UnaryServerInterceptor(auth.New(contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{
+				{
+					Id:          "client",
+					Secret:      "secret",
+					AccessScope: &contract.AccessScope{"/orders.v1.orderservice/listorders": true},
+					FUPScope:    &contract.FUPScope{"*": map[string]any{"concurrent": 1}},
+				},
+			}),
+			UseScopeAccessModel: &enabled,
+			FUPChecker:          fup.ConcurrencyFUPChecker{},
+		},
+		Cache: &contract.CacheConfig{
+			Driver: cache.NewMemoryCacheDriver(),
+		},
+	}))
+	var nestedErr error
+	var handler grpc.UnaryHandler
+	handler = func(ctx context.Context, req any) (any, error) {
+		if nil == req {
+			// the slot is held while the call is handled
+			_, nestedErr = interceptor(newIncomingContext(true), "nested", &grpc.UnaryServerInfo{FullMethod: listOrders}, handler)
+		}
+		return "ok", nil
+	}
+	call := func() (any, error) {
+		return interceptor(newIncomingContext(true), nil, &grpc.UnaryServerInfo{FullMethod: listOrders}, handler)
+	}
+
+	_, err := call()
+	assert.NoError(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(nestedErr))
+	assert.Contains(t, status.Convert(nestedErr).Message(), contract.AuthErrorCodes[contract.ConcurrencyLimitExceeded])
+	// the slot is released once the call is handled
+	_, err = call()
+	assert.NoError(t, err)
+}
+
+func TestUnaryServerInterceptor_PostHandlerAccounting(t *testing.T) {
+	enabled := true
+	postHandler := constants.FUPAccountingPostHandler
+	interceptor := UnaryServerInterceptor(auth.New(contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{
+				{
+					Id:          "client",
+					Secret:      "secret",
+					AccessScope: &contract.AccessScope{"/orders.v1.orderservice/listorders": true},
+					FUPScope:    &contract.FUPScope{"/orders.v1.orderservice/listorders": map[string]any{"minutely": 3}},
+				},
+			}),
+			UseScopeAccessModel: &enabled,
+			FUPChecker:          fup.PathFUPChecker{},
+		},
+		Cache: &contract.CacheConfig{
+			Driver: cache.NewMemoryCacheDriver(),
+		},
+		FUP: &contract.FUPConfig{Accounting: &postHandler},
+	}))
+	call := func(handler grpc.UnaryHandler) error {
+		_, err := interceptor(newIncomingContext(true), nil, &grpc.UnaryServerInfo{FullMethod: listOrders}, handler)
+		return err
+	}
+	failed := func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.Internal, "failed")
+	}
+	succeeded := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+
+	// the quota of the failed calls is refunded
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, codes.Internal, status.Code(call(failed)))
+	}
+	assert.NoError(t, call(succeeded))
+
+	// the cost reported by the handler is charged after the call
+	assert.NoError(t, call(func(ctx context.Context, req any) (any, error) {
+		SetCost(ctx, 2)
+		return "ok", nil
+	}))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call(succeeded)))
+}
@@ -4,12 +4,37 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/config"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/fup"
 	"github.com/wernerdweight/api-auth-go/v2/auth/security"
-	"github.com/wernerdweight/events-go"
 	"log"
 	"net/http"
 )
 
+// Init initializes the default configuration (and the cache driver) without installing the engine-wide middleware.
+// Use it if you only want to attach RequireClient, RequireUser, OptionalUser or RequireScope to selected route groups/routes.
+func Init(c contract.Config) {
+	config.ProviderInstance.Init(c)
+	initCache(config.ProviderInstance)
+}
+
+// New returns a new configuration instance (with an initialized cache driver) independent of the default one.
+// Use it to set up several auth configurations in one process (see MiddlewareFor and routes.RegisterFor).
+func New(c contract.Config) *config.Provider {
+	configProvider := config.NewProvider(c)
+	initCache(configProvider)
+	return configProvider
+}
+
+func initCache(configProvider *config.Provider) {
+	if configProvider.IsCacheEnabled() {
+		log.Println("initializing cache driver...")
+		configProvider.GetCacheDriver().Init(
+			configProvider.GetCachePrefix(),
+			configProvider.GetCacheTTL(),
+		)
+	}
+}
+
 // Middleware returns a gin.HandlerFunc that authenticates requests based on the provided config.
 // Auth routes (e.g. /authenticate, /registration/*) are NOT registered automatically.
 // You must call routes.Register(r) after r.Use(auth.Middleware(...)) to register them:
@@ -18,44 +43,46 @@ import (
 //	routes.Register(r)
 func Middleware(r *gin.Engine, c contract.Config) gin.HandlerFunc {
 	log.Println("setting up api-auth middleware...")
-	config.ProviderInstance.Init(c)
-	if config.ProviderInstance.IsCacheEnabled() {
-		log.Println("initializing cache driver...")
-		config.ProviderInstance.GetCacheDriver().Init(
-			config.ProviderInstance.GetCachePrefix(),
-			config.ProviderInstance.GetCacheTTL(),
-		)
-	}
+	Init(c)
+	return MiddlewareFor(config.ProviderInstance)
+}
 
-	if !config.ProviderInstance.IsApiKeyModeEnabled() && !config.ProviderInstance.IsClientIdAndSecretModeEnabled() {
+// MiddlewareFor returns a gin.HandlerFunc that authenticates requests based on the given configuration instance (see New).
+// You must call routes.RegisterFor(r, configProvider) after r.Use(auth.MiddlewareFor(configProvider)) to register the auth routes:
+//
+//	admin := auth.New(adminCfg)
+//	adminGroup := r.Group("/admin", auth.MiddlewareFor(admin))
+//	routes.RegisterFor(adminGroup, admin)
+func MiddlewareFor(configProvider *config.Provider) gin.HandlerFunc {
+	if !configProvider.IsAuthenticationEnabled() {
 		log.Println("api-auth is disabled")
 		return func(c *gin.Context) {
+			configProvider.Bind(c)
 			c.Next()
 		}
 	}
 
 	return func(c *gin.Context) {
-		if config.ProviderInstance.ShouldExcludeOptionsRequests() && http.MethodOptions == c.Request.Method {
+		configProvider.Bind(c)
+		if configProvider.ShouldExcludeOptionsRequests() && http.MethodOptions == c.Request.Method {
 			c.Next()
 			return
 		}
 
-		err := security.Authenticate(c)
+		requestContext := contract.NewGinContext(c)
+		err := security.Authenticate(requestContext)
 		if nil != err {
-			errorResponse := gin.H{
-				"code":    err.Code,
-				"message": err.Err.Error(),
-				"payload": err.Payload,
-			}
-			c.AbortWithStatusJSON(err.Status, errorResponse)
-			events.GetEventHub().DispatchAsync(&contract.AuthenticationFailedEvent{
-				Error:    *err,
-				Context:  c,
-				Response: errorResponse,
-			})
+			abortWithError(c, err)
+			fup.Settle(requestContext)
 			return
 		}
 
 		c.Next()
+		fup.Settle(requestContext)
 	}
 }
+
+func abortWithError(c *gin.Context, err *contract.AuthError) {
+	c.Abort()
+	security.RespondWithError(contract.NewGinContext(c), err)
+}
@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+type node interface {
+	evaluate(env *Environment) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) evaluate(env *Environment) (any, error) {
+	return n.value, nil
+}
+
+type identNode struct {
+	name string
+}
+
+func (n identNode) evaluate(env *Environment) (any, error) {
+	// unknown variables evaluate to null, so that e.g. `user.login` is null for requests without a user
+	return normalize(env.Variables[n.name]), nil
+}
+
+type memberNode struct {
+	target node
+	name   string
+}
+
+func (n memberNode) evaluate(env *Environment) (any, error) {
+	target, err := n.target.evaluate(env)
+	if nil != err {
+		return nil, err
+	}
+	if typedTarget, ok := target.(map[string]any); ok {
+		return normalize(typedTarget[n.name]), nil
+	}
+	return nil, nil
+}
+
+type callNode struct {
+	name      string
+	arguments []node
+}
+
+func (n callNode) evaluate(env *Environment) (any, error) {
+	function, ok := env.Functions[n.name]
+	if !ok {
+		function, ok = builtinFunctions[n.name]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s'", n.name)
+	}
+	arguments := make([]any, len(n.arguments))
+	for i, argument := range n.arguments {
+		value, err := argument.evaluate(env)
+		if nil != err {
+			return nil, err
+		}
+		arguments[i] = value
+	}
+	result, err := function(arguments...)
+	if nil != err {
+		return nil, fmt.Errorf("%s(): %v", n.name, err)
+	}
+	return normalize(result), nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n listNode) evaluate(env *Environment) (any, error) {
+	items := make([]any, len(n.items))
+	for i, item := range n.items {
+		value, err := item.evaluate(env)
+		if nil != err {
+			return nil, err
+		}
+		items[i] = value
+	}
+	return items, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) evaluate(env *Environment) (any, error) {
+	value, err := n.operand.evaluate(env)
+	if nil != err {
+		return nil, err
+	}
+	return !isTruthy(value), nil
+}
+
+type ternaryNode struct {
+	condition node
+	then      node
+	otherwise node
+}
+
+func (n ternaryNode) evaluate(env *Environment) (any, error) {
+	condition, err := n.condition.evaluate(env)
+	if nil != err {
+		return nil, err
+	}
+	if isTruthy(condition) {
+		return n.then.evaluate(env)
+	}
+	return n.otherwise.evaluate(env)
+}
+
+type binaryNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n binaryNode) evaluate(env *Environment) (any, error) {
+	left, err := n.left.evaluate(env)
+	if nil != err {
+		return nil, err
+	}
+	// logical operators short-circuit
+	if "&&" == n.operator && !isTruthy(left) {
+		return false, nil
+	}
+	if "||" == n.operator && isTruthy(left) {
+		return true, nil
+	}
+	right, err := n.right.evaluate(env)
+	if nil != err {
+		return nil, err
+	}
+	switch n.operator {
+	case "&&", "||":
+		return isTruthy(right), nil
+	case "==":
+		return isEqual(left, right), nil
+	case "!=":
+		return !isEqual(left, right), nil
+	case "in":
+		return contains(right, left), nil
+	}
+	return compare(n.operator, left, right)
+}
+
+func compare(operator string, left any, right any) (bool, error) {
+	var result int
+	leftNumber, leftIsNumber := left.(float64)
+	rightNumber, rightIsNumber := right.(float64)
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+	switch {
+	case leftIsNumber && rightIsNumber:
+		if leftNumber < rightNumber {
+			result = -1
+		} else if leftNumber > rightNumber {
+			result = 1
+		}
+	case leftIsString && rightIsString:
+		result = strings.Compare(leftString, rightString)
+	default:
+		return false, fmt.Errorf("can't compare %v and %v using '%s'", left, right, operator)
+	}
+	switch operator {
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	case ">=":
+		return result >= 0, nil
+	}
+	return false, fmt.Errorf("unknown operator '%s'", operator)
+}
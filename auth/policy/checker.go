@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/checker"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"log"
+	"strings"
+)
+
+// policyScopePrefix marks a scope value as a policy expression. The prefix is stripped before compilation.
+const policyScopePrefix = "p#"
+
+// AccessScopeChecker is an implementation of the AccessScopeCheckerInterface that evaluates policy expressions
+// attached to scope entries and then delegates the check to the wrapped Checker (defaults to PathAccessScopeChecker)
+type AccessScopeChecker struct {
+	Checker contract.AccessScopeCheckerInterface
+}
+
+func (ch AccessScopeChecker) getChecker() contract.AccessScopeCheckerInterface {
+	if nil == ch.Checker {
+		return checker.PathAccessScopeChecker{}
+	}
+	return ch.Checker
+}
+
+func (ch AccessScopeChecker) Check(scope *contract.AccessScope, c contract.RequestContext) constants.ScopeAccessibility {
+	if nil == scope || nil == c {
+		return ch.getChecker().Check(scope, c)
+	}
+	var env *Environment
+	getEnvironment := func() *Environment {
+		// the environment is only built if the scope contains a policy
+		if nil == env {
+			env = NewEnvironment(c)
+		}
+		return env
+	}
+	resolved := contract.AccessScope(resolvePolicies(*scope, getEnvironment))
+	return ch.getChecker().Check(&resolved, c)
+}
+
+// resolvePolicies returns a copy of the scope with policy expressions replaced by the values they evaluate to
+func resolvePolicies(scope map[string]any, getEnvironment func() *Environment) map[string]any {
+	resolved := make(map[string]any, len(scope))
+	for key, value := range scope {
+		switch typedValue := value.(type) {
+		case contract.AccessScope:
+			resolved[key] = resolvePolicies(typedValue, getEnvironment)
+		case map[string]any:
+			resolved[key] = resolvePolicies(typedValue, getEnvironment)
+		case string:
+			if strings.HasPrefix(typedValue, policyScopePrefix) {
+				resolved[key] = evaluatePolicy(typedValue[len(policyScopePrefix):], getEnvironment())
+				continue
+			}
+			resolved[key] = value
+		default:
+			resolved[key] = value
+		}
+	}
+	return resolved
+}
+
+// evaluatePolicy evaluates the policy to a scope value; policies may return a boolean or one of the scope accessibility
+// strings ('true', 'false', 'on-behalf'), anything else (including invalid policies) is considered forbidden
+func evaluatePolicy(source string, env *Environment) any {
+	expression, err := getCompiledExpression(source)
+	if nil != err {
+		log.Printf("can't compile policy '%s': %v", source, err)
+		return false
+	}
+	result, err := expression.Evaluate(env)
+	if nil != err {
+		log.Printf("can't evaluate policy '%s': %v", source, err)
+		return false
+	}
+	switch typedResult := result.(type) {
+	case bool:
+		return typedResult
+	case string:
+		for _, option := range constants.ScopeAccessibilityOptions {
+			if typedResult == string(option) {
+				return typedResult
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth/checker"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/entity"
+	"net/http/httptest"
+	"testing"
+)
+
+func createContext(method string, path string, headers map[string]string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(method, path, nil)
+	for key, value := range headers {
+		c.Request.Header.Set(key, value)
+	}
+	c.Set(constants.ApiUser, &entity.MemoryApiUser{Login: "user@domain.tld", OrganisationId: "acme"})
+	return c
+}
+
+func TestAccessScopeChecker_Check(t *testing.T) {
+	tests := []struct {
+		name  string
+		ch    AccessScopeChecker
+		scope *contract.AccessScope
+		c     *gin.Context
+		want  constants.ScopeAccessibility
+	}{
+		{
+			name:  "Nil scope",
+			ch:    AccessScopeChecker{},
+			scope: nil,
+			c:     createContext("GET", "/orders", nil),
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Scope without policies",
+			ch:    AccessScopeChecker{},
+			scope: &contract.AccessScope{"/orders": true},
+			c:     createContext("GET", "/orders", nil),
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Policy allows",
+			ch:    AccessScopeChecker{Checker: checker.PathAndMethodAccessScopeChecker{}},
+			scope: &contract.AccessScope{"patch:/orders/42": "p#header('X-Org') == user.tenant"},
+			c:     createContext("PATCH", "/orders/42", map[string]string{"X-Org": "acme"}),
+			want:  constants.ScopeAccessibilityAccessible,
+		},
+		{
+			name:  "Policy denies",
+			ch:    AccessScopeChecker{Checker: checker.PathAndMethodAccessScopeChecker{}},
+			scope: &contract.AccessScope{"patch:/orders/42": "p#header('X-Org') == user.tenant"},
+			c:     createContext("PATCH", "/orders/42", map[string]string{"X-Org": "other"}),
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Policy returns on-behalf",
+			ch:    AccessScopeChecker{},
+			scope: &contract.AccessScope{"/orders": "p#method == 'GET' ? true : 'on-behalf'"},
+			c:     createContext("POST", "/orders", nil),
+			want:  constants.ScopeAccessibilityOnBehalf,
+		},
+		{
+			name:  "Invalid policy is forbidden",
+			ch:    AccessScopeChecker{},
+			scope: &contract.AccessScope{"/orders": "p#method =="},
+			c:     createContext("GET", "/orders", nil),
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+		{
+			name:  "Policy returning unknown value is forbidden",
+			ch:    AccessScopeChecker{},
+			scope: &contract.AccessScope{"/orders": "p#method"},
+			c:     createContext("GET", "/orders", nil),
+			want:  constants.ScopeAccessibilityForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.ch.Check(tt.scope, contract.NewGinContext(tt.c)))
+		})
+	}
+}
+
+func Test_resolvePolicies(t *testing.T) {
+	env := NewEnvironment(contract.NewGinContext(createContext("GET", "/orders", nil)))
+	resolved := resolvePolicies(contract.AccessScope{
+		"plain":  "on-behalf",
+		"policy": "p#user.login == 'user@domain.tld'",
+		"nested": map[string]any{"policy": "p#method == 'POST'"},
+	}, func() *Environment { return env })
+	assert.Equal(t, map[string]any{
+		"plain":  "on-behalf",
+		"policy": true,
+		"nested": map[string]any{"policy": false},
+	}, resolved)
+}
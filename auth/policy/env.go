@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"time"
+)
+
+func getPrincipalAttributes(entity any) map[string]any {
+	attributes := map[string]any{
+		"tenant": contract.GetTenantId(entity),
+		"roles":  []any{},
+		"claims": map[string]any{},
+	}
+	if roleHolder, ok := entity.(contract.RoleHolderInterface); ok {
+		attributes["roles"] = normalize(roleHolder.GetRoles())
+	}
+	if claimsHolder, ok := entity.(contract.ClaimsHolderInterface); ok && nil != claimsHolder.GetClaims() {
+		attributes["claims"] = claimsHolder.GetClaims()
+	}
+	return attributes
+}
+
+func getClientAttributes(c contract.RequestContext) any {
+	value, ok := c.Get(constants.ApiClient)
+	if !ok {
+		return nil
+	}
+	apiClient, ok := value.(contract.ApiClientInterface)
+	if !ok {
+		return nil
+	}
+	attributes := getPrincipalAttributes(apiClient)
+	attributes["id"] = apiClient.GetClientId()
+	return attributes
+}
+
+func getUserAttributes(c contract.RequestContext) any {
+	value, ok := c.Get(constants.ApiUser)
+	if !ok {
+		return nil
+	}
+	apiUser, ok := value.(contract.ApiUserInterface)
+	if !ok {
+		return nil
+	}
+	attributes := getPrincipalAttributes(apiUser)
+	attributes["login"] = apiUser.GetLogin()
+	return attributes
+}
+
+func stringFunction(getter func(name string) string) Function {
+	return func(arguments ...any) (any, error) {
+		values, err := stringArguments(arguments, 1)
+		if nil != err {
+			return nil, err
+		}
+		return getter(values[0]), nil
+	}
+}
+
+// NewEnvironment exposes the attributes of the request and of the authenticated client/user to policy expressions
+func NewEnvironment(c contract.RequestContext) *Environment {
+	now := time.Now()
+	request := c.GetRequest()
+	env := &Environment{
+		Variables: map[string]any{
+			"route":   c.FullPath(),
+			"ip":      c.ClientIP(),
+			"now":     float64(now.Unix()),
+			"date":    now.Format("2006-01-02"),
+			"hour":    float64(now.Hour()),
+			"weekday": float64(now.Weekday()),
+			"tenant":  contract.GetString(c, constants.TenantId),
+			"client":  getClientAttributes(c),
+			"user":    getUserAttributes(c),
+		},
+		Functions: map[string]Function{
+			"header": stringFunction(func(name string) string {
+				if nil == request {
+					return ""
+				}
+				return request.Header.Get(name)
+			}),
+			"query": stringFunction(func(name string) string {
+				if nil == request || nil == request.URL {
+					return ""
+				}
+				return request.URL.Query().Get(name)
+			}),
+			"param": stringFunction(c.Param),
+			"cookie": stringFunction(func(name string) string {
+				value, _ := c.Cookie(name)
+				return value
+			}),
+		},
+	}
+	if nil != request {
+		env.Variables["method"] = request.Method
+		env.Variables["host"] = request.Host
+		if nil != request.URL {
+			env.Variables["path"] = request.URL.Path
+		}
+	}
+	return env
+}
@@ -0,0 +1,232 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Function is a function callable from policy expressions
+type Function func(arguments ...any) (any, error)
+
+// Environment holds the variables and functions available to policy expressions
+type Environment struct {
+	Variables map[string]any
+	Functions map[string]Function
+}
+
+// Expression is a compiled policy expression
+type Expression struct {
+	source string
+	root   node
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate evaluates the expression within the given environment
+func (e *Expression) Evaluate(env *Environment) (any, error) {
+	if nil == env {
+		env = &Environment{}
+	}
+	return e.root.evaluate(env)
+}
+
+// Compile parses the given source into an expression that can be evaluated repeatedly
+func Compile(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if nil != err {
+		return nil, err
+	}
+	root, err := (&parser{tokens: tokens}).parse()
+	if nil != err {
+		return nil, err
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// cachedExpression holds a compiled expression behind a sync.Once (see contract.cachedScopeRegex)
+type cachedExpression struct {
+	once       sync.Once
+	expression *Expression
+	err        error
+}
+
+// expressionCache memoizes compiled expressions, so that policies are not re-parsed on every request
+var expressionCache sync.Map // map[string]*cachedExpression
+
+func getCompiledExpression(source string) (*Expression, error) {
+	entryAny, _ := expressionCache.LoadOrStore(source, &cachedExpression{})
+	entry := entryAny.(*cachedExpression)
+	entry.once.Do(func() {
+		entry.expression, entry.err = Compile(source)
+	})
+	return entry.expression, entry.err
+}
+
+// normalize converts values coming from the environment to the types used by the expression language
+// (numbers are float64, lists are []any, objects are map[string]any)
+func normalize(value any) any {
+	switch typedValue := value.(type) {
+	case nil, bool, float64, string, []any, map[string]any:
+		return typedValue
+	case int:
+		return float64(typedValue)
+	case int64:
+		return float64(typedValue)
+	case float32:
+		return float64(typedValue)
+	case []string:
+		items := make([]any, len(typedValue))
+		for i, item := range typedValue {
+			items[i] = item
+		}
+		return items
+	case map[string]string:
+		items := make(map[string]any, len(typedValue))
+		for key, item := range typedValue {
+			items[key] = item
+		}
+		return items
+	}
+	return value
+}
+
+func isTruthy(value any) bool {
+	switch typedValue := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return typedValue
+	case float64:
+		return 0 != typedValue
+	case string:
+		return "" != typedValue
+	case []any:
+		return len(typedValue) > 0
+	case map[string]any:
+		return len(typedValue) > 0
+	}
+	return true
+}
+
+func isEqual(left any, right any) bool {
+	return reflect.DeepEqual(normalize(left), normalize(right))
+}
+
+func contains(collection any, item any) bool {
+	switch typedCollection := collection.(type) {
+	case []any:
+		for _, value := range typedCollection {
+			if isEqual(value, item) {
+				return true
+			}
+		}
+	case map[string]any:
+		if key, ok := item.(string); ok {
+			_, exists := typedCollection[key]
+			return exists
+		}
+	case string:
+		if substring, ok := item.(string); ok {
+			return strings.Contains(typedCollection, substring)
+		}
+	}
+	return false
+}
+
+func stringArguments(arguments []any, count int) ([]string, error) {
+	if len(arguments) != count {
+		return nil, fmt.Errorf("expected %d arguments, got %d", count, len(arguments))
+	}
+	values := make([]string, count)
+	for i, argument := range arguments {
+		if nil == argument {
+			continue
+		}
+		value, ok := argument.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument %d must be a string", i+1)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+var regexCache sync.Map // map[string]*regexp.Regexp
+
+func matches(value string, pattern string) (bool, error) {
+	if cached, ok := regexCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp).MatchString(value), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if nil != err {
+		return false, err
+	}
+	regexCache.Store(pattern, re)
+	return re.MatchString(value), nil
+}
+
+// builtinFunctions are available to all expressions
+var builtinFunctions = map[string]Function{
+	"startsWith": func(arguments ...any) (any, error) {
+		values, err := stringArguments(arguments, 2)
+		if nil != err {
+			return nil, err
+		}
+		return strings.HasPrefix(values[0], values[1]), nil
+	},
+	"endsWith": func(arguments ...any) (any, error) {
+		values, err := stringArguments(arguments, 2)
+		if nil != err {
+			return nil, err
+		}
+		return strings.HasSuffix(values[0], values[1]), nil
+	},
+	"contains": func(arguments ...any) (any, error) {
+		if len(arguments) != 2 {
+			return nil, fmt.Errorf("expected 2 arguments, got %d", len(arguments))
+		}
+		return contains(arguments[0], arguments[1]), nil
+	},
+	"matches": func(arguments ...any) (any, error) {
+		values, err := stringArguments(arguments, 2)
+		if nil != err {
+			return nil, err
+		}
+		return matches(values[0], values[1])
+	},
+	"lower": func(arguments ...any) (any, error) {
+		values, err := stringArguments(arguments, 1)
+		if nil != err {
+			return nil, err
+		}
+		return strings.ToLower(values[0]), nil
+	},
+	"upper": func(arguments ...any) (any, error) {
+		values, err := stringArguments(arguments, 1)
+		if nil != err {
+			return nil, err
+		}
+		return strings.ToUpper(values[0]), nil
+	},
+	"len": func(arguments ...any) (any, error) {
+		if len(arguments) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(arguments))
+		}
+		switch typedValue := arguments[0].(type) {
+		case nil:
+			return 0, nil
+		case string:
+			return len(typedValue), nil
+		case []any:
+			return len(typedValue), nil
+		case map[string]any:
+			return len(typedValue), nil
+		}
+		return nil, fmt.Errorf("argument 1 must be a string, list or object")
+	},
+}
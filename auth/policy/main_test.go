@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExpression_Evaluate(t *testing.T) {
+	env := &Environment{
+		Variables: map[string]any{
+			"method": "PATCH",
+			"hour":   10,
+			"user": map[string]any{
+				"login":  "user@domain.tld",
+				"roles":  []string{"admin", "editor"},
+				"claims": map[string]any{"org": "acme"},
+			},
+		},
+		Functions: map[string]Function{
+			"header": func(arguments ...any) (any, error) {
+				if "X-Org" == arguments[0] {
+					return "acme", nil
+				}
+				return "", nil
+			},
+		},
+	}
+	tests := []struct {
+		name   string
+		source string
+		want   any
+	}{
+		{name: "Literal", source: "true", want: true},
+		{name: "String equality", source: "method == 'PATCH'", want: true},
+		{name: "Double quoted string", source: `method != "GET"`, want: true},
+		{name: "Number comparison", source: "hour >= 9 && hour < 17", want: true},
+		{name: "Member access", source: "user.claims.org == header('X-Org')", want: true},
+		{name: "Unknown member is null", source: "user.claims.missing == null", want: true},
+		{name: "Unknown variable is null", source: "client.id == null", want: true},
+		{name: "In list", source: "'admin' in user.roles", want: true},
+		{name: "Not in list", source: "!('guest' in user.roles)", want: true},
+		{name: "In literal list", source: "method in ['GET', 'PATCH']", want: true},
+		{name: "Or", source: "method == 'GET' || method == 'PATCH'", want: true},
+		{name: "Precedence", source: "false && true || true", want: true},
+		{name: "Ternary", source: "method == 'PATCH' ? 'on-behalf' : true", want: "on-behalf"},
+		{name: "Builtin function", source: "startsWith(lower(user.login), 'user@')", want: true},
+		{name: "Regex function", source: "matches(user.login, '^[a-z]+@domain\\\\.tld$')", want: true},
+		{name: "Len", source: "len(user.roles) == 2", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := Compile(tt.source)
+			if assert.Nil(t, err) {
+				got, err := expression.Evaluate(env)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, source := range []string{"", "method ==", "(true", "'unterminated", "method # 1", "[1, 2", "true ? 1"} {
+		t.Run(source, func(t *testing.T) {
+			_, err := Compile(source)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestExpression_Evaluate_Errors(t *testing.T) {
+	for _, source := range []string{"unknown()", "'a' < 1", "lower(1)"} {
+		t.Run(source, func(t *testing.T) {
+			expression, err := Compile(source)
+			if assert.Nil(t, err) {
+				_, err = expression.Evaluate(nil)
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parser is a recursive descent parser of the policy expression language; precedence (lowest first):
+// ternary (?:), ||, &&, comparison (== != < <= > >= in), unary (!), member access/call, primary
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.position]
+	if tokenEOF != t.typ {
+		p.position++
+	}
+	return t
+}
+
+func (p *parser) isOperator(value string) bool {
+	t := p.peek()
+	return tokenOperator == t.typ && value == t.value
+}
+
+func (p *parser) expect(value string) error {
+	t := p.next()
+	if tokenOperator != t.typ || value != t.value {
+		return fmt.Errorf("expected '%s' at position %d", value, t.position)
+	}
+	return nil
+}
+
+func (p *parser) parse() (node, error) {
+	root, err := p.parseTernary()
+	if nil != err {
+		return nil, err
+	}
+	if t := p.peek(); tokenEOF != t.typ {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", t.value, t.position)
+	}
+	return root, nil
+}
+
+func (p *parser) parseTernary() (node, error) {
+	condition, err := p.parseOr()
+	if nil != err {
+		return nil, err
+	}
+	if !p.isOperator("?") {
+		return condition, nil
+	}
+	p.next()
+	then, err := p.parseTernary()
+	if nil != err {
+		return nil, err
+	}
+	if err := p.expect(":"); nil != err {
+		return nil, err
+	}
+	otherwise, err := p.parseTernary()
+	if nil != err {
+		return nil, err
+	}
+	return ternaryNode{condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if nil != err {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if nil != err {
+			return nil, err
+		}
+		left = binaryNode{operator: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if nil != err {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		p.next()
+		right, err := p.parseComparison()
+		if nil != err {
+			return nil, err
+		}
+		left = binaryNode{operator: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseUnary()
+	if nil != err {
+		return nil, err
+	}
+	t := p.peek()
+	isComparison := tokenOperator == t.typ && ("==" == t.value || "!=" == t.value || "<" == t.value || "<=" == t.value || ">" == t.value || ">=" == t.value)
+	isIn := tokenIdent == t.typ && "in" == t.value
+	if !isComparison && !isIn {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseUnary()
+	if nil != err {
+		return nil, err
+	}
+	return binaryNode{operator: t.value, left: left, right: right}, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if nil != err {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	target, err := p.parsePrimary()
+	if nil != err {
+		return nil, err
+	}
+	for p.isOperator(".") {
+		p.next()
+		t := p.next()
+		if tokenIdent != t.typ {
+			return nil, fmt.Errorf("expected attribute name at position %d", t.position)
+		}
+		target = memberNode{target: target, name: t.value}
+	}
+	return target, nil
+}
+
+func (p *parser) parseArguments() ([]node, error) {
+	var arguments []node
+	if p.isOperator(")") {
+		p.next()
+		return arguments, nil
+	}
+	for {
+		argument, err := p.parseTernary()
+		if nil != err {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+		if p.isOperator(",") {
+			p.next()
+			continue
+		}
+		return arguments, p.expect(")")
+	}
+}
+
+func (p *parser) parseList() (node, error) {
+	var items []node
+	if p.isOperator("]") {
+		p.next()
+		return listNode{items: items}, nil
+	}
+	for {
+		item, err := p.parseTernary()
+		if nil != err {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.isOperator(",") {
+			p.next()
+			continue
+		}
+		return listNode{items: items}, p.expect("]")
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.typ {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.value, 64)
+		if nil != err {
+			return nil, fmt.Errorf("invalid number '%s' at position %d", t.value, t.position)
+		}
+		return literalNode{value: value}, nil
+	case tokenString:
+		return literalNode{value: t.value}, nil
+	case tokenIdent:
+		switch t.value {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null":
+			return literalNode{value: nil}, nil
+		}
+		if p.isOperator("(") {
+			p.next()
+			arguments, err := p.parseArguments()
+			if nil != err {
+				return nil, err
+			}
+			return callNode{name: t.value, arguments: arguments}, nil
+		}
+		return identNode{name: t.value}, nil
+	case tokenOperator:
+		if "(" == t.value {
+			inner, err := p.parseTernary()
+			if nil != err {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		}
+		if "[" == t.value {
+			return p.parseList()
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected '%s' at position %d", t.value, t.position)
+}
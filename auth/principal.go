@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+)
+
+// Principal describes the authenticated request: the api client, the optional api user, the authentication method,
+// the key id, the effective scope, the checked FUP limits and the expiration of the credentials (see contract.Principal)
+type Principal = contract.Principal
+
+// GetPrincipal returns the principal of the request authenticated by the middleware (or RequireClient, RequireUser, ...) or nil if the request is not authenticated
+func GetPrincipal(c *gin.Context) *Principal {
+	return contract.GetPrincipal(c)
+}
+
+// PrincipalFromContext returns the principal stored in ctx by the net/http middleware or the gRPC interceptors (see stdhttp and grpcauth) or nil
+func PrincipalFromContext(ctx context.Context) *Principal {
+	return contract.PrincipalFromContext(ctx)
+}
@@ -14,11 +14,33 @@ import (
 	"time"
 )
 
+// bindConfigProvider binds a provider to configProvider; the provider can't be shared by several configuration instances
+// (it would use the settings of the instance initialized last), create a provider for each of them instead
+func bindConfigProvider(bound **config.Provider, configProvider *config.Provider) {
+	if nil != *bound && *bound != configProvider {
+		panic("the data provider is already used by another configuration instance, create a provider for each configuration instance")
+	}
+	*bound = configProvider
+}
+
 // GormApiClientProvider is an implementation of the ApiClientProviderInterface for GORM
 type GormApiClientProvider struct {
 	newApiClient    func() contract.ApiClientInterface
 	newApiClientKey func() contract.ApiClientKeyInterface
 	getConnection   func() *gorm.DB
+	config          *config.Provider
+}
+
+// SetConfigProvider sets the configuration instance the provider is used in (called by config.Provider.Init)
+func (p *GormApiClientProvider) SetConfigProvider(configProvider *config.Provider) {
+	bindConfigProvider(&p.config, configProvider)
+}
+
+func (p GormApiClientProvider) getConfig() (*config.Provider, *contract.AuthError) {
+	if nil == p.config {
+		return nil, contract.NewInternalError(contract.DataProviderNotBound, nil)
+	}
+	return p.config, nil
 }
 
 func (p GormApiClientProvider) ProvideByIdAndSecret(id string, secret string) (contract.ApiClientInterface, *contract.AuthError) {
@@ -75,7 +97,11 @@ func (p GormApiClientProvider) ProvideByApiKey(apiKey string) (contract.ApiClien
 	})
 	if nil != result.Error {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			if config.ProviderInstance.IsAdditionalApiKeysEnabled() {
+			configProvider, err := p.getConfig()
+			if nil != err {
+				return nil, err
+			}
+			if configProvider.IsAdditionalApiKeysEnabled() {
 				return p.provideByAdditionalKey(apiKey)
 			}
 			return nil, contract.NewAuthError(contract.ClientNotFound, nil)
@@ -85,6 +111,21 @@ func (p GormApiClientProvider) ProvideByApiKey(apiKey string) (contract.ApiClien
 	return apiClient, nil
 }
 
+func (p GormApiClientProvider) ProvideByClientId(id string) (contract.ApiClientInterface, *contract.AuthError) {
+	apiClient := p.newApiClient()
+	conn := p.getConnection()
+	result := conn.First(&apiClient, entity.GormApiClient{
+		ClientId: id,
+	})
+	if nil != result.Error {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, contract.NewAuthError(contract.ClientNotFound, nil)
+		}
+		return nil, contract.NewInternalError(contract.DatabaseError, map[string]string{"details": result.Error.Error()})
+	}
+	return apiClient, nil
+}
+
 func (p GormApiClientProvider) Save(client contract.ApiClientInterface) *contract.AuthError {
 	conn := p.getConnection()
 	result := conn.Save(client)
@@ -107,6 +148,19 @@ type GormApiUserProvider struct {
 	newApiUser      func() contract.ApiUserInterface
 	newApiUserToken func() contract.ApiUserTokenInterface
 	getConnection   func() *gorm.DB
+	config          *config.Provider
+}
+
+// SetConfigProvider sets the configuration instance the provider is used in (called by config.Provider.Init)
+func (p *GormApiUserProvider) SetConfigProvider(configProvider *config.Provider) {
+	bindConfigProvider(&p.config, configProvider)
+}
+
+func (p GormApiUserProvider) getConfig() (*config.Provider, *contract.AuthError) {
+	if nil == p.config {
+		return nil, contract.NewInternalError(contract.DataProviderNotBound, nil)
+	}
+	return p.config, nil
 }
 
 func (p GormApiUserProvider) ProvideByLoginAndPassword(login string, password string) (contract.ApiUserInterface, *contract.AuthError) {
@@ -180,7 +234,11 @@ func (p GormApiUserProvider) ProvideByConfirmationToken(token string) (contract.
 		return nil, contract.NewInternalError(contract.DatabaseError, map[string]string{"details": result.Error.Error()})
 	}
 	// check token expiration
-	expirationInterval := config.ProviderInstance.GetConfirmationTokenExpirationInterval()
+	configProvider, err := p.getConfig()
+	if nil != err {
+		return nil, err
+	}
+	expirationInterval := configProvider.GetConfirmationTokenExpirationInterval()
 	expiresAt := apiUser.GetConfirmationRequestedAt().Add(expirationInterval)
 	if expiresAt.Before(time.Now()) {
 		return nil, contract.NewAuthError(contract.ConfirmationTokenExpired, map[string]time.Time{"expiredAt": expiresAt})
@@ -201,7 +259,11 @@ func (p GormApiUserProvider) ProvideByResetToken(token string) (contract.ApiUser
 		return nil, contract.NewInternalError(contract.DatabaseError, map[string]string{"details": result.Error.Error()})
 	}
 	// check token expiration
-	expirationInterval := config.ProviderInstance.GetConfirmationTokenExpirationInterval()
+	configProvider, err := p.getConfig()
+	if nil != err {
+		return nil, err
+	}
+	expirationInterval := configProvider.GetConfirmationTokenExpirationInterval()
 	expiresAt := apiUser.GetResetRequestedAt().Add(expirationInterval)
 	if expiresAt.Before(time.Now()) {
 		return nil, contract.NewAuthError(contract.ResetTokenExpired, map[string]time.Time{"expiredAt": expiresAt})
@@ -222,6 +284,10 @@ func (p GormApiUserProvider) ProvideNew(login string, encryptedPassword string)
 
 func (p GormApiUserProvider) InvalidateTokens(user contract.ApiUserInterface) *contract.AuthError {
 	slog.Debug("invalidating tokens for user", slog.String("user", user.GetLogin()))
+	configProvider, authErr := p.getConfig()
+	if nil != authErr {
+		return authErr
+	}
 	conn := p.getConnection()
 	id, err := uuid.Parse(user.GetID())
 	if nil != err {
@@ -240,8 +306,8 @@ func (p GormApiUserProvider) InvalidateTokens(user contract.ApiUserInterface) *c
 	for index, token := range tokens {
 		tokens[index].SetExpirationDate(time.Now())
 		slog.Debug("invalidating token", slog.String("token", token.Token))
-		if config.ProviderInstance.IsCacheEnabled() {
-			cacheErr := config.ProviderInstance.GetCacheDriver().InvalidateToken(token.Token)
+		if configProvider.IsCacheEnabled() {
+			cacheErr := configProvider.GetCacheDriver().InvalidateToken(token.Token)
 			if nil != cacheErr {
 				slog.Error("can't invalidate token in cache", slog.String("token", token.Token), slog.String("user", user.GetLogin()), slog.String("error", cacheErr.Err.Error()))
 			}
@@ -272,3 +338,68 @@ func NewGormApiUserProvider(newApiUser func() contract.ApiUserInterface, newApiU
 		getConnection:   getConnection,
 	}
 }
+
+// GormApiRoleProvider is an implementation of the ApiRoleProviderInterface for GORM
+type GormApiRoleProvider struct {
+	newApiRole    func() contract.ApiRoleInterface
+	getConnection func() *gorm.DB
+}
+
+func (p GormApiRoleProvider) ProvideByNames(names []string) ([]contract.ApiRoleInterface, *contract.AuthError) {
+	if 0 == len(names) {
+		return nil, nil
+	}
+	conn := p.getConnection()
+	// keep the order in which the roles are assigned (it is significant when merging scopes)
+	var roles []contract.ApiRoleInterface
+	for _, name := range names {
+		apiRole := p.newApiRole()
+		result := conn.First(&apiRole, "name = ?", name)
+		if nil != result.Error {
+			// unknown roles are skipped
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, contract.NewInternalError(contract.DatabaseError, map[string]string{"details": result.Error.Error()})
+		}
+		roles = append(roles, apiRole)
+	}
+	return roles, nil
+}
+
+func NewGormApiRoleProvider(newApiRole func() contract.ApiRoleInterface, getConnection func() *gorm.DB) *GormApiRoleProvider {
+	return &GormApiRoleProvider{
+		newApiRole:    newApiRole,
+		getConnection: getConnection,
+	}
+}
+
+// GormApiOrganisationProvider is an implementation of the ApiOrganisationProviderInterface for GORM
+type GormApiOrganisationProvider struct {
+	newApiOrganisation func() contract.ApiOrganisationInterface
+	getConnection      func() *gorm.DB
+}
+
+func (p GormApiOrganisationProvider) ProvideById(id string) (contract.ApiOrganisationInterface, *contract.AuthError) {
+	organisationId, err := uuid.Parse(id)
+	if nil != err {
+		return nil, contract.NewAuthError(contract.OrganisationNotFound, nil)
+	}
+	apiOrganisation := p.newApiOrganisation()
+	conn := p.getConnection()
+	result := conn.First(&apiOrganisation, organisationId)
+	if nil != result.Error {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, contract.NewAuthError(contract.OrganisationNotFound, nil)
+		}
+		return nil, contract.NewInternalError(contract.DatabaseError, map[string]string{"details": result.Error.Error()})
+	}
+	return apiOrganisation, nil
+}
+
+func NewGormApiOrganisationProvider(newApiOrganisation func() contract.ApiOrganisationInterface, getConnection func() *gorm.DB) *GormApiOrganisationProvider {
+	return &GormApiOrganisationProvider{
+		newApiOrganisation: newApiOrganisation,
+		getConnection:      getConnection,
+	}
+}
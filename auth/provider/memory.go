@@ -38,6 +38,16 @@ func (p MemoryApiClientProvider) ProvideByApiKey(apiKey string) (contract.ApiCli
 	return nil, contract.NewAuthError(contract.ClientNotFound, nil)
 }
 
+func (p MemoryApiClientProvider) ProvideByClientId(id string) (contract.ApiClientInterface, *contract.AuthError) {
+	for i := range p.memory {
+		if p.memory[i].Id == id {
+			return &p.memory[i], nil
+		}
+	}
+
+	return nil, contract.NewAuthError(contract.ClientNotFound, nil)
+}
+
 func (p MemoryApiClientProvider) Save(client contract.ApiClientInterface) *contract.AuthError {
 	// no-op (saved in memory)
 	return nil
@@ -130,3 +140,48 @@ func NewMemoryApiUserProvider(memory []entity.MemoryApiUser) *MemoryApiUserProvi
 		memory: memory,
 	}
 }
+
+// MemoryApiRoleProvider is the simplest implementation of the ApiRoleProviderInterface
+type MemoryApiRoleProvider struct {
+	memory []entity.MemoryApiRole
+}
+
+func (p MemoryApiRoleProvider) ProvideByNames(names []string) ([]contract.ApiRoleInterface, *contract.AuthError) {
+	var roles []contract.ApiRoleInterface
+	for _, name := range names {
+		for i := range p.memory {
+			if p.memory[i].Name == name {
+				roles = append(roles, &p.memory[i])
+				break
+			}
+		}
+	}
+	return roles, nil
+}
+
+func NewMemoryApiRoleProvider(memory []entity.MemoryApiRole) *MemoryApiRoleProvider {
+	return &MemoryApiRoleProvider{
+		memory: memory,
+	}
+}
+
+// MemoryApiOrganisationProvider is the simplest implementation of the ApiOrganisationProviderInterface
+type MemoryApiOrganisationProvider struct {
+	memory []entity.MemoryApiOrganisation
+}
+
+func (p MemoryApiOrganisationProvider) ProvideById(id string) (contract.ApiOrganisationInterface, *contract.AuthError) {
+	for i := range p.memory {
+		if p.memory[i].Id == id {
+			return &p.memory[i], nil
+		}
+	}
+
+	return nil, contract.NewAuthError(contract.OrganisationNotFound, nil)
+}
+
+func NewMemoryApiOrganisationProvider(memory []entity.MemoryApiOrganisation) *MemoryApiOrganisationProvider {
+	return &MemoryApiOrganisationProvider{
+		memory: memory,
+	}
+}
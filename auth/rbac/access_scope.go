@@ -0,0 +1,43 @@
+package rbac
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/checker"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"log"
+)
+
+// AccessScopeChecker wraps an AccessScopeCheckerInterface implementation and extends the checked scope
+// with the scopes of the roles assigned to the entity stored in the context under Subject
+type AccessScopeChecker struct {
+	Checker contract.AccessScopeCheckerInterface
+	Subject string
+}
+
+func (ch AccessScopeChecker) getChecker() contract.AccessScopeCheckerInterface {
+	if nil == ch.Checker {
+		return checker.PathAccessScopeChecker{}
+	}
+	return ch.Checker
+}
+
+func (ch AccessScopeChecker) getSubject() string {
+	if "" == ch.Subject {
+		return constants.ApiClient
+	}
+	return ch.Subject
+}
+
+func (ch AccessScopeChecker) Check(scope *contract.AccessScope, c contract.RequestContext) constants.ScopeAccessibility {
+	roles := getRoles(c, ch.getSubject())
+	if 0 == len(roles) {
+		return ch.getChecker().Check(scope, c)
+	}
+	roleScopes, err := ResolveRoleScopes(config.GetProvider(c), roles)
+	if nil != err {
+		log.Printf("can't resolve role scopes: %v", err)
+		return ch.getChecker().Check(scope, c)
+	}
+	return ch.getChecker().Check(contract.MergeAccessScopes(scope, roleScopes.AccessScope), c)
+}
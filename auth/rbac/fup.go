@@ -0,0 +1,39 @@
+package rbac
+
+import (
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"log"
+)
+
+// FUPChecker wraps a FUPCheckerInterface implementation and extends the checked scope
+// with the FUP scopes of the roles assigned to the entity stored in the context under Subject
+type FUPChecker struct {
+	Checker contract.FUPCheckerInterface
+	Subject string
+}
+
+func (ch FUPChecker) getSubject() string {
+	if "" == ch.Subject {
+		return constants.ApiClient
+	}
+	return ch.Subject
+}
+
+func (ch FUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	if nil == ch.Checker {
+		// no limitations by default
+		return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityUnlimited}
+	}
+	roles := getRoles(c, ch.getSubject())
+	if 0 == len(roles) {
+		return ch.Checker.Check(scope, c, key)
+	}
+	roleScopes, err := ResolveRoleScopes(config.GetProvider(c), roles)
+	if nil != err {
+		log.Printf("can't resolve role scopes: %v", err)
+		return ch.Checker.Check(scope, c, key)
+	}
+	return ch.Checker.Check(contract.MergeFUPScopes(scope, roleScopes.FUPScope), c, key)
+}
@@ -0,0 +1,86 @@
+package rbac
+
+import (
+	"encoding/json"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"log"
+	"sort"
+)
+
+func getCacheKey(roles []string) string {
+	names := make([]string, len(roles))
+	copy(names, roles)
+	sort.Strings(names)
+	// names are encoded as a JSON array so that a name containing a separator can't collide with another set of names
+	key, err := json.Marshal(names)
+	if nil != err {
+		log.Printf("can't encode role names: %v", err)
+	}
+	return string(key)
+}
+
+// getRoleCacheDriver returns the cache driver if the cache is enabled and the driver can cache role scopes (or nil)
+func getRoleCacheDriver(configProvider *config.Provider) contract.RoleCacheDriverInterface {
+	if !configProvider.IsCacheEnabled() {
+		return nil
+	}
+	roleCacheDriver, ok := configProvider.GetCacheDriver().(contract.RoleCacheDriverInterface)
+	if !ok {
+		return nil
+	}
+	return roleCacheDriver
+}
+
+// ResolveRoleScopes loads the given roles from the role provider of configProvider and merges their scopes;
+// the result is cached (if the cache driver implements RoleCacheDriverInterface) under the sorted list of role names
+func ResolveRoleScopes(configProvider *config.Provider, roles []string) (*contract.RoleScopes, *contract.AuthError) {
+	if 0 == len(roles) || !configProvider.IsRolesEnabled() {
+		return &contract.RoleScopes{}, nil
+	}
+	key := getCacheKey(roles)
+	roleCacheDriver := getRoleCacheDriver(configProvider)
+	if nil != roleCacheDriver {
+		cached, err := roleCacheDriver.GetRoleScopes(key)
+		if nil != err {
+			return nil, err
+		}
+		if nil != cached {
+			return cached, nil
+		}
+	}
+	apiRoles, err := configProvider.GetRoleProvider().ProvideByNames(roles)
+	if nil != err {
+		return nil, err
+	}
+	accessScopes := make([]*contract.AccessScope, 0, len(apiRoles))
+	fupScopes := make([]*contract.FUPScope, 0, len(apiRoles))
+	for _, apiRole := range apiRoles {
+		accessScopes = append(accessScopes, apiRole.GetAccessScope())
+		fupScopes = append(fupScopes, apiRole.GetFUPScope())
+	}
+	scopes := &contract.RoleScopes{
+		AccessScope: contract.MergeAccessScopes(nil, accessScopes...),
+		FUPScope:    contract.MergeFUPScopes(nil, fupScopes...),
+	}
+	if nil != roleCacheDriver {
+		err = roleCacheDriver.SetRoleScopes(key, scopes)
+		if nil != err {
+			return nil, err
+		}
+	}
+	return scopes, nil
+}
+
+// getRoles returns the roles of the entity (api client or api user) stored in the context under subject
+func getRoles(c contract.RequestContext, subject string) []string {
+	value, ok := contract.GetValue(c, subject)
+	if !ok {
+		return nil
+	}
+	holder, ok := value.(contract.RoleHolderInterface)
+	if !ok {
+		return nil
+	}
+	return holder.GetRoles()
+}
@@ -0,0 +1,192 @@
+package rbac
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth/cache"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/entity"
+	"github.com/wernerdweight/api-auth-go/v2/auth/provider"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingRoleProvider counts the lookups to verify the role scopes are cached
+type countingRoleProvider struct {
+	provider contract.ApiRoleProviderInterface
+	calls    int
+}
+
+func (p *countingRoleProvider) ProvideByNames(names []string) ([]contract.ApiRoleInterface, *contract.AuthError) {
+	p.calls++
+	return p.provider.ProvideByNames(names)
+}
+
+// recordingFUPChecker records the scope it was called with
+type recordingFUPChecker struct {
+	scope *contract.FUPScope
+}
+
+func (ch *recordingFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	ch.scope = scope
+	return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityAccessible}
+}
+
+// plainCacheDriver exposes the required cache driver methods only (it doesn't implement RoleCacheDriverInterface)
+type plainCacheDriver struct {
+	contract.CacheDriverInterface
+}
+
+func newRoleProvider() *countingRoleProvider {
+	return &countingRoleProvider{
+		provider: provider.NewMemoryApiRoleProvider([]entity.MemoryApiRole{
+			{
+				Name:        "reader",
+				AccessScope: &contract.AccessScope{"/orders": true},
+				FUPScope:    &contract.FUPScope{"orders": map[string]any{"daily": 10}},
+			},
+			{
+				Name:        "writer",
+				AccessScope: &contract.AccessScope{"/invoices": true, "/orders": false},
+				FUPScope:    &contract.FUPScope{"orders": map[string]any{"daily": 100}},
+			},
+			{Name: "a,b"},
+			{Name: "a"},
+			{Name: "b"},
+		}),
+	}
+}
+
+func newConfigProvider(roleProvider contract.ApiRoleProviderInterface, cacheDriver contract.CacheDriverInterface) *config.Provider {
+	cfg := contract.Config{
+		Roles: &contract.RolesConfig{Provider: roleProvider},
+	}
+	if nil != cacheDriver {
+		cfg.Cache = &contract.CacheConfig{Driver: cacheDriver}
+	}
+	return config.NewProvider(cfg)
+}
+
+func newContext(configProvider *config.Provider, path string, roles ...string) contract.RequestContext {
+	c := contract.NewHttpContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	configProvider.BindTo(c)
+	c.Set(constants.ApiClient, &entity.MemoryApiClient{Id: "client", Roles: roles})
+	return c
+}
+
+func TestResolveRoleScopes(t *testing.T) {
+	roleProvider := newRoleProvider()
+	scopes, err := ResolveRoleScopes(newConfigProvider(roleProvider, nil), []string{"reader", "writer", "unknown"})
+	assert.Nil(t, err)
+	// the most permissive access and the highest limit win among roles
+	assert.Equal(t, &contract.AccessScope{"/orders": true, "/invoices": true}, scopes.AccessScope)
+	assert.Equal(t, &contract.FUPScope{"orders": map[string]any{"daily": 100}}, scopes.FUPScope)
+
+	// no roles means no lookup
+	scopes, err = ResolveRoleScopes(newConfigProvider(roleProvider, nil), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, &contract.RoleScopes{}, scopes)
+	assert.Equal(t, 1, roleProvider.calls)
+
+	// roles are not resolved if no role provider is configured
+	scopes, err = ResolveRoleScopes(config.NewProvider(contract.Config{}), []string{"reader"})
+	assert.Nil(t, err)
+	assert.Equal(t, &contract.RoleScopes{}, scopes)
+}
+
+func TestResolveRoleScopes_Cache(t *testing.T) {
+	roleProvider := newRoleProvider()
+	cacheDriver := cache.NewMemoryCacheDriver()
+	_ = cacheDriver.Init("", time.Hour)
+	configProvider := newConfigProvider(roleProvider, cacheDriver)
+
+	first, err := ResolveRoleScopes(configProvider, []string{"reader", "writer"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, roleProvider.calls)
+
+	// the same set of roles (in any order) is served from the cache
+	second, err := ResolveRoleScopes(configProvider, []string{"writer", "reader"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, roleProvider.calls)
+	assert.Equal(t, first, second)
+
+	// a different set of roles is looked up
+	_, err = ResolveRoleScopes(configProvider, []string{"reader"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, roleProvider.calls)
+
+	// a role name containing a separator doesn't collide with a different set of roles
+	_, err = ResolveRoleScopes(configProvider, []string{"a,b"})
+	assert.Nil(t, err)
+	_, err = ResolveRoleScopes(configProvider, []string{"a", "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, roleProvider.calls)
+	assert.NotEqual(t, getCacheKey([]string{"a,b"}), getCacheKey([]string{"a", "b"}))
+}
+
+func TestResolveRoleScopes_CacheNotSupported(t *testing.T) {
+	roleProvider := newRoleProvider()
+	cacheDriver := cache.NewMemoryCacheDriver()
+	_ = cacheDriver.Init("", time.Hour)
+	configProvider := newConfigProvider(roleProvider, plainCacheDriver{cacheDriver})
+
+	// the roles are looked up on every request
+	for i := 1; i <= 2; i++ {
+		scopes, err := ResolveRoleScopes(configProvider, []string{"reader", "writer"})
+		assert.Nil(t, err)
+		assert.Equal(t, &contract.AccessScope{"/orders": true, "/invoices": true}, scopes.AccessScope)
+		assert.Equal(t, i, roleProvider.calls)
+	}
+}
+
+func TestAccessScopeChecker_Check(t *testing.T) {
+	configProvider := newConfigProvider(newRoleProvider(), nil)
+	ch := AccessScopeChecker{}
+
+	// the scope granted by a role is added to the own scope
+	c := newContext(configProvider, "/invoices", "writer")
+	assert.Equal(t, constants.ScopeAccessibilityAccessible, ch.Check(&contract.AccessScope{"/orders": true}, c))
+
+	// the own scope takes precedence over roles
+	c = newContext(configProvider, "/orders", "reader")
+	assert.Equal(t, constants.ScopeAccessibilityForbidden, ch.Check(&contract.AccessScope{"/orders": false}, c))
+
+	// without roles only the own scope is checked
+	c = newContext(configProvider, "/invoices")
+	assert.Equal(t, constants.ScopeAccessibilityForbidden, ch.Check(&contract.AccessScope{"/orders": true}, c))
+
+	// roles of the subject configured on the checker are used
+	c = newContext(configProvider, "/invoices", "writer")
+	ch = AccessScopeChecker{Subject: constants.ApiUser}
+	assert.Equal(t, constants.ScopeAccessibilityForbidden, ch.Check(&contract.AccessScope{"/orders": true}, c))
+}
+
+func TestFUPChecker_Check(t *testing.T) {
+	configProvider := newConfigProvider(newRoleProvider(), nil)
+	recorder := &recordingFUPChecker{}
+	ch := FUPChecker{Checker: recorder}
+
+	// the limits of roles are merged into the own scope (the own scope takes precedence)
+	c := newContext(configProvider, "/orders", "reader")
+	ch.Check(&contract.FUPScope{"invoices": map[string]any{"daily": 1}}, c, "client")
+	assert.Equal(t, &contract.FUPScope{
+		"orders":   map[string]any{"daily": 10},
+		"invoices": map[string]any{"daily": 1},
+	}, recorder.scope)
+
+	c = newContext(configProvider, "/orders", "reader", "writer")
+	ch.Check(&contract.FUPScope{"orders": map[string]any{"daily": 5}}, c, "client")
+	assert.Equal(t, &contract.FUPScope{"orders": map[string]any{"daily": 5}}, recorder.scope)
+
+	// without roles the own scope is passed as is
+	scope := &contract.FUPScope{"orders": map[string]any{"daily": 5}}
+	c = newContext(configProvider, "/orders")
+	ch.Check(scope, c, "client")
+	assert.Same(t, scope, recorder.scope)
+
+	// without a wrapped checker there are no limitations
+	assert.Equal(t, constants.ScopeAccessibilityUnlimited, FUPChecker{}.Check(scope, c, "client").Accessible)
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/fup"
+	"github.com/wernerdweight/api-auth-go/v2/auth/security"
+	"net/http"
+)
+
+func require(requirement constants.UserRequirement) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.GetProvider(c).ShouldExcludeOptionsRequests() && http.MethodOptions == c.Request.Method {
+			c.Next()
+			return
+		}
+
+		requestContext := contract.NewGinContext(c)
+		err := security.AuthenticateWith(requestContext, requirement)
+		if nil != err {
+			abortWithError(c, err)
+			fup.Settle(requestContext)
+			return
+		}
+
+		c.Next()
+		fup.Settle(requestContext)
+	}
+}
+
+// RequireClient returns a gin.HandlerFunc that requires an authenticated api client (including client scope and FUP checks).
+// The api user is authenticated if user credentials are provided or if the client scope requires it (on-behalf access).
+// Unlike Middleware, it ignores TargetHandlers and ExcludeHandlers (attach it to the route groups/routes you want to protect).
+// The configuration instance bound to the request is used (see config.Provider.Bind), the default one otherwise.
+func RequireClient() gin.HandlerFunc {
+	return require(constants.UserRequirementScope)
+}
+
+// RequireUser returns a gin.HandlerFunc that requires an authenticated api client and an authenticated api user.
+func RequireUser() gin.HandlerFunc {
+	return require(constants.UserRequirementRequired)
+}
+
+// OptionalUser returns a gin.HandlerFunc that requires an authenticated api client and authenticates the api user if possible.
+// Missing or invalid user credentials are ignored, unless the client scope requires the user (on-behalf access).
+func OptionalUser() gin.HandlerFunc {
+	return require(constants.UserRequirementOptional)
+}
+
+// RequireScope returns a gin.HandlerFunc that requires an authenticated api client whose (effective) access scope grants access to key.
+// The key uses `|` as a hierarchy separator (e.g. `orders|write`); if the scope grants on-behalf access only, an authenticated api user is required.
+// The scopes are checked against key instead of the request path, so the scope doesn't have to grant access to the path as well
+// (unless the client has been authenticated by Middleware already, which checks the path).
+func RequireScope(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.GetProvider(c).ShouldExcludeOptionsRequests() && http.MethodOptions == c.Request.Method {
+			c.Next()
+			return
+		}
+
+		requestContext := contract.NewGinContext(c)
+		err := security.AuthenticateForScope(requestContext, key)
+		if nil != err {
+			abortWithError(c, err)
+			fup.Settle(requestContext)
+			return
+		}
+
+		c.Next()
+		fup.Settle(requestContext)
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/entity"
+	"github.com/wernerdweight/api-auth-go/v2/auth/provider"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newScopeConfig() contract.Config {
+	useScopeAccessModel := true
+	return contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{
+				{
+					Id:     "client",
+					Secret: "secret",
+					AccessScope: &contract.AccessScope{
+						"/orders": true,
+						"orders":  map[string]any{"read": true, "write": "on-behalf"},
+						"admin":   false,
+					},
+				},
+			}),
+			UseScopeAccessModel: &useScopeAccessModel,
+		},
+		User: &contract.UserConfig{
+			Provider: provider.NewMemoryApiUserProvider([]entity.MemoryApiUser{
+				{
+					Id:           "user",
+					Login:        "user",
+					CurrentToken: &entity.MemoryApiUserToken{Token: "token", ExpirationDate: time.Now().Add(time.Hour)},
+				},
+			}),
+			TokenFactory: func() contract.ApiUserTokenInterface { return &entity.MemoryApiUserToken{} },
+		},
+	}
+}
+
+func newScopeRequest(path string, withUser bool) *http.Request {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.Header.Set(constants.ClientIdHeader, "client")
+	request.Header.Set(constants.ClientSecretHeader, "secret")
+	if withUser {
+		request.Header.Set(constants.ApiUserTokenHeader, "token")
+	}
+	return request
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configProvider := New(newScopeConfig())
+	r := gin.New()
+	r.Use(configProvider.Bind)
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/reports", RequireScope("orders|read"), handler)
+	r.GET("/orders", RequireScope("admin"), handler)
+	r.POST("/reports", RequireScope("orders|write"), handler)
+
+	// the scope doesn't have to grant access to the path, only to the key
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, newScopeRequest("/reports", false))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// access to the path doesn't grant access to the key
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, newScopeRequest("/orders", false))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), contract.AuthErrorCodes[contract.ClientForbidden])
+
+	// on-behalf access requires the user
+	request := newScopeRequest("/reports", false)
+	request.Method = http.MethodPost
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), contract.AuthErrorCodes[contract.UserTokenRequired])
+
+	request = newScopeRequest("/reports", true)
+	request.Method = http.MethodPost
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestRequireScope_AfterMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	configProvider := New(newScopeConfig())
+	r := gin.New()
+	r.Use(MiddlewareFor(configProvider))
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/orders", RequireScope("orders|read"), handler)
+	r.GET("/orders/admin", RequireScope("admin"), handler)
+	r.GET("/reports", RequireScope("orders|read"), handler)
+
+	// the middleware checks the path, RequireScope checks the key
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, newScopeRequest("/orders", false))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, newScopeRequest("/orders/admin", false))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), contract.AuthErrorCodes[contract.ClientForbidden])
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, newScopeRequest("/reports", false))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
@@ -27,19 +27,19 @@ func extractCredentials(header string) (string, string, *contract.AuthError) {
 	return credentials[0], credentials[1], nil
 }
 
-func createToken() contract.ApiUserTokenInterface {
+func createToken(configProvider *config.Provider) contract.ApiUserTokenInterface {
 	tokenGenerator := generator.NewTokenGenerator("")
 	token := tokenGenerator.Generate(constants.DefaultTokenLength)
-	tokenClass := config.ProviderInstance.GetTokenFactory()()
+	tokenClass := configProvider.GetTokenFactory()()
 	tokenClass.SetToken(token)
-	tokenClass.SetExpirationDate(time.Now().Add(config.ProviderInstance.GetApiTokenExpirationInterval()))
+	tokenClass.SetExpirationDate(time.Now().Add(configProvider.GetApiTokenExpirationInterval()))
 	return tokenClass
 }
 
-func authenticateHandler(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
+func authenticateHandler(c contract.RequestContext) {
+	authHeader := c.GetRequest().Header.Get("Authorization")
 	if authHeader == "" {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    contract.Unauthorized,
 			"message": contract.AuthErrorCodes[contract.Unauthorized],
 			"payload": nil,
@@ -47,25 +47,23 @@ func authenticateHandler(c *gin.Context) {
 		return
 	}
 
-	apiClient, _ := c.Get(constants.ApiClient)
-	var typedApiClient contract.ApiClientInterface
-	if nil != apiClient {
-		typedApiClient = apiClient.(contract.ApiClientInterface)
-	}
+	typedApiClient := contract.GetPrincipal(c).GetApiClient()
 
 	login, password, err := extractCredentials(authHeader)
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    err.Code,
 			"message": err.Err.Error(),
 			"payload": err.Payload,
 		})
+		return
 	}
 
-	apiUserProvider := config.ProviderInstance.GetUserProvider()
+	configProvider := config.GetProvider(c)
+	apiUserProvider := configProvider.GetUserProvider()
 	apiUser, err := apiUserProvider.ProvideByLoginAndPassword(login, password)
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    err.Code,
 			"message": err.Err.Error(),
 			"payload": err.Payload,
@@ -73,8 +71,19 @@ func authenticateHandler(c *gin.Context) {
 		return
 	}
 
+	// users can only log in through clients of their own organisation
+	clientTenantId := contract.GetTenantId(typedApiClient)
+	if "" != clientTenantId && clientTenantId != contract.GetTenantId(apiUser) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    contract.TenantMismatch,
+			"message": contract.AuthErrorCodes[contract.TenantMismatch],
+			"payload": nil,
+		})
+		return
+	}
+
 	previousLoginAt := apiUser.GetLastLoginAt()
-	token := createToken()
+	token := createToken(configProvider)
 	now := time.Now()
 	apiUser.AddApiToken(token)
 	apiUser.SetLastLoginAt(&now)
@@ -85,7 +94,7 @@ func authenticateHandler(c *gin.Context) {
 		ApiClient: typedApiClient,
 	})
 	if nil != loginErr {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    contract.Unauthorized,
 			"message": contract.AuthErrorCodes[contract.Unauthorized],
 			"payload": map[string]string{"details": loginErr.Error()},
@@ -95,7 +104,7 @@ func authenticateHandler(c *gin.Context) {
 
 	err = apiUserProvider.Save(apiUser)
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    err.Code,
 			"message": err.Err.Error(),
 			"payload": err.Payload,
@@ -107,7 +116,7 @@ func authenticateHandler(c *gin.Context) {
 	apiUser.SetLastLoginAt(previousLoginAt)
 	output, err := marshaller.MarshalPublic(apiUser)
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    err.Code,
 			"message": err.Err.Error(),
 			"payload": err.Payload,
@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/fup"
+	"net/http"
+	"time"
+)
+
+type fupLimitUsage struct {
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+type fupUsage struct {
+	Client       map[string]map[constants.Period]fupLimitUsage `json:"client,omitempty"`
+	User         map[string]map[constants.Period]fupLimitUsage `json:"user,omitempty"`
+	Organisation map[string]map[constants.Period]fupLimitUsage `json:"organisation,omitempty"`
+}
+
+func toFUPUsage(usage map[string]map[constants.Period]contract.FUPLimits) map[string]map[constants.Period]fupLimitUsage {
+	result := make(map[string]map[constants.Period]fupLimitUsage, len(usage))
+	for key, limits := range usage {
+		result[key] = make(map[constants.Period]fupLimitUsage, len(limits))
+		for period, limit := range limits {
+			result[key][period] = fupLimitUsage{
+				Limit:     limit.Limit,
+				Used:      limit.Used,
+				Remaining: limit.GetRemaining(),
+				ResetAt:   limit.ResetAt,
+			}
+		}
+	}
+	return result
+}
+
+func abortWithFUPError(c contract.RequestContext, err *contract.AuthError) {
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"code":    err.Code,
+		"message": err.Err.Error(),
+		"payload": err.Payload,
+	})
+}
+
+func fupUsageHandler(c contract.RequestContext) {
+	configProvider := config.GetProvider(c)
+	principal := contract.GetPrincipal(c)
+	apiClient := principal.GetApiClient()
+	if nil == apiClient {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    contract.Unauthorized,
+			"message": contract.AuthErrorCodes[contract.Unauthorized],
+			"payload": nil,
+		})
+		return
+	}
+
+	usage := fupUsage{}
+	// the limits are checked with the scope access model only (see security.authenticate)
+	if configProvider.IsClientScopeAccessModelEnabled() && configProvider.IsClientFUPEnabled() {
+		clientUsage, err := fup.Query(c, configProvider.GetClientFUPChecker(), apiClient.GetFUPScope(), fup.GetClientKey(apiClient))
+		if nil != err {
+			abortWithFUPError(c, err)
+			return
+		}
+		usage.Client = toFUPUsage(clientUsage)
+	}
+	if apiUser := principal.GetApiUser(); nil != apiUser && configProvider.IsUserScopeAccessModelEnabled() && configProvider.IsUserFUPEnabled() {
+		userUsage, err := fup.Query(c, configProvider.GetUserFUPChecker(), apiUser.GetFUPScope(), apiUser.GetLogin())
+		if nil != err {
+			abortWithFUPError(c, err)
+			return
+		}
+		usage.User = toFUPUsage(userUsage)
+	}
+	// the organisation of the client (or of the user if the client has none, see security.checkTenant)
+	if tenantId := contract.GetString(c, constants.TenantId); "" != tenantId && configProvider.IsClientScopeAccessModelEnabled() && configProvider.IsOrganisationFUPEnabled() {
+		apiOrganisation, err := configProvider.GetOrganisationProvider().ProvideById(tenantId)
+		if nil != err {
+			abortWithFUPError(c, err)
+			return
+		}
+		organisationUsage, err := fup.Query(c, configProvider.GetOrganisationFUPChecker(), apiOrganisation.GetFUPScope(), fup.GetOrganisationKey(tenantId))
+		if nil != err {
+			abortWithFUPError(c, err)
+			return
+		}
+		usage.Organisation = toFUPUsage(organisationUsage)
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
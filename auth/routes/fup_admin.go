@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/fup"
+	"net/http"
+	"strings"
+)
+
+type FUPResetRequest struct {
+	// Entry is the FUP entry id (see fup.Entry); all the entries of the subject are reset if empty
+	Entry  string           `json:"entry"`
+	Period constants.Period `json:"period"`
+}
+
+type FUPAdjustRequest struct {
+	Entry  string           `json:"entry" binding:"required"`
+	Period constants.Period `json:"period" binding:"required"`
+	Delta  int              `json:"delta" binding:"required"`
+}
+
+// RegisterFUPAdmin adds the FUP administration routes (/fup/admin/:subject/:id, /fup/admin/:subject/:id/reset, /fup/admin/:subject/:id/adjust)
+// to the engine or route group; the subject is `client` (the id is the client id or `<client id>:<api key>`), `user` (the id is the login)
+// or `organisation` (the id is the tenant id).
+// The routes are not added by Register, mount them behind the authorization of your administrators
+// (e.g. a route group with auth.RequireScope("fup-admin")).
+func RegisterFUPAdmin(r gin.IRoutes, configProvider *config.Provider) {
+	registerRoutes(r, configProvider, GetFUPAdminRoutes())
+}
+
+// GetFUPAdminRoutes returns the FUP administration routes (see RegisterFUPAdmin)
+func GetFUPAdminRoutes() []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/fup/admin/:subject/:id", Handler: fupAdminListHandler},
+		{Method: http.MethodPost, Path: "/fup/admin/:subject/:id/reset", Handler: fupAdminResetHandler},
+		{Method: http.MethodPost, Path: "/fup/admin/:subject/:id/adjust", Handler: fupAdminAdjustHandler},
+	}
+}
+
+func abortWithFUPAdminError(c contract.RequestContext, status int, err *contract.AuthError) {
+	c.JSON(status, gin.H{
+		"code":    err.Code,
+		"message": err.Err.Error(),
+		"payload": err.Payload,
+	})
+}
+
+// resolveFUPSubject returns the FUP key and the FUP scope of the subject (the scope is needed for the time zone of the calendar periods)
+func resolveFUPSubject(c contract.RequestContext) (string, *contract.FUPScope, bool) {
+	configProvider := config.GetProvider(c)
+	id := c.Param("id")
+	var scope *contract.FUPScope
+	var err *contract.AuthError
+	notFound := contract.Unknown
+	switch c.Param("subject") {
+	case "client":
+		var apiClient contract.ApiClientInterface
+		byIdProvider, ok := configProvider.GetClientProvider().(contract.ApiClientByIdProviderInterface)
+		if !ok {
+			abortWithFUPAdminError(c, http.StatusInternalServerError, contract.NewInternalError(contract.ClientByIdNotSupported, nil))
+			return "", nil, false
+		}
+		// the key of a client with an additional API key is `<client id>:<api key>`
+		apiClient, err = byIdProvider.ProvideByClientId(strings.SplitN(id, ":", 2)[0])
+		if nil == err && nil != apiClient {
+			scope = apiClient.GetFUPScope()
+		}
+		notFound = contract.ClientNotFound
+	case "user":
+		var apiUser contract.ApiUserInterface
+		if nil == configProvider.GetUserProvider() {
+			abortWithFUPAdminError(c, http.StatusInternalServerError, contract.NewInternalError(contract.UserProviderNotConfigured, nil))
+			return "", nil, false
+		}
+		apiUser, err = configProvider.GetUserProvider().ProvideByLogin(id)
+		if nil == err && nil != apiUser {
+			scope = apiUser.GetFUPScope()
+		}
+		notFound = contract.UserNotFound
+	case "organisation":
+		var apiOrganisation contract.ApiOrganisationInterface
+		if nil != configProvider.GetOrganisationProvider() {
+			apiOrganisation, err = configProvider.GetOrganisationProvider().ProvideById(id)
+		}
+		if nil == err && nil != apiOrganisation {
+			scope = apiOrganisation.GetFUPScope()
+		}
+		notFound = contract.OrganisationNotFound
+		id = fup.GetOrganisationKey(id)
+	default:
+		abortWithFUPAdminError(c, http.StatusNotFound, contract.NewAuthError(contract.InvalidRequest, map[string]string{"details": fmt.Sprintf("unknown FUP subject %s", c.Param("subject"))}))
+		return "", nil, false
+	}
+	if nil != err && notFound != err.Code {
+		abortWithFUPAdminError(c, http.StatusInternalServerError, err)
+		return "", nil, false
+	}
+	if nil == scope {
+		abortWithFUPAdminError(c, http.StatusNotFound, contract.NewAuthError(notFound, nil))
+		return "", nil, false
+	}
+	return id, scope, true
+}
+
+// getFUPAdminActor returns who changes the FUP entries (the login of the user or the client id of the administrator)
+func getFUPAdminActor(c contract.RequestContext) string {
+	principal := contract.GetPrincipal(c)
+	if apiUser := principal.GetApiUser(); nil != apiUser {
+		return apiUser.GetLogin()
+	}
+	if apiClient := principal.GetApiClient(); nil != apiClient {
+		return apiClient.GetClientId()
+	}
+	return ""
+}
+
+func handleFUPAdminError(c contract.RequestContext, err *contract.AuthError) {
+	if contract.InvalidRequest == err.Code {
+		abortWithFUPAdminError(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	abortWithFUPAdminError(c, http.StatusInternalServerError, err)
+}
+
+func fupAdminListHandler(c contract.RequestContext) {
+	key, scope, ok := resolveFUPSubject(c)
+	if !ok {
+		return
+	}
+	entries, err := fup.ListEntries(config.GetProvider(c), scope, key)
+	if nil != err {
+		handleFUPAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func fupAdminResetHandler(c contract.RequestContext) {
+	request := FUPResetRequest{}
+	if err := c.ShouldBindJSON(&request); nil != err {
+		handleFUPAdminError(c, contract.NewAuthError(contract.InvalidRequest, map[string]string{"details": err.Error()}))
+		return
+	}
+	key, scope, ok := resolveFUPSubject(c)
+	if !ok {
+		return
+	}
+	configProvider := config.GetProvider(c)
+	entries := []string{request.Entry}
+	if "" == request.Entry {
+		listed, err := fup.ListEntries(configProvider, scope, key)
+		if nil != err {
+			handleFUPAdminError(c, err)
+			return
+		}
+		entries = make([]string, 0, len(listed))
+		for _, entry := range listed {
+			entries = append(entries, entry.Id)
+		}
+	}
+	for _, entry := range entries {
+		if err := fup.ResetEntry(configProvider, scope, key, entry, request.Period, getFUPAdminActor(c)); nil != err {
+			handleFUPAdminError(c, err)
+			return
+		}
+	}
+	c.GetWriter().WriteHeader(http.StatusNoContent)
+}
+
+func fupAdminAdjustHandler(c contract.RequestContext) {
+	request := FUPAdjustRequest{}
+	if err := c.ShouldBindJSON(&request); nil != err {
+		handleFUPAdminError(c, contract.NewAuthError(contract.InvalidRequest, map[string]string{"details": err.Error()}))
+		return
+	}
+	key, scope, ok := resolveFUPSubject(c)
+	if !ok {
+		return
+	}
+	if err := fup.AdjustEntry(config.GetProvider(c), scope, key, request.Entry, request.Period, request.Delta, getFUPAdminActor(c)); nil != err {
+		handleFUPAdminError(c, err)
+		return
+	}
+	c.GetWriter().WriteHeader(http.StatusNoContent)
+}
@@ -3,20 +3,62 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"net/http"
 )
 
-// Register adds auth routes (/authenticate, /registration/*, /resetting/*, /token/generate)
+// Route is an auth route; the routes are registered on gin engines (see RegisterFor) and served by net/http (see stdhttp.Routes)
+type Route struct {
+	Method string
+	// Path is the route template (e.g. `/registration/confirm/:token`)
+	Path    string
+	Handler func(c contract.RequestContext)
+}
+
+// GetRoutes returns the auth routes (/authenticate, /registration/*, /resetting/*, /token/generate, /fup/usage)
+// enabled by the given configuration instance
+func GetRoutes(configProvider *config.Provider) []Route {
+	routes := []Route{
+		{Method: http.MethodPost, Path: "/authenticate", Handler: authenticateHandler},
+	}
+	if configProvider.IsUserRegistrationEnabled() {
+		routes = append(
+			routes,
+			Route{Method: http.MethodPost, Path: "/registration/request", Handler: registrationRequestHandler},
+			Route{Method: http.MethodPost, Path: "/registration/confirm/:token", Handler: registrationConfirmHandler},
+			Route{Method: http.MethodPost, Path: "/resetting/request", Handler: resettingRequestHandler},
+			Route{Method: http.MethodPost, Path: "/resetting/reset/:token", Handler: resettingResetHandler},
+		)
+	}
+	if configProvider.IsOneOffTokenModeEnabled() {
+		routes = append(routes, Route{Method: http.MethodGet, Path: "/token/generate", Handler: generateTokenHandler})
+	}
+	if configProvider.IsClientFUPEnabled() || configProvider.IsUserFUPEnabled() {
+		routes = append(routes, Route{Method: http.MethodGet, Path: "/fup/usage", Handler: fupUsageHandler})
+	}
+	return routes
+}
+
+// registerRoutes adds the routes to the engine or route group (the handlers get the configuration instance bound to the request)
+func registerRoutes(r gin.IRoutes, configProvider *config.Provider, routes []Route) {
+	for _, route := range routes {
+		handler := route.Handler
+		r.Handle(route.Method, route.Path, configProvider.Bind, func(c *gin.Context) {
+			handler(contract.NewGinContext(c))
+		})
+	}
+}
+
+// Register adds auth routes (/authenticate, /registration/*, /resetting/*, /token/generate, /fup/usage)
 // to the engine. Must be called after r.Use(auth.Middleware(...)) so the auth middleware
 // applies to these routes.
 func Register(r *gin.Engine) {
-	r.POST("/authenticate", authenticateHandler)
-	if config.ProviderInstance.IsUserRegistrationEnabled() {
-		r.POST("/registration/request", registrationRequestHandler)
-		r.POST("/registration/confirm/:token", registrationConfirmHandler)
-		r.POST("/resetting/request", resettingRequestHandler)
-		r.POST("/resetting/reset/:token", resettingResetHandler)
-	}
-	if config.ProviderInstance.IsOneOffTokenModeEnabled() {
-		r.GET("/token/generate", generateTokenHandler)
-	}
+	RegisterFor(r, config.ProviderInstance)
+}
+
+// RegisterFor adds auth routes of the given configuration instance to the engine or route group.
+// Must be called after r.Use(auth.MiddlewareFor(configProvider)) so the auth middleware
+// applies to these routes.
+func RegisterFor(r gin.IRoutes, configProvider *config.Provider) {
+	registerRoutes(r, configProvider, GetRoutes(configProvider))
 }
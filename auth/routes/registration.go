@@ -6,7 +6,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/wernerdweight/api-auth-go/v2/auth/config"
-	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
 	"github.com/wernerdweight/api-auth-go/v2/auth/encoder"
 	"github.com/wernerdweight/events-go"
@@ -43,10 +42,10 @@ func validatePassword(email string, password string) *contract.AuthError {
 	return nil
 }
 
-func registrationRequestHandler(c *gin.Context) {
+func registrationRequestHandler(c contract.RequestContext) {
 	request := RegistrationRequest{}
 	if err := c.ShouldBindJSON(&request); nil != err {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    contract.InvalidRequest,
 			"message": contract.AuthErrorCodes[contract.InvalidRequest],
 			"payload": map[string]string{"details": err.Error()},
@@ -60,7 +59,7 @@ func registrationRequestHandler(c *gin.Context) {
 		Password: request.Password,
 	})
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    contract.InvalidRequest,
 			"message": contract.AuthErrorCodes[contract.InvalidRequest],
 			"payload": map[string]string{"details": err.Error()},
@@ -69,10 +68,10 @@ func registrationRequestHandler(c *gin.Context) {
 	}
 
 	// check for duplicates
-	provider := config.ProviderInstance.GetUserProvider()
+	provider := config.GetProvider(c).GetUserProvider()
 	user, authErr := provider.ProvideByLogin(request.Email)
 	if nil != user {
-		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+		c.JSON(http.StatusConflict, gin.H{
 			"code":    contract.UserAlreadyExists,
 			"message": contract.AuthErrorCodes[contract.UserAlreadyExists],
 			"payload": nil,
@@ -80,7 +79,7 @@ func registrationRequestHandler(c *gin.Context) {
 		return
 	}
 	if nil != authErr && contract.UserNotFound != authErr.Code {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
@@ -90,7 +89,7 @@ func registrationRequestHandler(c *gin.Context) {
 
 	authErr = validatePassword(request.Email, request.Password)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": map[string][]string{"details": authErr.Payload.([]string)},
@@ -100,7 +99,7 @@ func registrationRequestHandler(c *gin.Context) {
 
 	encryptedPassword, err := encoder.EncryptPassword(request.Password)
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    contract.EncryptionError,
 			"message": contract.AuthErrorCodes[contract.EncryptionError],
 			"payload": map[string]string{"details": err.Error()},
@@ -108,11 +107,7 @@ func registrationRequestHandler(c *gin.Context) {
 		return
 	}
 
-	apiClient, _ := c.Get(constants.ApiClient)
-	var typedApiClient contract.ApiClientInterface
-	if nil != apiClient {
-		typedApiClient = apiClient.(contract.ApiClientInterface)
-	}
+	typedApiClient := contract.GetPrincipal(c).GetApiClient()
 
 	apiUser := provider.ProvideNew(request.Email, encryptedPassword)
 	// call external service to set user details and other fields (event)
@@ -122,7 +117,7 @@ func registrationRequestHandler(c *gin.Context) {
 		PlainPassword: request.Password,
 	})
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    contract.InvalidRequest,
 			"message": contract.AuthErrorCodes[contract.InvalidRequest],
 			"payload": map[string]string{"details": err.Error()},
@@ -132,7 +127,7 @@ func registrationRequestHandler(c *gin.Context) {
 
 	authErr = provider.Save(apiUser)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
@@ -153,13 +148,13 @@ func registrationRequestHandler(c *gin.Context) {
 	})
 }
 
-func registrationConfirmHandler(c *gin.Context) {
+func registrationConfirmHandler(c contract.RequestContext) {
 	token := c.Param("token")
 
-	provider := config.ProviderInstance.GetUserProvider()
+	provider := config.GetProvider(c).GetUserProvider()
 	apiUser, authErr := provider.ProvideByConfirmationToken(token)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+		c.JSON(http.StatusNotFound, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
@@ -171,11 +166,7 @@ func registrationConfirmHandler(c *gin.Context) {
 	apiUser.SetConfirmationToken(nil)
 	apiUser.SetConfirmationRequestedAt(nil)
 
-	apiClient, _ := c.Get(constants.ApiClient)
-	var typedApiClient contract.ApiClientInterface
-	if nil != apiClient {
-		typedApiClient = apiClient.(contract.ApiClientInterface)
-	}
+	typedApiClient := contract.GetPrincipal(c).GetApiClient()
 
 	// call external service to set user details and other fields (event)
 	err := events.GetEventHub().DispatchSync(&contract.ActivateApiUserEvent{
@@ -183,7 +174,7 @@ func registrationConfirmHandler(c *gin.Context) {
 		ApiClient: typedApiClient,
 	})
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    contract.InvalidRequest,
 			"message": contract.AuthErrorCodes[contract.InvalidRequest],
 			"payload": map[string]string{"details": err.Error()},
@@ -193,7 +184,7 @@ func registrationConfirmHandler(c *gin.Context) {
 
 	authErr = provider.Save(apiUser)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
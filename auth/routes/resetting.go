@@ -20,10 +20,10 @@ type ResettingResetRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-func resettingRequestHandler(c *gin.Context) {
+func resettingRequestHandler(c contract.RequestContext) {
 	request := ResettingRequestRequest{}
 	if err := c.ShouldBindJSON(&request); nil != err {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    contract.InvalidRequest,
 			"message": contract.AuthErrorCodes[contract.InvalidRequest],
 			"payload": map[string]string{"details": err.Error()},
@@ -31,10 +31,10 @@ func resettingRequestHandler(c *gin.Context) {
 		return
 	}
 
-	provider := config.ProviderInstance.GetUserProvider()
+	provider := config.GetProvider(c).GetUserProvider()
 	apiUser, authErr := provider.ProvideByLogin(request.Email)
 	if nil == apiUser {
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+		c.JSON(http.StatusNotFound, gin.H{
 			"code":    contract.UserNotFound,
 			"message": contract.AuthErrorCodes[contract.UserNotFound],
 			"payload": nil,
@@ -42,7 +42,7 @@ func resettingRequestHandler(c *gin.Context) {
 		return
 	}
 	if nil != authErr && contract.UserNotFound != authErr.Code {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
@@ -50,7 +50,7 @@ func resettingRequestHandler(c *gin.Context) {
 		return
 	}
 	if !apiUser.IsActive() {
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		c.JSON(http.StatusForbidden, gin.H{
 			"code":    contract.UserNotActive,
 			"message": contract.AuthErrorCodes[contract.UserNotActive],
 			"payload": nil,
@@ -60,10 +60,10 @@ func resettingRequestHandler(c *gin.Context) {
 
 	// check for recent requests (prevent spam)
 	if nil != apiUser.GetResetRequestedAt() && nil != apiUser.GetResetToken() {
-		expirationInterval := config.ProviderInstance.GetConfirmationTokenExpirationInterval()
+		expirationInterval := config.GetProvider(c).GetConfirmationTokenExpirationInterval()
 		expiresAt := apiUser.GetResetRequestedAt().Add(expirationInterval)
 		if expiresAt.After(time.Now()) {
-			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			c.JSON(http.StatusConflict, gin.H{
 				"code":    contract.ResettingAlreadyRequested,
 				"message": contract.AuthErrorCodes[contract.ResettingAlreadyRequested],
 				"payload": map[string]time.Time{"expiresAt": expiresAt},
@@ -78,18 +78,14 @@ func resettingRequestHandler(c *gin.Context) {
 	apiUser.SetResetToken(&token)
 	apiUser.SetResetRequestedAt(&now)
 
-	apiClient, _ := c.Get(constants.ApiClient)
-	var typedApiClient contract.ApiClientInterface
-	if nil != apiClient {
-		typedApiClient = apiClient.(contract.ApiClientInterface)
-	}
+	typedApiClient := contract.GetPrincipal(c).GetApiClient()
 
 	err := events.GetEventHub().DispatchSync(&contract.RequestResetApiUserPasswordEvent{
 		ApiUser:   apiUser,
 		ApiClient: typedApiClient,
 	})
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    contract.InvalidRequest,
 			"message": contract.AuthErrorCodes[contract.InvalidRequest],
 			"payload": map[string]string{"details": err.Error()},
@@ -99,7 +95,7 @@ func resettingRequestHandler(c *gin.Context) {
 
 	authErr = provider.Save(apiUser)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
@@ -118,11 +114,11 @@ func resettingRequestHandler(c *gin.Context) {
 	})
 }
 
-func resettingResetHandler(c *gin.Context) {
+func resettingResetHandler(c contract.RequestContext) {
 	token := c.Param("token")
 	request := ResettingResetRequest{}
 	if err := c.ShouldBindJSON(&request); nil != err {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    contract.InvalidRequest,
 			"message": contract.AuthErrorCodes[contract.InvalidRequest],
 			"payload": map[string]string{"details": err.Error()},
@@ -130,10 +126,10 @@ func resettingResetHandler(c *gin.Context) {
 		return
 	}
 
-	provider := config.ProviderInstance.GetUserProvider()
+	provider := config.GetProvider(c).GetUserProvider()
 	apiUser, authErr := provider.ProvideByResetToken(token)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+		c.JSON(http.StatusNotFound, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
@@ -143,7 +139,7 @@ func resettingResetHandler(c *gin.Context) {
 
 	authErr = validatePassword(apiUser.GetLogin(), request.Password)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": map[string][]string{"details": authErr.Payload.([]string)},
@@ -153,7 +149,7 @@ func resettingResetHandler(c *gin.Context) {
 
 	encryptedPassword, err := encoder.EncryptPassword(request.Password)
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    contract.EncryptionError,
 			"message": contract.AuthErrorCodes[contract.EncryptionError],
 			"payload": map[string]string{"details": err.Error()},
@@ -165,11 +161,7 @@ func resettingResetHandler(c *gin.Context) {
 	apiUser.SetResetToken(nil)
 	apiUser.SetResetRequestedAt(nil)
 
-	apiClient, _ := c.Get(constants.ApiClient)
-	var typedApiClient contract.ApiClientInterface
-	if nil != apiClient {
-		typedApiClient = apiClient.(contract.ApiClientInterface)
-	}
+	typedApiClient := contract.GetPrincipal(c).GetApiClient()
 
 	// call external service to set user details and other fields (event)
 	err = events.GetEventHub().DispatchSync(&contract.ResetApiUserPasswordEvent{
@@ -177,7 +169,7 @@ func resettingResetHandler(c *gin.Context) {
 		ApiClient: typedApiClient,
 	})
 	if nil != err {
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
 			"code":    contract.InvalidRequest,
 			"message": contract.AuthErrorCodes[contract.InvalidRequest],
 			"payload": map[string]string{"details": err.Error()},
@@ -187,7 +179,7 @@ func resettingResetHandler(c *gin.Context) {
 
 	authErr = provider.InvalidateTokens(apiUser)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
@@ -196,7 +188,7 @@ func resettingResetHandler(c *gin.Context) {
 	}
 	authErr = provider.Save(apiUser)
 	if nil != authErr {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    authErr.Code,
 			"message": authErr.Err.Error(),
 			"payload": authErr.Payload,
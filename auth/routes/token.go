@@ -10,20 +10,21 @@ import (
 	"time"
 )
 
-func generateTokenHandler(c *gin.Context) {
-	if !config.ProviderInstance.IsCacheEnabled() {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+func generateTokenHandler(c contract.RequestContext) {
+	configProvider := config.GetProvider(c)
+	if !configProvider.IsCacheEnabled() {
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    contract.CacheDisabled,
 			"message": contract.AuthErrorCodes[contract.CacheDisabled],
 			"payload": nil,
 		})
 		return
 	}
-	cacheDriver := config.ProviderInstance.GetCacheDriver()
+	cacheDriver := configProvider.GetCacheDriver()
 
-	apiClient, ok := c.Get(constants.ApiClient)
-	if !ok {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+	apiClient := contract.GetPrincipal(c).GetApiClient()
+	if nil == apiClient {
+		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    contract.Unauthorized,
 			"message": contract.AuthErrorCodes[contract.Unauthorized],
 			"payload": nil,
@@ -34,10 +35,10 @@ func generateTokenHandler(c *gin.Context) {
 	tokenGenerator := generator.NewTokenGenerator("")
 	token := contract.OneOffToken{
 		Value:   tokenGenerator.Generate(constants.OneOffTokenLength),
-		Expires: time.Now().Add(config.ProviderInstance.GetOneOffTokenExpirationInterval()),
+		Expires: time.Now().Add(configProvider.GetOneOffTokenExpirationInterval()),
 	}
 
-	cacheDriver.SetApiClientByOneOffToken(token, apiClient.(contract.ApiClientInterface))
+	cacheDriver.SetApiClientByOneOffToken(token, apiClient)
 
 	c.JSON(http.StatusOK, token)
 }
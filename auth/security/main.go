@@ -6,15 +6,20 @@ import (
 	"github.com/wernerdweight/api-auth-go/v2/auth/config"
 	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
 	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/fup"
+	"github.com/wernerdweight/api-auth-go/v2/auth/signer"
+	"github.com/wernerdweight/events-go"
 	"log"
+	"log/slog"
 	"regexp"
 )
 
-func shouldAuthenticate(c *gin.Context) bool {
-	excludeHandlers := config.ProviderInstance.GetExcludeHandlers()
+func shouldAuthenticate(c contract.RequestContext) bool {
+	configProvider := config.GetProvider(c)
+	excludeHandlers := configProvider.GetExcludeHandlers()
 	if nil != excludeHandlers && len(*excludeHandlers) > 0 {
 		for _, excludeHandler := range *excludeHandlers {
-			matched, err := regexp.MatchString(excludeHandler, c.Request.URL.String())
+			matched, err := regexp.MatchString(excludeHandler, c.GetRequest().URL.String())
 			if nil != err {
 				log.Printf("can't match exclude handler pattern '%s': %v", excludeHandler, err)
 			}
@@ -23,12 +28,12 @@ func shouldAuthenticate(c *gin.Context) bool {
 			}
 		}
 	}
-	targetHandlers := config.ProviderInstance.GetTargetHandlers()
+	targetHandlers := configProvider.GetTargetHandlers()
 	if nil == targetHandlers || len(*targetHandlers) == 0 {
 		return true
 	}
 	for _, targetHandler := range *targetHandlers {
-		matched, err := regexp.MatchString(targetHandler, c.Request.URL.String())
+		matched, err := regexp.MatchString(targetHandler, c.GetRequest().URL.String())
 		if nil != err {
 			log.Printf("can't match target handler pattern '%s': %v", targetHandler, err)
 		}
@@ -39,25 +44,34 @@ func shouldAuthenticate(c *gin.Context) bool {
 	return false
 }
 
-func shouldAuthenticateByOneOffToken(c *gin.Context) bool {
-	return config.ProviderInstance.IsOneOffTokenModeEnabled() && c.Request.Header.Get(constants.OneOffTokenHeader) != ""
+func shouldAuthenticateByOneOffToken(c contract.RequestContext) bool {
+	return config.GetProvider(c).IsOneOffTokenModeEnabled() && c.GetRequest().Header.Get(constants.OneOffTokenHeader) != ""
 }
 
-func shouldAuthenticateByApiClientAndSecret(c *gin.Context) bool {
-	return c.Request.Header.Get(constants.ClientIdHeader) != "" && c.Request.Header.Get(constants.ClientSecretHeader) != ""
+func shouldAuthenticateBySignedUrl(c contract.RequestContext) bool {
+	return config.GetProvider(c).IsSignedUrlModeEnabled() && c.GetRequest().URL.Query().Get(constants.SignedUrlSignatureParam) != ""
 }
 
-func shouldAuthenticateByApiKey(c *gin.Context) bool {
-	return c.Request.Header.Get(constants.ApiKeyHeader) != ""
+func hasSignedUrlUser(c contract.RequestContext) bool {
+	return shouldAuthenticateBySignedUrl(c) && c.GetRequest().URL.Query().Get(constants.SignedUrlUserParam) != ""
 }
 
-func authenticateApiClientByOneOffToken(c *gin.Context) (contract.ApiClientInterface, *contract.AuthError) {
+func shouldAuthenticateByApiClientAndSecret(c contract.RequestContext) bool {
+	return c.GetRequest().Header.Get(constants.ClientIdHeader) != "" && c.GetRequest().Header.Get(constants.ClientSecretHeader) != ""
+}
+
+func shouldAuthenticateByApiKey(c contract.RequestContext) bool {
+	return c.GetRequest().Header.Get(constants.ApiKeyHeader) != ""
+}
+
+func authenticateApiClientByOneOffToken(c contract.RequestContext) (contract.ApiClientInterface, *contract.AuthError) {
+	configProvider := config.GetProvider(c)
 	// check if one-off token is allowed for the current request
-	targetHandlers := config.ProviderInstance.GetTargetOneOffTokenHandlers()
+	targetHandlers := configProvider.GetTargetOneOffTokenHandlers()
 	if nil != targetHandlers && len(*targetHandlers) > 0 {
 		inScope := false
 		for _, targetHandler := range *targetHandlers {
-			matched, err := regexp.MatchString(targetHandler, c.Request.URL.String())
+			matched, err := regexp.MatchString(targetHandler, c.GetRequest().URL.String())
 			if nil != err {
 				log.Printf("can't match one-off token target handler pattern '%s': %v", targetHandler, err)
 			}
@@ -71,11 +85,11 @@ func authenticateApiClientByOneOffToken(c *gin.Context) (contract.ApiClientInter
 		}
 	}
 
-	token := c.Request.Header.Get(constants.OneOffTokenHeader)
-	if !config.ProviderInstance.IsCacheEnabled() {
+	token := c.GetRequest().Header.Get(constants.OneOffTokenHeader)
+	if !configProvider.IsCacheEnabled() {
 		return nil, contract.NewInternalError(contract.CacheDisabled, nil)
 	}
-	cacheDriver := config.ProviderInstance.GetCacheDriver()
+	cacheDriver := configProvider.GetCacheDriver()
 	apiClient, err := cacheDriver.GetApiClientByOneOffToken(token)
 	if nil != err {
 		return nil, err
@@ -90,11 +104,38 @@ func authenticateApiClientByOneOffToken(c *gin.Context) (contract.ApiClientInter
 	return apiClient, nil
 }
 
-func authenticateApiClientByApiClientAndSecret(c *gin.Context, apiClientProvider contract.ApiClientProviderInterface[contract.ApiClientInterface]) (contract.ApiClientInterface, *contract.AuthError) {
-	clientId := c.Request.Header.Get(constants.ClientIdHeader)
-	clientSecret := c.Request.Header.Get(constants.ClientSecretHeader)
-	if config.ProviderInstance.IsCacheEnabled() {
-		apiClient, err := config.ProviderInstance.GetCacheDriver().GetApiClientByIdAndSecret(clientId, clientSecret)
+// getSignedUrlClaims returns the claims of the signed URL of the request (the URL is verified once per request)
+func getSignedUrlClaims(c contract.RequestContext) (*signer.Claims, *contract.AuthError) {
+	if value, ok := c.Get(constants.SignedUrlClaims); ok {
+		return value.(*signer.Claims), nil
+	}
+	claims, err := signer.NewUrlSignerFor(config.GetProvider(c)).Verify(c.GetRequest().URL, c.GetRequest().Method)
+	if nil != err {
+		return nil, err
+	}
+	c.Set(constants.SignedUrlClaims, claims)
+	return claims, nil
+}
+
+func authenticateApiClientBySignedUrl(c contract.RequestContext, apiClientProvider contract.ApiClientProviderInterface[contract.ApiClientInterface]) (contract.ApiClientInterface, *contract.AuthError) {
+	claims, err := getSignedUrlClaims(c)
+	if nil != err {
+		return nil, err
+	}
+	byIdProvider, ok := apiClientProvider.(contract.ApiClientByIdProviderInterface)
+	if !ok {
+		return nil, contract.NewInternalError(contract.ClientByIdNotSupported, nil)
+	}
+	// signed URLs must work without cache, so the client is always loaded from the provider
+	return byIdProvider.ProvideByClientId(claims.ClientId)
+}
+
+func authenticateApiClientByApiClientAndSecret(c contract.RequestContext, apiClientProvider contract.ApiClientProviderInterface[contract.ApiClientInterface]) (contract.ApiClientInterface, *contract.AuthError) {
+	configProvider := config.GetProvider(c)
+	clientId := c.GetRequest().Header.Get(constants.ClientIdHeader)
+	clientSecret := c.GetRequest().Header.Get(constants.ClientSecretHeader)
+	if configProvider.IsCacheEnabled() {
+		apiClient, err := configProvider.GetCacheDriver().GetApiClientByIdAndSecret(clientId, clientSecret)
 		if nil != apiClient {
 			return apiClient, nil
 		}
@@ -106,8 +147,8 @@ func authenticateApiClientByApiClientAndSecret(c *gin.Context, apiClientProvider
 	if nil != err {
 		return nil, err
 	}
-	if config.ProviderInstance.IsCacheEnabled() {
-		err = config.ProviderInstance.GetCacheDriver().SetApiClientByIdAndSecret(clientId, clientSecret, apiClient)
+	if configProvider.IsCacheEnabled() {
+		err = configProvider.GetCacheDriver().SetApiClientByIdAndSecret(clientId, clientSecret, apiClient)
 		if nil != err {
 			log.Printf("can't set api client to cache: %v", err)
 		}
@@ -115,10 +156,11 @@ func authenticateApiClientByApiClientAndSecret(c *gin.Context, apiClientProvider
 	return apiClient, nil
 }
 
-func authenticateApiClientByApiKey(c *gin.Context, apiClientProvider contract.ApiClientProviderInterface[contract.ApiClientInterface]) (contract.ApiClientInterface, *contract.AuthError) {
-	apiKey := c.Request.Header.Get(constants.ApiKeyHeader)
-	if config.ProviderInstance.IsCacheEnabled() {
-		apiClient, err := config.ProviderInstance.GetCacheDriver().GetApiClientByApiKey(apiKey)
+func authenticateApiClientByApiKey(c contract.RequestContext, apiClientProvider contract.ApiClientProviderInterface[contract.ApiClientInterface]) (contract.ApiClientInterface, *contract.AuthError) {
+	configProvider := config.GetProvider(c)
+	apiKey := c.GetRequest().Header.Get(constants.ApiKeyHeader)
+	if configProvider.IsCacheEnabled() {
+		apiClient, err := configProvider.GetCacheDriver().GetApiClientByApiKey(apiKey)
 		if nil != apiClient {
 			return apiClient, nil
 		}
@@ -130,8 +172,8 @@ func authenticateApiClientByApiKey(c *gin.Context, apiClientProvider contract.Ap
 	if nil != err {
 		return nil, err
 	}
-	if config.ProviderInstance.IsCacheEnabled() {
-		err = config.ProviderInstance.GetCacheDriver().SetApiClientByApiKey(apiKey, apiClient)
+	if configProvider.IsCacheEnabled() {
+		err = configProvider.GetCacheDriver().SetApiClientByApiKey(apiKey, apiClient)
 		if nil != err {
 			log.Printf("can't set api client to cache: %v", err)
 		}
@@ -139,12 +181,16 @@ func authenticateApiClientByApiKey(c *gin.Context, apiClientProvider contract.Ap
 	return apiClient, nil
 }
 
-func authenticateApiClient(c *gin.Context) (contract.ApiClientInterface, *contract.AuthError) {
+func authenticateApiClient(c contract.RequestContext) (contract.ApiClientInterface, *contract.AuthError) {
 	if shouldAuthenticateByOneOffToken(c) {
 		return authenticateApiClientByOneOffToken(c)
 	}
 
-	apiClientProvider := config.ProviderInstance.GetClientProvider()
+	apiClientProvider := config.GetProvider(c).GetClientProvider()
+	if shouldAuthenticateBySignedUrl(c) {
+		return authenticateApiClientBySignedUrl(c, apiClientProvider)
+	}
+
 	if shouldAuthenticateByApiClientAndSecret(c) {
 		return authenticateApiClientByApiClientAndSecret(c, apiClientProvider)
 	}
@@ -156,15 +202,38 @@ func authenticateApiClient(c *gin.Context) (contract.ApiClientInterface, *contra
 	return nil, contract.NewAuthError(contract.NoCredentialsProvided, nil)
 }
 
-func authenticateApiUser(c *gin.Context) (contract.ApiUserInterface, *contract.AuthError) {
-	if c.Request.Header.Get(constants.ApiUserTokenHeader) == "" {
+func authenticateApiUserBySignedUrl(c contract.RequestContext) (contract.ApiUserInterface, *contract.AuthError) {
+	claims, err := getSignedUrlClaims(c)
+	if nil != err {
+		return nil, err
+	}
+	apiUserProvider := config.GetProvider(c).GetUserProvider()
+	if nil == apiUserProvider {
+		return nil, contract.NewInternalError(contract.UserProviderNotConfigured, nil)
+	}
+	apiUser, err := apiUserProvider.ProvideByLogin(claims.Login)
+	if nil != err {
+		return nil, err
+	}
+	if !apiUser.IsActive() {
+		return nil, contract.NewAuthError(contract.UserNotActive, nil)
+	}
+	return apiUser, nil
+}
+
+func authenticateApiUser(c contract.RequestContext) (contract.ApiUserInterface, *contract.AuthError) {
+	configProvider := config.GetProvider(c)
+	if hasSignedUrlUser(c) {
+		return authenticateApiUserBySignedUrl(c)
+	}
+	if c.GetRequest().Header.Get(constants.ApiUserTokenHeader) == "" {
 		return nil, contract.NewAuthError(contract.UserTokenRequired, nil)
 	}
-	apiToken := c.Request.Header.Get(constants.ApiUserTokenHeader)
-	currentToken := config.ProviderInstance.GetTokenFactory()()
+	apiToken := c.GetRequest().Header.Get(constants.ApiUserTokenHeader)
+	currentToken := configProvider.GetTokenFactory()()
 	currentToken.SetToken(apiToken)
-	if config.ProviderInstance.IsCacheEnabled() {
-		apiUser, err := config.ProviderInstance.GetCacheDriver().GetApiUserByToken(apiToken)
+	if configProvider.IsCacheEnabled() {
+		apiUser, err := configProvider.GetCacheDriver().GetApiUserByToken(apiToken)
 		if nil != apiUser {
 			apiUser.SetCurrentToken(currentToken)
 			return apiUser, nil
@@ -173,7 +242,7 @@ func authenticateApiUser(c *gin.Context) (contract.ApiUserInterface, *contract.A
 			log.Printf("can't get api user from cache: %v", err)
 		}
 	}
-	apiUserProvider := config.ProviderInstance.GetUserProvider()
+	apiUserProvider := configProvider.GetUserProvider()
 	if nil == apiUserProvider {
 		return nil, contract.NewInternalError(contract.UserProviderNotConfigured, nil)
 	}
@@ -181,8 +250,8 @@ func authenticateApiUser(c *gin.Context) (contract.ApiUserInterface, *contract.A
 	if nil != err {
 		return nil, err
 	}
-	if config.ProviderInstance.IsCacheEnabled() {
-		err = config.ProviderInstance.GetCacheDriver().SetApiUserByToken(apiToken, apiUser)
+	if configProvider.IsCacheEnabled() {
+		err = configProvider.GetCacheDriver().SetApiUserByToken(apiToken, apiUser)
 		if nil != err {
 			log.Printf("can't set api user to cache: %v", err)
 		}
@@ -192,88 +261,429 @@ func authenticateApiUser(c *gin.Context) (contract.ApiUserInterface, *contract.A
 	return apiUser, nil
 }
 
-func authenticateOnBehalf(c *gin.Context) *contract.AuthError {
+// getAuthenticationMethod returns the method the api client has been authenticated by (see authenticateApiClient)
+func getAuthenticationMethod(c contract.RequestContext, apiClient contract.ApiClientInterface) constants.AuthenticationMethod {
+	switch {
+	case shouldAuthenticateByOneOffToken(c):
+		return constants.AuthenticationMethodOneOffToken
+	case shouldAuthenticateBySignedUrl(c):
+		return constants.AuthenticationMethodSignedUrl
+	case shouldAuthenticateByApiClientAndSecret(c):
+		return constants.AuthenticationMethodClientSecret
+	case nil != apiClient.GetCurrentApiKey():
+		return constants.AuthenticationMethodAdditionalApiKey
+	}
+	return constants.AuthenticationMethodApiKey
+}
+
+func newPrincipal(c contract.RequestContext, apiClient contract.ApiClientInterface) *contract.Principal {
+	principal := &contract.Principal{
+		ApiClient: apiClient,
+		Method:    getAuthenticationMethod(c, apiClient),
+	}
+	if constants.AuthenticationMethodAdditionalApiKey == principal.Method {
+		principal.KeyId = contract.GetKeyId(apiClient.GetCurrentApiKey())
+		principal.ExpiresAt = apiClient.GetCurrentApiKey().GetExpirationDate()
+	}
+	return principal
+}
+
+// completePrincipal sets the user, tenant and effective scope of the authenticated request to its principal
+func completePrincipal(c contract.RequestContext) {
+	principal := contract.GetPrincipal(c)
+	if nil == principal {
+		return
+	}
+	principal.TenantId = contract.GetString(c, constants.TenantId)
+	if effectiveScope, ok := c.Get(constants.EffectiveScope); ok {
+		principal.EffectiveScope = effectiveScope.(*contract.AccessScope)
+	}
+	if apiUser, ok := c.Get(constants.ApiUser); ok {
+		principal.ApiUser = apiUser.(contract.ApiUserInterface)
+		if token := principal.ApiUser.GetCurrentToken(); nil != token {
+			expiresAt := token.GetExpirationDate()
+			principal.ExpiresAt = &expiresAt
+		}
+	}
+}
+
+func checkTenant(c contract.RequestContext, apiUser contract.ApiUserInterface) *contract.AuthError {
+	userTenantId := contract.GetTenantId(apiUser)
+	clientTenantId := contract.GetString(c, constants.TenantId)
+	if "" == clientTenantId {
+		// clients without an organisation are not restricted to a single tenant
+		if "" != userTenantId {
+			c.Set(constants.TenantId, userTenantId)
+		}
+		return nil
+	}
+	if clientTenantId != userTenantId {
+		return contract.NewAuthError(contract.TenantMismatch, nil)
+	}
+	return nil
+}
+
+func checkOrganisationFUP(c contract.RequestContext) *contract.AuthError {
+	configProvider := config.GetProvider(c)
+	tenantId := contract.GetString(c, constants.TenantId)
+	if "" == tenantId {
+		return nil
+	}
+	setTraceSubject(c, "organisation")
+	apiOrganisation, err := configProvider.GetOrganisationProvider().ProvideById(tenantId)
+	if nil != err {
+		return err
+	}
+	organisationFUPChecker := configProvider.GetOrganisationFUPChecker()
+	fupLimits := organisationFUPChecker.Check(apiOrganisation.GetFUPScope(), c, fup.GetOrganisationKey(tenantId))
+	if nil != fupLimits.Error {
+		return fupLimits.Error
+	}
+	if fupLimits.Accessible == constants.ScopeAccessibilityForbidden {
+		err = deny(c, contract.NewFUPError(fupLimits.GetErrorCode(), fupLimits.Limits))
+		if nil != err {
+			c.Header(constants.RetryAfterHeader, fmt.Sprintf("%d", fupLimits.GetRetryAfter()))
+			fup.SetRateLimitHeaders(c, "organisation", fupLimits)
+			return err
+		}
+	}
+	if principal := contract.GetPrincipal(c); nil != principal {
+		principal.OrganisationFUPLimits = fupLimits.Limits
+	}
+	fup.SetLimitsHeaders(c, "organisation", constants.OrganisationFUPLimitsHeader, fupLimits)
+	return nil
+}
+
+// isFUPExempt returns true if the request is neither counted towards FUP limits nor throttled (e.g. the FUP usage route, see FUPConfig.ExemptHandlers)
+func isFUPExempt(c contract.RequestContext) bool {
+	return config.GetProvider(c).IsFUPExempt(c.GetRequest().URL.Path)
+}
+
+// canUserScopeGrantAccess returns true if the user scope can grant access to a path forbidden by the client scope
+func canUserScopeGrantAccess(configProvider *config.Provider) bool {
+	combination := configProvider.GetScopeCombination()
+	return constants.ScopeCombinationUserOnly == combination || constants.ScopeCombinationUnion == combination
+}
+
+func combineScopeAccessibility(combination constants.ScopeCombination, clientScopeAccessibility constants.ScopeAccessibility, userScopeAccessibility constants.ScopeAccessibility) bool {
+	isClientAccessible := constants.ScopeAccessibilityForbidden != clientScopeAccessibility
+	isUserAccessible := constants.ScopeAccessibilityForbidden != userScopeAccessibility
+	switch combination {
+	case constants.ScopeCombinationClientOnly:
+		return isClientAccessible
+	case constants.ScopeCombinationUserOnly:
+		return isUserAccessible
+	case constants.ScopeCombinationUnion:
+		return isClientAccessible || isUserAccessible
+	}
+	return isClientAccessible && isUserAccessible
+}
+
+func combineScopes(combination constants.ScopeCombination, clientScope *contract.AccessScope, userScope *contract.AccessScope) *contract.AccessScope {
+	switch combination {
+	case constants.ScopeCombinationClientOnly:
+		return clientScope
+	case constants.ScopeCombinationUserOnly:
+		return userScope
+	case constants.ScopeCombinationUnion:
+		return contract.UnionAccessScopes(clientScope, userScope)
+	}
+	return contract.IntersectAccessScopes(clientScope, userScope)
+}
+
+func hasUserCredentials(c contract.RequestContext) bool {
+	return c.GetRequest().Header.Get(constants.ApiUserTokenHeader) != "" || hasSignedUrlUser(c)
+}
+
+// checkAccessScope checks scope against scopeKey (using `|` as a hierarchy separator) if given, with checker otherwise
+func checkAccessScope(c contract.RequestContext, checker contract.AccessScopeCheckerInterface, scope *contract.AccessScope, scopeKey string) constants.ScopeAccessibility {
+	if "" == scopeKey {
+		return checker.Check(scope, c)
+	}
+	if nil == scope {
+		return constants.ScopeAccessibilityForbidden
+	}
+	return scope.CheckAccessibility(scopeKey, "|", c)
+}
+
+func authenticateOnBehalf(c contract.RequestContext, apiClient contract.ApiClientInterface, clientScopeAccessibility constants.ScopeAccessibility, requirement constants.UserRequirement, scopeKey string) *contract.AuthError {
+	configProvider := config.GetProvider(c)
 	apiUser, err := authenticateApiUser(c)
+	if nil != err {
+		if constants.UserRequirementOptional == requirement && constants.ScopeAccessibilityAccessible == clientScopeAccessibility {
+			// optional user: invalid user credentials are ignored if the client is allowed to access the path on its own
+			return nil
+		}
+		return err
+	}
+
+	clientTenantId := contract.GetString(c, constants.TenantId)
+	err = checkTenant(c, apiUser)
 	if nil != err {
 		return err
 	}
 
 	c.Set(constants.ApiUser, apiUser)
+	setTraceSubject(c, constants.ApiUser)
+
+	if "" == clientTenantId && configProvider.IsClientScopeAccessModelEnabled() && configProvider.IsOrganisationFUPEnabled() && !isFUPExempt(c) {
+		// the client has no organisation, so the limits of the user's organisation (if any) apply
+		err = checkOrganisationFUP(c)
+		if nil != err {
+			return err
+		}
+		setTraceSubject(c, constants.ApiUser)
+	}
 
-	if !config.ProviderInstance.IsUserScopeAccessModelEnabled() {
+	if !configProvider.IsUserScopeAccessModelEnabled() {
 		return nil
 	}
 
-	if config.ProviderInstance.IsUserFUPEnabled() {
-		userFUPChecker := config.ProviderInstance.GetUserFUPChecker()
+	if configProvider.IsUserFUPEnabled() && !isFUPExempt(c) {
+		userFUPChecker := configProvider.GetUserFUPChecker()
 		fupLimits := userFUPChecker.Check(apiUser.GetFUPScope(), c, apiUser.GetLogin())
 		if nil != fupLimits.Error {
 			return fupLimits.Error
 		}
 		if fupLimits.Accessible == constants.ScopeAccessibilityForbidden {
-			c.Header(constants.RetryAfterHeader, fmt.Sprintf("%d", fupLimits.GetRetryAfter()))
-			return contract.NewFUPError(contract.RequestLimitDepleted, fupLimits.Limits)
+			err = deny(c, contract.NewFUPError(fupLimits.GetErrorCode(), fupLimits.Limits))
+			if nil != err {
+				c.Header(constants.RetryAfterHeader, fmt.Sprintf("%d", fupLimits.GetRetryAfter()))
+				fup.SetRateLimitHeaders(c, "user", fupLimits)
+				return err
+			}
 		}
-		header := fupLimits.GetLimitsHeader()
-		if "" != header {
-			c.Header(constants.UserFUPLimitsHeader, header)
+		if principal := contract.GetPrincipal(c); nil != principal {
+			principal.UserFUPLimits = fupLimits.Limits
 		}
+		fup.SetLimitsHeaders(c, "user", constants.UserFUPLimitsHeader, fupLimits)
+	}
+	combination := configProvider.GetScopeCombination()
+	if constants.ScopeCombinationClientOnly == combination {
+		return nil
+	}
+	userAccessScopeChecker := configProvider.GetUserScopeAccessChecker()
+	userScopeAccessibility := checkAccessScope(c, userAccessScopeChecker, apiUser.GetUserScope(), scopeKey)
+	c.Set(constants.EffectiveScope, combineScopes(combination, apiClient.GetClientScope(), apiUser.GetUserScope()))
+
+	if !combineScopeAccessibility(combination, clientScopeAccessibility, userScopeAccessibility) {
+		return deny(c, contract.NewAuthError(contract.UserForbidden, nil))
+	}
+	return nil
+}
+
+// isReportOnly returns true if denials should only be reported for the current request (globally or for the authenticated client)
+func isReportOnly(c contract.RequestContext) bool {
+	if config.GetProvider(c).IsReportOnlyEnabled() {
+		return true
 	}
-	userAccessScopeChecker := config.ProviderInstance.GetUserScopeAccessChecker()
-	userScopeAccessibility := userAccessScopeChecker.Check(apiUser.GetUserScope(), c)
+	apiClient, _ := c.Get(constants.ApiClient)
+	return contract.IsReportOnly(apiClient)
+}
 
-	if constants.ScopeAccessibilityForbidden == userScopeAccessibility {
-		return contract.NewAuthError(contract.UserForbidden, nil)
+// deny returns err, unless the request is authenticated in report-only mode; in that case err is recorded (see reportWouldHaveDenied) and nil is returned
+func deny(c contract.RequestContext, err *contract.AuthError) *contract.AuthError {
+	if !isReportOnly(c) {
+		return err
 	}
+	c.Set(constants.WouldHaveDenied, append(getWouldHaveDenied(c), *err))
 	return nil
 }
 
-func Authenticate(c *gin.Context) *contract.AuthError {
+func getWouldHaveDenied(c contract.RequestContext) []contract.AuthError {
+	if value, ok := c.Get(constants.WouldHaveDenied); ok {
+		return value.([]contract.AuthError)
+	}
+	return nil
+}
+
+func reportWouldHaveDenied(c contract.RequestContext, denials []contract.AuthError, trace *contract.DecisionTrace) {
+	if 0 == len(denials) {
+		return
+	}
+	event := &contract.WouldHaveDeniedEvent{
+		Context: c,
+		Errors:  denials,
+		Trace:   trace,
+	}
+	if apiClient, ok := c.Get(constants.ApiClient); ok {
+		event.ApiClient = apiClient.(contract.ApiClientInterface)
+	}
+	if apiUser, ok := c.Get(constants.ApiUser); ok {
+		event.ApiUser = apiUser.(contract.ApiUserInterface)
+	}
+	events.GetEventHub().DispatchAsync(event)
+}
+
+// setTraceSubject sets the subject of the following checks in the decision trace (if tracing is enabled)
+func setTraceSubject(c contract.RequestContext, subject string) {
+	trace := contract.GetDecisionTrace(c)
+	if nil != trace {
+		trace.Subject = subject
+	}
+}
+
+// canReceiveTraceHeader returns true if the trace header is requested by a privileged client (see TraceConfig.HeaderClients)
+func canReceiveTraceHeader(c contract.RequestContext) bool {
+	if "" == c.GetRequest().Header.Get(constants.DebugHeader) {
+		return false
+	}
+	apiClient, ok := c.Get(constants.ApiClient)
+	if !ok {
+		return false
+	}
+	return config.GetProvider(c).CanReceiveTraceHeader(apiClient.(contract.ApiClientInterface).GetClientId())
+}
+
+func reportDecisionTrace(c contract.RequestContext, trace *contract.DecisionTrace, err *contract.AuthError) {
+	configProvider := config.GetProvider(c)
+	if nil != err {
+		trace.Error = err.Err.Error()
+	}
+	if canReceiveTraceHeader(c) {
+		header, marshalErr := trace.MarshalLimited(configProvider.GetTraceHeaderMaxLength())
+		if nil != marshalErr {
+			log.Printf("can't marshal decision trace: %v", marshalErr)
+		} else if nil != header {
+			c.Header(constants.DecisionTraceHeader, string(header))
+		}
+	}
+	if configProvider.IsTraceLogEnabled() {
+		slog.Info("authorization decision", "method", c.GetRequest().Method, "path", c.GetRequest().URL.Path, "trace", trace)
+	}
+}
+
+// Authenticate authenticates the request if it is targeted by the configuration (see TargetHandlers and ExcludeHandlers)
+func Authenticate(c contract.RequestContext) *contract.AuthError {
 	if !shouldAuthenticate(c) {
 		return nil
 	}
+	return AuthenticateWith(c, constants.UserRequirementScope)
+}
 
-	apiClient, err := authenticateApiClient(c)
+// AuthenticateWith authenticates the request regardless of the targeted handlers;
+// requirement decides whether the api user must be authenticated (scope: only if required by the client scope)
+func AuthenticateWith(c contract.RequestContext, requirement constants.UserRequirement) *contract.AuthError {
+	return authenticateRequest(c, requirement, "")
+}
+
+// AuthenticateForScope authenticates the request regardless of the targeted handlers (the api user is required only by the scope);
+// unlike AuthenticateWith, the client and user scopes are checked against key (using `|` as a hierarchy separator) instead of the request path
+func AuthenticateForScope(c contract.RequestContext, key string) *contract.AuthError {
+	_, authenticated := c.Get(constants.ApiClient)
+	if !authenticated && config.GetProvider(c).IsClientScopeAccessModelEnabled() {
+		return authenticateRequest(c, constants.UserRequirementScope, key)
+	}
+	// the scopes are not checked during the authentication (or they have been checked against the path already)
+	err := authenticateRequest(c, constants.UserRequirementScope, "")
 	if nil != err {
 		return err
 	}
+	return CheckScope(c, key)
+}
 
-	c.Set(constants.ApiClient, apiClient)
+// authenticateRequest authenticates the request; if scopeKey is given, the scopes are checked against it instead of the request path
+func authenticateRequest(c contract.RequestContext, requirement constants.UserRequirement, scopeKey string) *contract.AuthError {
+	if apiClient, ok := c.Get(constants.ApiClient); ok {
+		// the client is already authenticated (e.g. by the engine-wide middleware), only the user may be missing
+		err := authenticateMissingUser(c, apiClient.(contract.ApiClientInterface), requirement)
+		if nil == err {
+			completePrincipal(c)
+		}
+		return err
+	}
+	var trace *contract.DecisionTrace
+	if config.GetProvider(c).IsTracingEnabled() {
+		trace = &contract.DecisionTrace{}
+		c.Set(constants.DecisionTrace, trace)
+	}
+	err := authenticate(c, requirement, scopeKey)
+	if nil != trace {
+		reportDecisionTrace(c, trace, err)
+	}
+	if nil == err {
+		completePrincipal(c)
+		reportWouldHaveDenied(c, getWouldHaveDenied(c), trace)
+	}
+	return err
+}
 
-	if !config.ProviderInstance.IsClientScopeAccessModelEnabled() {
+func authenticateMissingUser(c contract.RequestContext, apiClient contract.ApiClientInterface, requirement constants.UserRequirement) *contract.AuthError {
+	if _, ok := c.Get(constants.ApiUser); ok || constants.UserRequirementScope == requirement {
+		return nil
+	}
+	if constants.UserRequirementOptional == requirement && !hasUserCredentials(c) {
 		return nil
 	}
+	// the client scope has been checked already (any user requirement of the client scope has been handled too)
+	return authenticateOnBehalf(c, apiClient, constants.ScopeAccessibilityAccessible, requirement, "")
+}
+
+func authenticate(c contract.RequestContext, requirement constants.UserRequirement, scopeKey string) *contract.AuthError {
+	configProvider := config.GetProvider(c)
+	apiClient, err := authenticateApiClient(c)
+	if nil != err {
+		return err
+	}
 
-	if config.ProviderInstance.IsClientFUPEnabled() {
-		clientFUPChecker := config.ProviderInstance.GetClientFUPChecker()
-		fupKey := apiClient.GetClientId()
-		if apiClient.GetCurrentApiKey() != nil {
-			// use API key as a part of the FUP key if additional API key is provided (it might have different limits)
-			fupKey = fmt.Sprintf("%s:%s", fupKey, apiClient.GetCurrentApiKey().GetKey())
+	c.Set(constants.ApiClient, apiClient)
+	principal := newPrincipal(c, apiClient)
+	c.Set(constants.Principal, principal)
+	setTraceSubject(c, constants.ApiClient)
+	tenantId := contract.GetTenantId(apiClient)
+	if "" != tenantId {
+		c.Set(constants.TenantId, tenantId)
+	}
+
+	if !configProvider.IsClientScopeAccessModelEnabled() {
+		if constants.UserRequirementScope == requirement || (constants.UserRequirementOptional == requirement && !hasUserCredentials(c)) {
+			return nil
 		}
-		fupLimits := clientFUPChecker.Check(apiClient.GetFUPScope(), c, fupKey)
+		return authenticateOnBehalf(c, apiClient, constants.ScopeAccessibilityAccessible, requirement, scopeKey)
+	}
+
+	if configProvider.IsClientFUPEnabled() && !isFUPExempt(c) {
+		clientFUPChecker := configProvider.GetClientFUPChecker()
+		fupLimits := clientFUPChecker.Check(apiClient.GetFUPScope(), c, fup.GetClientKey(apiClient))
 		if nil != fupLimits.Error {
 			return fupLimits.Error
 		}
 		if fupLimits.Accessible == constants.ScopeAccessibilityForbidden {
-			c.Header(constants.RetryAfterHeader, fmt.Sprintf("%d", fupLimits.GetRetryAfter()))
-			return contract.NewFUPError(contract.RequestLimitDepleted, fupLimits.Limits)
+			err = deny(c, contract.NewFUPError(fupLimits.GetErrorCode(), fupLimits.Limits))
+			if nil != err {
+				c.Header(constants.RetryAfterHeader, fmt.Sprintf("%d", fupLimits.GetRetryAfter()))
+				fup.SetRateLimitHeaders(c, "client", fupLimits)
+				return err
+			}
 		}
-		header := fupLimits.GetLimitsHeader()
-		if "" != header {
-			c.Header(constants.ClientFUPLimitsHeader, header)
+		principal.ClientFUPLimits = fupLimits.Limits
+		fup.SetLimitsHeaders(c, "client", constants.ClientFUPLimitsHeader, fupLimits)
+	}
+	if configProvider.IsOrganisationFUPEnabled() && !isFUPExempt(c) {
+		err = checkOrganisationFUP(c)
+		if nil != err {
+			return err
 		}
+		setTraceSubject(c, constants.ApiClient)
 	}
-	clientAccessScopeChecker := config.ProviderInstance.GetClientScopeAccessChecker()
-	scopeAccessibility := clientAccessScopeChecker.Check(apiClient.GetClientScope(), c)
+	clientAccessScopeChecker := configProvider.GetClientScopeAccessChecker()
+	scopeAccessibility := checkAccessScope(c, clientAccessScopeChecker, apiClient.GetClientScope(), scopeKey)
 
-	if constants.ScopeAccessibilityForbidden == scopeAccessibility {
-		return contract.NewAuthError(contract.ClientForbidden, nil)
+	c.Set(constants.EffectiveScope, apiClient.GetClientScope())
+
+	withUserCredentials := hasUserCredentials(c)
+	if constants.ScopeAccessibilityForbidden == scopeAccessibility && (!withUserCredentials || !canUserScopeGrantAccess(configProvider)) {
+		err = deny(c, contract.NewAuthError(contract.ClientForbidden, nil))
+		if nil != err || !withUserCredentials {
+			return err
+		}
+		// report-only mode: the client denial is recorded already, so only validate the user (and their own scope)
+		scopeAccessibility = constants.ScopeAccessibilityOnBehalf
 	}
 
 	// if user credentials are provided, validate them even if not required by the client-level access scope
-	if constants.ScopeAccessibilityOnBehalf == scopeAccessibility || c.Request.Header.Get(constants.ApiUserTokenHeader) != "" {
-		return authenticateOnBehalf(c)
+	if constants.ScopeAccessibilityOnBehalf == scopeAccessibility || withUserCredentials || constants.UserRequirementRequired == requirement {
+		return authenticateOnBehalf(c, apiClient, scopeAccessibility, requirement, scopeKey)
 	}
 
 	if constants.ScopeAccessibilityAccessible == scopeAccessibility {
@@ -282,3 +692,62 @@ func Authenticate(c *gin.Context) *contract.AuthError {
 
 	return contract.NewAuthError(contract.UnknownScopeAccessibility, map[string]constants.ScopeAccessibility{"scope": scopeAccessibility})
 }
+
+// CheckScope checks the access to key (using `|` as a hierarchy separator) against the effective scope of the authenticated request
+// (the client scope combined with the user scope, see ScopeCombination); the request must be authenticated already
+func CheckScope(c contract.RequestContext, key string) *contract.AuthError {
+	value, ok := c.Get(constants.ApiClient)
+	if !ok {
+		return contract.NewAuthError(contract.Unauthorized, nil)
+	}
+	apiClient := value.(contract.ApiClientInterface)
+	apiUser, withUser := c.Get(constants.ApiUser)
+
+	scope := apiClient.GetClientScope()
+	if effectiveScope, ok := c.Get(constants.EffectiveScope); ok {
+		scope = effectiveScope.(*contract.AccessScope)
+	} else if withUser {
+		scope = combineScopes(config.GetProvider(c).GetScopeCombination(), scope, apiUser.(contract.ApiUserInterface).GetUserScope())
+	}
+
+	accessibility := constants.ScopeAccessibilityForbidden
+	if nil != scope {
+		accessibility = scope.CheckAccessibility(key, "|", c)
+	}
+	switch {
+	case constants.ScopeAccessibilityAccessible == accessibility:
+		return nil
+	case constants.ScopeAccessibilityOnBehalf == accessibility && withUser:
+		return nil
+	case constants.ScopeAccessibilityOnBehalf == accessibility:
+		return denyScope(c, contract.NewAuthError(contract.UserTokenRequired, nil))
+	case withUser:
+		return denyScope(c, contract.NewAuthError(contract.UserForbidden, nil))
+	}
+	return denyScope(c, contract.NewAuthError(contract.ClientForbidden, nil))
+}
+
+// denyScope is deny for checks made after the authentication (the would-have-denied event is dispatched right away)
+func denyScope(c contract.RequestContext, err *contract.AuthError) *contract.AuthError {
+	if nil != deny(c, err) {
+		return err
+	}
+	reportWouldHaveDenied(c, []contract.AuthError{*err}, contract.GetDecisionTrace(c))
+	return nil
+}
+
+// RespondWithError writes the error response (`{"code": ..., "message": ..., "payload": ...}` with the status of err)
+// and dispatches the AuthenticationFailedEvent; the auth middlewares call it when the authentication fails
+func RespondWithError(c contract.RequestContext, err *contract.AuthError) {
+	errorResponse := gin.H{
+		"code":    err.Code,
+		"message": err.Err.Error(),
+		"payload": err.Payload,
+	}
+	c.JSON(err.Status, errorResponse)
+	events.GetEventHub().DispatchAsync(&contract.AuthenticationFailedEvent{
+		Error:    *err,
+		Context:  c,
+		Response: errorResponse,
+	})
+}
@@ -0,0 +1,444 @@
+package security
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/entity"
+	"github.com/wernerdweight/api-auth-go/v2/auth/provider"
+	"github.com/wernerdweight/api-auth-go/v2/auth/signer"
+	"github.com/wernerdweight/events-go"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingFUPChecker records the checked keys and forbids the access to the keys in forbidden
+type recordingFUPChecker struct {
+	keys      []string
+	forbidden map[string]bool
+}
+
+func (ch *recordingFUPChecker) Check(scope *contract.FUPScope, c contract.RequestContext, key string) contract.FUPScopeLimits {
+	ch.keys = append(ch.keys, key)
+	if ch.forbidden[key] {
+		return contract.FUPScopeLimits{
+			Accessible: constants.ScopeAccessibilityForbidden,
+			Limits:     map[constants.Period]contract.FUPLimits{constants.PeriodDaily: {Limit: 1, Used: 1, Period: constants.PeriodDaily, ResetAt: time.Now().Add(time.Hour)}},
+		}
+	}
+	return contract.FUPScopeLimits{Accessible: constants.ScopeAccessibilityAccessible}
+}
+
+func newTestConfig(clients []entity.MemoryApiClient, users []entity.MemoryApiUser) contract.Config {
+	useScopeAccessModel := true
+	return contract.Config{
+		Client: contract.ClientConfig{
+			Provider:            provider.NewMemoryApiClientProvider(clients),
+			UseScopeAccessModel: &useScopeAccessModel,
+		},
+		User: &contract.UserConfig{
+			Provider:     provider.NewMemoryApiUserProvider(users),
+			TokenFactory: func() contract.ApiUserTokenInterface { return &entity.MemoryApiUserToken{} },
+		},
+	}
+}
+
+func newTestUser(login string, tenantId string, userScope *contract.AccessScope) entity.MemoryApiUser {
+	return entity.MemoryApiUser{
+		Id:             login,
+		Login:          login,
+		AccessScope:    userScope,
+		OrganisationId: tenantId,
+		CurrentToken:   &entity.MemoryApiUserToken{Token: login, ExpirationDate: time.Now().Add(time.Hour)},
+	}
+}
+
+func newTestContext(configProvider *config.Provider, method string, path string, clientId string, userToken string) *contract.HttpContext {
+	request := httptest.NewRequest(method, path, nil)
+	request.Header.Set(constants.ClientIdHeader, clientId)
+	request.Header.Set(constants.ClientSecretHeader, "secret")
+	if "" != userToken {
+		request.Header.Set(constants.ApiUserTokenHeader, userToken)
+	}
+	c := contract.NewHttpContext(httptest.NewRecorder(), request)
+	configProvider.BindTo(c)
+	return c
+}
+
+func TestCheckTenant(t *testing.T) {
+	configProvider := config.NewProvider(newTestConfig(nil, nil))
+	user := newTestUser("user", "acme", nil)
+	other := newTestUser("other", "other", nil)
+	withoutTenant := newTestUser("nobody", "", nil)
+
+	// users of a different organisation than the client are rejected
+	c := newTestContext(configProvider, http.MethodGet, "/orders", "client", "")
+	c.Set(constants.TenantId, "acme")
+	assert.Nil(t, checkTenant(c, &user))
+	err := checkTenant(c, &other)
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.TenantMismatch, err.Code)
+	assert.NotNil(t, checkTenant(c, &withoutTenant))
+
+	// clients without an organisation take over the organisation of the user
+	c = newTestContext(configProvider, http.MethodGet, "/orders", "client", "")
+	assert.Nil(t, checkTenant(c, &user))
+	assert.Equal(t, "acme", contract.GetString(c, constants.TenantId))
+}
+
+func TestAuthenticate_TenantMismatch(t *testing.T) {
+	configProvider := config.NewProvider(newTestConfig(
+		[]entity.MemoryApiClient{{Id: "client", Secret: "secret", OrganisationId: "acme", AccessScope: &contract.AccessScope{"/orders": true}}},
+		[]entity.MemoryApiUser{newTestUser("user", "acme", nil), newTestUser("other", "other", nil)},
+	))
+
+	c := newTestContext(configProvider, http.MethodGet, "/orders", "client", "user")
+	assert.Nil(t, Authenticate(c))
+	assert.Equal(t, "acme", contract.GetPrincipal(c).TenantId)
+
+	c = newTestContext(configProvider, http.MethodGet, "/orders", "client", "other")
+	err := Authenticate(c)
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.TenantMismatch, err.Code)
+}
+
+func TestAuthenticate_OrganisationFUP(t *testing.T) {
+	cfg := newTestConfig(
+		[]entity.MemoryApiClient{
+			{Id: "client", Secret: "secret", OrganisationId: "acme", AccessScope: &contract.AccessScope{"/orders": true}},
+			{Id: "shared", Secret: "secret", AccessScope: &contract.AccessScope{"/orders": true}},
+		},
+		[]entity.MemoryApiUser{newTestUser("user", "acme", nil), newTestUser("nobody", "", nil)},
+	)
+	organisationFUPChecker := &recordingFUPChecker{forbidden: map[string]bool{"organisation:acme": true}}
+	cfg.Organisation = &contract.OrganisationConfig{
+		Provider:   provider.NewMemoryApiOrganisationProvider([]entity.MemoryApiOrganisation{{Id: "acme", Name: "ACME"}}),
+		FUPChecker: organisationFUPChecker,
+	}
+	configProvider := config.NewProvider(cfg)
+
+	// the organisation of the client is checked
+	err := Authenticate(newTestContext(configProvider, http.MethodGet, "/orders", "client", ""))
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.RequestLimitDepleted, err.Code)
+	assert.Equal(t, []string{"organisation:acme"}, organisationFUPChecker.keys)
+
+	// the organisation of the user is checked if the client has no organisation
+	organisationFUPChecker.keys = nil
+	err = Authenticate(newTestContext(configProvider, http.MethodGet, "/orders", "shared", "user"))
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.RequestLimitDepleted, err.Code)
+	assert.Equal(t, []string{"organisation:acme"}, organisationFUPChecker.keys)
+
+	// no organisation, no organisation limits
+	organisationFUPChecker.keys = nil
+	assert.Nil(t, Authenticate(newTestContext(configProvider, http.MethodGet, "/orders", "shared", "")))
+	assert.Nil(t, Authenticate(newTestContext(configProvider, http.MethodGet, "/orders", "shared", "nobody")))
+	assert.Nil(t, organisationFUPChecker.keys)
+}
+
+func TestAuthenticate_FUPExempt(t *testing.T) {
+	scope := &contract.AccessScope{"/orders": true, "/fup/usage": true}
+	cfg := newTestConfig(
+		[]entity.MemoryApiClient{{Id: "client", Secret: "secret", OrganisationId: "acme", AccessScope: scope}},
+		[]entity.MemoryApiUser{newTestUser("user", "acme", scope)},
+	)
+	useScopeAccessModel := true
+	cfg.User.UseScopeAccessModel = &useScopeAccessModel
+	fupChecker := &recordingFUPChecker{forbidden: map[string]bool{"client": true, "user": true, "organisation:acme": true}}
+	cfg.Client.FUPChecker = fupChecker
+	cfg.User.FUPChecker = fupChecker
+	cfg.Organisation = &contract.OrganisationConfig{
+		Provider:   provider.NewMemoryApiOrganisationProvider([]entity.MemoryApiOrganisation{{Id: "acme", Name: "ACME"}}),
+		FUPChecker: fupChecker,
+	}
+	configProvider := config.NewProvider(cfg)
+
+	err := Authenticate(newTestContext(configProvider, http.MethodGet, "/orders", "client", "user"))
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.RequestLimitDepleted, err.Code)
+
+	// the FUP usage route is neither counted nor throttled by default
+	fupChecker.keys = nil
+	assert.Nil(t, Authenticate(newTestContext(configProvider, http.MethodGet, "/fup/usage", "client", "user")))
+	assert.Nil(t, fupChecker.keys)
+
+	// the exemption can be turned off
+	cfg.FUP = &contract.FUPConfig{ExemptHandlers: []string{}}
+	configProvider = config.NewProvider(cfg)
+	err = Authenticate(newTestContext(configProvider, http.MethodGet, "/fup/usage", "client", "user"))
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"client"}, fupChecker.keys)
+}
+
+func TestAuthenticate_ScopeCombination(t *testing.T) {
+	tests := []struct {
+		name        string
+		combination constants.ScopeCombination
+		path        string
+		wantErr     contract.AuthErrorCode
+		wantScope   *contract.AccessScope
+	}{
+		{
+			name:        "Intersection: allowed by both scopes",
+			combination: constants.ScopeCombinationIntersection,
+			path:        "/orders",
+			wantScope:   &contract.AccessScope{"/orders": true},
+		},
+		{
+			name:        "Intersection: forbidden by the user scope",
+			combination: constants.ScopeCombinationIntersection,
+			path:        "/reports",
+			wantErr:     contract.UserForbidden,
+		},
+		{
+			name:        "Intersection: forbidden by the client scope",
+			combination: constants.ScopeCombinationIntersection,
+			path:        "/invoices",
+			wantErr:     contract.ClientForbidden,
+		},
+		{
+			name:        "Union: allowed by the client scope only",
+			combination: constants.ScopeCombinationUnion,
+			path:        "/reports",
+			wantScope:   &contract.AccessScope{"/orders": true, "/reports": true, "/invoices": true},
+		},
+		{
+			name:        "Union: allowed by the user scope only",
+			combination: constants.ScopeCombinationUnion,
+			path:        "/invoices",
+			wantScope:   &contract.AccessScope{"/orders": true, "/reports": true, "/invoices": true},
+		},
+		{
+			name:        "Union: forbidden by both scopes",
+			combination: constants.ScopeCombinationUnion,
+			path:        "/admin",
+			wantErr:     contract.UserForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useScopeAccessModel := true
+			combination := tt.combination
+			cfg := newTestConfig(
+				[]entity.MemoryApiClient{{Id: "client", Secret: "secret", AccessScope: &contract.AccessScope{"/orders": true, "/reports": true}}},
+				[]entity.MemoryApiUser{newTestUser("user", "", &contract.AccessScope{"/orders": true, "/invoices": true})},
+			)
+			cfg.User.UseScopeAccessModel = &useScopeAccessModel
+			cfg.User.ScopeCombination = &combination
+			c := newTestContext(config.NewProvider(cfg), http.MethodGet, tt.path, "client", "user")
+
+			err := Authenticate(c)
+			if contract.Unknown != tt.wantErr {
+				assert.NotNil(t, err)
+				assert.Equal(t, tt.wantErr, err.Code)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantScope, contract.GetPrincipal(c).EffectiveScope)
+		})
+	}
+}
+
+type wouldHaveDeniedSubscriber struct {
+	events chan *contract.WouldHaveDeniedEvent
+}
+
+func (s *wouldHaveDeniedSubscriber) Handle(event events.Event[events.EventPayload]) error {
+	select {
+	case s.events <- event.GetPayload().(*contract.WouldHaveDeniedEvent):
+	default:
+	}
+	return nil
+}
+
+func (s *wouldHaveDeniedSubscriber) GetKey() events.EventKey {
+	return contract.WouldHaveDeniedEventKey
+}
+
+func (s *wouldHaveDeniedSubscriber) GetPriority() int {
+	return 0
+}
+
+func TestAuthenticate_ReportOnly(t *testing.T) {
+	subscriber := &wouldHaveDeniedSubscriber{events: make(chan *contract.WouldHaveDeniedEvent, 1)}
+	events.GetEventHub().Subscribe(subscriber)
+	receive := func() *contract.WouldHaveDeniedEvent {
+		select {
+		case event := <-subscriber.events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("WouldHaveDeniedEvent was not dispatched")
+		}
+		return nil
+	}
+
+	reportOnly := true
+	cfg := newTestConfig(
+		[]entity.MemoryApiClient{
+			{Id: "client", Secret: "secret", AccessScope: &contract.AccessScope{"/orders": true}},
+			{Id: "shadow", Secret: "secret", AccessScope: &contract.AccessScope{"/orders": true}, ReportOnly: true},
+		},
+		nil,
+	)
+	clientFUPChecker := &recordingFUPChecker{forbidden: map[string]bool{"shadow": true}}
+	cfg.Client.FUPChecker = clientFUPChecker
+
+	// the client in report-only mode is let through, the reasons are recorded and dispatched
+	configProvider := config.NewProvider(cfg)
+	c := newTestContext(configProvider, http.MethodGet, "/admin", "shadow", "")
+	assert.Nil(t, Authenticate(c))
+	value, ok := c.Get(constants.WouldHaveDenied)
+	assert.True(t, ok)
+	denials := value.([]contract.AuthError)
+	assert.Len(t, denials, 2)
+	assert.Equal(t, contract.RequestLimitDepleted, denials[0].Code)
+	assert.Equal(t, contract.ClientForbidden, denials[1].Code)
+	event := receive()
+	assert.Equal(t, "shadow", event.ApiClient.GetClientId())
+	assert.Equal(t, denials, event.Errors)
+
+	// other clients are enforced
+	c = newTestContext(configProvider, http.MethodGet, "/admin", "client", "")
+	err := Authenticate(c)
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.ClientForbidden, err.Code)
+	_, ok = c.Get(constants.WouldHaveDenied)
+	assert.False(t, ok)
+
+	// allowed requests are not reported
+	c = newTestContext(configProvider, http.MethodGet, "/orders", "client", "")
+	assert.Nil(t, Authenticate(c))
+	_, ok = c.Get(constants.WouldHaveDenied)
+	assert.False(t, ok)
+
+	// all clients are let through in the global report-only mode
+	cfg.ReportOnly = &reportOnly
+	configProvider = config.NewProvider(cfg)
+	c = newTestContext(configProvider, http.MethodGet, "/admin", "client", "")
+	assert.Nil(t, Authenticate(c))
+	event = receive()
+	assert.Equal(t, "client", event.ApiClient.GetClientId())
+	assert.Equal(t, contract.ClientForbidden, event.Errors[0].Code)
+
+	// the authentication itself is always enforced
+	request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	request.Header.Set(constants.ClientIdHeader, "client")
+	request.Header.Set(constants.ClientSecretHeader, "invalid")
+	c = contract.NewHttpContext(httptest.NewRecorder(), request)
+	configProvider.BindTo(c)
+	err = Authenticate(c)
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.ClientNotFound, err.Code)
+}
+
+func TestAuthenticate_TraceHeader(t *testing.T) {
+	enabled := true
+	cfg := newTestConfig(
+		[]entity.MemoryApiClient{
+			{Id: "client", Secret: "secret", AccessScope: &contract.AccessScope{"/orders": true}},
+			{Id: "admin", Secret: "secret", AccessScope: &contract.AccessScope{"/orders": true}},
+		},
+		nil,
+	)
+	cfg.Trace = &contract.TraceConfig{Header: &enabled, HeaderClients: []string{"admin"}}
+	configProvider := config.NewProvider(cfg)
+	authenticate := func(clientId string, debug bool) http.Header {
+		c := newTestContext(configProvider, http.MethodGet, "/orders", clientId, "")
+		if debug {
+			c.GetRequest().Header.Set(constants.DebugHeader, "1")
+		}
+		assert.Nil(t, Authenticate(c))
+		return c.GetWriter().Header()
+	}
+
+	// the trace is only returned to privileged clients requesting it
+	assert.Contains(t, authenticate("admin", true).Get(constants.DecisionTraceHeader), `"key":"/orders"`)
+	assert.Empty(t, authenticate("admin", false).Get(constants.DecisionTraceHeader))
+	assert.Empty(t, authenticate("client", true).Get(constants.DecisionTraceHeader))
+
+	// the trace is truncated to the maximum length
+	maxLength := 40
+	cfg.Trace.HeaderMaxLength = &maxLength
+	configProvider = config.NewProvider(cfg)
+	assert.Equal(t, `{"checks":[],"truncated":true}`, authenticate("admin", true).Get(constants.DecisionTraceHeader))
+}
+
+// idAndSecretClientProvider only implements the mandatory methods of the client provider (no ProvideByClientId)
+type idAndSecretClientProvider struct {
+	contract.ApiClientProviderInterface[contract.ApiClientInterface]
+}
+
+func TestAuthenticate_SignedUrl(t *testing.T) {
+	enabled := true
+	signedUrlKey := "key"
+	cfg := newTestConfig(
+		[]entity.MemoryApiClient{{Id: "client", Secret: "secret", AccessScope: &contract.AccessScope{"/downloads/42": "on-behalf"}}},
+		[]entity.MemoryApiUser{newTestUser("user", "", nil)},
+	)
+	cfg.Client.SignedUrlKey = &signedUrlKey
+	cfg.Mode = &contract.ModesConfig{SignedUrl: &enabled}
+	configProvider := config.NewProvider(cfg)
+	urlSigner := signer.NewUrlSignerFor(configProvider)
+	sign := func(login string, method string, expiresAt time.Time) string {
+		signedUrl, err := urlSigner.Sign("/downloads/42?format=pdf", signer.Claims{ClientId: "client", Login: login, Method: method, ExpiresAt: expiresAt})
+		assert.Nil(t, err)
+		return signedUrl
+	}
+	newContext := func(method string, target string) *contract.HttpContext {
+		c := contract.NewHttpContext(httptest.NewRecorder(), httptest.NewRequest(method, target, nil))
+		configProvider.BindTo(c)
+		return c
+	}
+	expiresAt := time.Now().Add(time.Hour)
+
+	// a URL signed for the user authenticates both the client and the user
+	c := newContext(http.MethodGet, sign("user", http.MethodGet, expiresAt))
+	assert.Nil(t, Authenticate(c))
+	principal := contract.GetPrincipal(c)
+	assert.Equal(t, constants.AuthenticationMethodSignedUrl, principal.Method)
+	assert.Equal(t, "client", principal.ApiClient.GetClientId())
+	assert.Equal(t, "user", principal.ApiUser.GetLogin())
+	claims, ok := c.Get(constants.SignedUrlClaims)
+	assert.True(t, ok)
+	assert.Equal(t, "user", claims.(*signer.Claims).Login)
+
+	// a URL signed for the client only doesn't satisfy the on-behalf scope
+	err := Authenticate(newContext(http.MethodGet, sign("", http.MethodGet, expiresAt)))
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.UserTokenRequired, err.Code)
+
+	// expired URLs are rejected
+	err = Authenticate(newContext(http.MethodGet, sign("user", http.MethodGet, time.Now().Add(-time.Minute))))
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.SignedUrlExpired, err.Code)
+
+	// tampered URLs are rejected
+	signedUrl := sign("user", http.MethodGet, expiresAt)
+	for _, tampered := range []string{
+		strings.Replace(signedUrl, "/downloads/42", "/downloads/43", 1),
+		strings.Replace(signedUrl, "format=pdf", "format=csv", 1),
+		strings.Replace(signedUrl, "auth_user=user", "auth_user="+url.QueryEscape("admin"), 1),
+	} {
+		err = Authenticate(newContext(http.MethodGet, tampered))
+		assert.NotNil(t, err)
+		assert.Equal(t, contract.InvalidSignedUrl, err.Code)
+	}
+
+	// the URL is only valid for the signed method
+	err = Authenticate(newContext(http.MethodDelete, signedUrl))
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.InvalidSignedUrl, err.Code)
+
+	// client providers that can't load clients by id don't support signed URLs
+	cfg.Client.Provider = idAndSecretClientProvider{provider.NewMemoryApiClientProvider(nil)}
+	configProvider = config.NewProvider(cfg)
+	err = Authenticate(newContext(http.MethodGet, signedUrl))
+	assert.NotNil(t, err)
+	assert.Equal(t, contract.ClientByIdNotSupported, err.Code)
+}
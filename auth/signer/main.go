@@ -0,0 +1,125 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Claims holds the identity and restrictions carried by a signed URL
+type Claims struct {
+	ClientId  string
+	Login     string
+	Method    string
+	ExpiresAt time.Time
+}
+
+// UrlSigner signs and verifies URLs using HMAC-SHA256 and a server-side key
+type UrlSigner struct {
+	Key []byte
+}
+
+func (s UrlSigner) computeSignature(method string, path string, query url.Values) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(strings.ToUpper(method)))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(path))
+	mac.Write([]byte("\n"))
+	// url.Values.Encode sorts the parameters by key, so the payload is canonical
+	mac.Write([]byte(query.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign returns rawUrl extended with the query parameters that carry the claims and their signature
+func (s UrlSigner) Sign(rawUrl string, claims Claims) (string, *contract.AuthError) {
+	if 0 == len(s.Key) {
+		return "", contract.NewInternalError(contract.SignedUrlKeyNotConfigured, nil)
+	}
+	u, err := url.Parse(rawUrl)
+	if nil != err {
+		return "", contract.NewInternalError(contract.InvalidSignedUrl, map[string]string{"details": err.Error()})
+	}
+	query := u.Query()
+	query.Del(constants.SignedUrlSignatureParam)
+	query.Set(constants.SignedUrlClientIdParam, claims.ClientId)
+	query.Del(constants.SignedUrlUserParam)
+	if "" != claims.Login {
+		query.Set(constants.SignedUrlUserParam, claims.Login)
+	}
+	query.Set(constants.SignedUrlExpiresParam, strconv.FormatInt(claims.ExpiresAt.Unix(), 10))
+	query.Set(constants.SignedUrlMethodParam, strings.ToUpper(claims.Method))
+	query.Set(constants.SignedUrlSignatureParam, s.computeSignature(claims.Method, u.Path, query))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Verify checks the signature, expiry and method of the given URL and returns the claims it carries
+func (s UrlSigner) Verify(u *url.URL, method string) (*Claims, *contract.AuthError) {
+	if 0 == len(s.Key) {
+		return nil, contract.NewInternalError(contract.SignedUrlKeyNotConfigured, nil)
+	}
+	if nil == u {
+		return nil, contract.NewAuthError(contract.InvalidSignedUrl, nil)
+	}
+	query := u.Query()
+	signature := query.Get(constants.SignedUrlSignatureParam)
+	clientId := query.Get(constants.SignedUrlClientIdParam)
+	expires := query.Get(constants.SignedUrlExpiresParam)
+	signedMethod := query.Get(constants.SignedUrlMethodParam)
+	if "" == signature || "" == clientId || "" == expires || "" == signedMethod {
+		return nil, contract.NewAuthError(contract.InvalidSignedUrl, nil)
+	}
+	query.Del(constants.SignedUrlSignatureParam)
+	expected := s.computeSignature(signedMethod, u.Path, query)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, contract.NewAuthError(contract.InvalidSignedUrl, nil)
+	}
+	timestamp, err := strconv.ParseInt(expires, 10, 64)
+	if nil != err {
+		return nil, contract.NewAuthError(contract.InvalidSignedUrl, map[string]string{"details": err.Error()})
+	}
+	expiresAt := time.Unix(timestamp, 0)
+	if expiresAt.Before(time.Now()) {
+		return nil, contract.NewAuthError(contract.SignedUrlExpired, map[string]time.Time{"expiredAt": expiresAt})
+	}
+	if !strings.EqualFold(signedMethod, method) {
+		return nil, contract.NewAuthError(contract.InvalidSignedUrl, map[string]string{"details": "method not allowed"})
+	}
+	return &Claims{
+		ClientId:  clientId,
+		Login:     query.Get(constants.SignedUrlUserParam),
+		Method:    signedMethod,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// NewUrlSigner returns a signer using the signing key from the default configuration (see config.ProviderInstance)
+func NewUrlSigner() UrlSigner {
+	return NewUrlSignerFor(config.ProviderInstance)
+}
+
+// NewUrlSignerFor returns a signer using the signing key from the given configuration instance
+func NewUrlSignerFor(configProvider *config.Provider) UrlSigner {
+	return UrlSigner{Key: []byte(configProvider.GetSignedUrlKey())}
+}
+
+// SignUrl signs rawUrl for the given client (and optionally user) using the configured key;
+// the resulting URL is only valid for the given method until expiresAt
+func SignUrl(rawUrl string, method string, apiClient contract.ApiClientInterface, apiUser contract.ApiUserInterface, expiresAt time.Time) (string, *contract.AuthError) {
+	claims := Claims{
+		ClientId:  apiClient.GetClientId(),
+		Method:    method,
+		ExpiresAt: expiresAt,
+	}
+	if nil != apiUser {
+		claims.Login = apiUser.GetLogin()
+	}
+	return NewUrlSigner().Sign(rawUrl, claims)
+}
@@ -0,0 +1,127 @@
+package signer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUrlSigner_SignAndVerify(t *testing.T) {
+	assertion := assert.New(t)
+	s := UrlSigner{Key: []byte("test-key")}
+
+	signed, err := s.Sign("https://api.tld/downloads/42?format=pdf", Claims{
+		ClientId:  "client-id",
+		Login:     "user@domain.tld",
+		Method:    "get",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assertion.Nil(err)
+
+	u, _ := url.Parse(signed)
+	assertion.Equal("pdf", u.Query().Get("format"))
+	assertion.NotEmpty(u.Query().Get(constants.SignedUrlSignatureParam))
+
+	claims, err := s.Verify(u, "GET")
+	assertion.Nil(err)
+	assertion.Equal("client-id", claims.ClientId)
+	assertion.Equal("user@domain.tld", claims.Login)
+	assertion.Equal("GET", claims.Method)
+}
+
+func TestUrlSigner_Verify(t *testing.T) {
+	s := UrlSigner{Key: []byte("test-key")}
+	sign := func(rawUrl string, method string, expiresAt time.Time) string {
+		signed, _ := s.Sign(rawUrl, Claims{ClientId: "client-id", Method: method, ExpiresAt: expiresAt})
+		return signed
+	}
+	valid := sign("https://api.tld/downloads/42?format=pdf", "GET", time.Now().Add(time.Hour))
+	tests := []struct {
+		name   string
+		signer UrlSigner
+		rawUrl string
+		method string
+		want   contract.AuthErrorCode
+	}{
+		{
+			name:   "Valid",
+			signer: s,
+			rawUrl: valid,
+			method: "GET",
+			want:   contract.Unknown,
+		},
+		{
+			name:   "Missing signature",
+			signer: s,
+			rawUrl: "https://api.tld/downloads/42?format=pdf",
+			method: "GET",
+			want:   contract.InvalidSignedUrl,
+		},
+		{
+			name:   "Tampered query",
+			signer: s,
+			rawUrl: valid + "&format=csv",
+			method: "GET",
+			want:   contract.InvalidSignedUrl,
+		},
+		{
+			name:   "Tampered path",
+			signer: s,
+			rawUrl: strings.Replace(valid, "/downloads/42", "/downloads/43", 1),
+			method: "GET",
+			want:   contract.InvalidSignedUrl,
+		},
+		{
+			name:   "Different key",
+			signer: UrlSigner{Key: []byte("other-key")},
+			rawUrl: valid,
+			method: "GET",
+			want:   contract.InvalidSignedUrl,
+		},
+		{
+			name:   "Different method",
+			signer: s,
+			rawUrl: valid,
+			method: "DELETE",
+			want:   contract.InvalidSignedUrl,
+		},
+		{
+			name:   "Expired",
+			signer: s,
+			rawUrl: sign("https://api.tld/downloads/42", "GET", time.Now().Add(-time.Minute)),
+			method: "GET",
+			want:   contract.SignedUrlExpired,
+		},
+		{
+			name:   "No key",
+			signer: UrlSigner{},
+			rawUrl: valid,
+			method: "GET",
+			want:   contract.SignedUrlKeyNotConfigured,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.rawUrl)
+			_, err := tt.signer.Verify(u, tt.method)
+			if contract.Unknown == tt.want {
+				assert.Nil(t, err)
+				return
+			}
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.want, err.Code)
+			}
+		})
+	}
+}
+
+func TestUrlSigner_Sign_NoKey(t *testing.T) {
+	_, err := UrlSigner{}.Sign("https://api.tld/", Claims{ClientId: "client-id", Method: "GET", ExpiresAt: time.Now()})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, contract.SignedUrlKeyNotConfigured, err.Code)
+	}
+}
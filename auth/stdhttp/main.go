@@ -0,0 +1,143 @@
+// Package stdhttp adapts the auth middleware and routes to net/http (http.Handler middlewares as used by chi or http.ServeMux).
+// The requests are wrapped in a contract.HttpContext, so all providers, checkers, FUP checkers and the cache work as with gin.
+package stdhttp
+
+import (
+	"context"
+	"github.com/wernerdweight/api-auth-go/v2/auth/config"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/fup"
+	"github.com/wernerdweight/api-auth-go/v2/auth/routes"
+	"github.com/wernerdweight/api-auth-go/v2/auth/security"
+	"net/http"
+	"strings"
+)
+
+// authenticate runs the authentication pipeline of the gin middleware (see auth.MiddlewareFor) and calls next if the request is authenticated
+func authenticate(configProvider *config.Provider, c *contract.HttpContext, next func()) {
+	configProvider.BindTo(c)
+	if !configProvider.IsAuthenticationEnabled() || (configProvider.ShouldExcludeOptionsRequests() && http.MethodOptions == c.GetRequest().Method) {
+		next()
+		return
+	}
+
+	err := security.Authenticate(c)
+	if nil != err {
+		security.RespondWithError(c, err)
+		fup.Settle(c)
+		return
+	}
+
+	next()
+	fup.Settle(c)
+}
+
+// Middleware returns a net/http middleware that authenticates requests based on the given configuration instance (see auth.New);
+// the authenticated client and user are available through GetApiClient and GetApiUser
+// NOTE: the route-based checkers (e.g. checker.RouteAccessScopeChecker) need the route template, which is only known to the routes (see Routes)
+func Middleware(configProvider *config.Provider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := contract.NewHttpContext(w, r)
+			authenticate(configProvider, c, func() {
+				next.ServeHTTP(c.GetWriter(), c.Request())
+			})
+		})
+	}
+}
+
+// matchRoute returns the values of the parameters of the route template (e.g. `/registration/confirm/:token`) if path matches it
+func matchRoute(template string, path string) (map[string]string, bool) {
+	templateSegments := strings.Split(strings.Trim(template, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(templateSegments) != len(pathSegments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, ":") {
+			if "" == pathSegments[i] {
+				return nil, false
+			}
+			params[segment[1:]] = pathSegments[i]
+			continue
+		}
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// serveRoutes returns a http.Handler serving the routes (authenticated by the auth middleware; unknown routes are not found)
+func serveRoutes(configProvider *config.Provider, authRoutes []routes.Route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, route := range authRoutes {
+			params, ok := matchRoute(route.Path, r.URL.Path)
+			if !ok || route.Method != r.Method {
+				continue
+			}
+			c := contract.NewHttpContext(w, r)
+			c.Route = route.Path
+			c.Params = params
+			authenticate(configProvider, c, func() {
+				route.Handler(c)
+			})
+			return
+		}
+		http.NotFound(w, r)
+	})
+}
+
+// Routes returns a http.Handler serving the auth routes (/authenticate, /registration/*, /resetting/*, /token/generate, /fup/usage)
+// of the given configuration instance (the requests are authenticated by the auth middleware)
+func Routes(configProvider *config.Provider) http.Handler {
+	return serveRoutes(configProvider, routes.GetRoutes(configProvider))
+}
+
+// FUPAdminRoutes returns a http.Handler serving the FUP administration routes (see routes.RegisterFUPAdmin)
+// of the given configuration instance; mount it behind the authorization of your administrators
+func FUPAdminRoutes(configProvider *config.Provider) http.Handler {
+	return serveRoutes(configProvider, routes.GetFUPAdminRoutes())
+}
+
+// RegisterRoutes mounts the auth routes of the given configuration instance on mux (see Routes)
+func RegisterRoutes(mux *http.ServeMux, configProvider *config.Provider) {
+	handler := Routes(configProvider)
+	mux.Handle("/authenticate", handler)
+	if configProvider.IsUserRegistrationEnabled() {
+		mux.Handle("/registration/", handler)
+		mux.Handle("/resetting/", handler)
+	}
+	if configProvider.IsOneOffTokenModeEnabled() {
+		mux.Handle("/token/generate", handler)
+	}
+	if configProvider.IsClientFUPEnabled() || configProvider.IsUserFUPEnabled() {
+		mux.Handle("/fup/usage", handler)
+	}
+}
+
+// GetApiClient returns the authenticated api client or nil if the request is not authenticated
+func GetApiClient(ctx context.Context) contract.ApiClientInterface {
+	return contract.ApiClientFromContext(ctx)
+}
+
+// GetApiUser returns the authenticated api user or nil if the request is not made on behalf of a user
+func GetApiUser(ctx context.Context) contract.ApiUserInterface {
+	return contract.ApiUserFromContext(ctx)
+}
+
+// GetTenantId returns the tenant (organisation) id of the request or an empty string if the client/user doesn't belong to an organisation
+func GetTenantId(ctx context.Context) string {
+	return contract.TenantIdFromContext(ctx)
+}
+
+// GetEffectiveScope returns the effective access scope of the request or nil if the scope access model is disabled
+func GetEffectiveScope(ctx context.Context) *contract.AccessScope {
+	return contract.EffectiveScopeFromContext(ctx)
+}
+
+// GetPrincipal returns the principal of the request (client, user, authentication method, ...) or nil if the request is not authenticated
+func GetPrincipal(ctx context.Context) *contract.Principal {
+	return contract.PrincipalFromContext(ctx)
+}
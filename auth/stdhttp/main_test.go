@@ -0,0 +1,189 @@
+package stdhttp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/wernerdweight/api-auth-go/v2/auth"
+	"github.com/wernerdweight/api-auth-go/v2/auth/cache"
+	"github.com/wernerdweight/api-auth-go/v2/auth/constants"
+	"github.com/wernerdweight/api-auth-go/v2/auth/contract"
+	"github.com/wernerdweight/api-auth-go/v2/auth/entity"
+	"github.com/wernerdweight/api-auth-go/v2/auth/provider"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newRequest(method string, path string, withCredentials bool) *http.Request {
+	request := httptest.NewRequest(method, path, nil)
+	if withCredentials {
+		request.Header.Set(constants.ClientIdHeader, "client")
+		request.Header.Set(constants.ClientSecretHeader, "secret")
+	}
+	return request
+}
+
+func TestMiddleware(t *testing.T) {
+	configProvider := auth.New(contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{
+				{Id: "client", Secret: "secret", OrganisationId: "tenant"},
+			}),
+		},
+	})
+	handler := Middleware(configProvider)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiClient := GetApiClient(r.Context())
+		assert.NotNil(t, apiClient)
+		assert.Nil(t, GetApiUser(r.Context()))
+		assert.Equal(t, "tenant", GetTenantId(r.Context()))
+		assert.Equal(t, apiClient, GetPrincipal(r.Context()).ApiClient)
+		_, _ = w.Write([]byte(apiClient.GetClientId()))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest(http.MethodGet, "/orders", true))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "client", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest(http.MethodGet, "/orders", false))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), contract.AuthErrorCodes[contract.NoCredentialsProvided])
+}
+
+func TestMiddleware_NotFound(t *testing.T) {
+	configProvider := auth.New(contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{{Id: "client", Secret: "secret"}}),
+		},
+	})
+	handler := Middleware(configProvider)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	// the response of the handler is passed through as is
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest(http.MethodGet, "/orders/42", true))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "", recorder.Body.String())
+}
+
+func TestFUPAdminRoutes(t *testing.T) {
+	configProvider := auth.New(contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{
+				{Id: "client", Secret: "secret", FUPScope: &contract.FUPScope{"*": map[string]any{"daily": 10}}},
+			}),
+		},
+		Cache: &contract.CacheConfig{Driver: cache.NewMemoryCacheDriver()},
+	})
+	handler := FUPAdminRoutes(configProvider)
+
+	// the route parameters are passed to the handler
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest(http.MethodGet, "/fup/admin/client/client", true))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "[]", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest(http.MethodGet, "/fup/admin/unknown/client", true))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// unknown routes are not authenticated
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest(http.MethodGet, "/fup/admin/client", false))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	configProvider := auth.New(contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{{Id: "client", Secret: "secret"}}),
+		},
+	})
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, configProvider)
+
+	// the user credentials are missing
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, newRequest(http.MethodPost, "/authenticate", true))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), contract.AuthErrorCodes[contract.Unauthorized])
+
+	// registration is disabled
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, newRequest(http.MethodPost, "/registration/request", true))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestGetApiClient(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Nil(t, GetApiClient(request.Context()))
+	assert.Nil(t, GetApiUser(request.Context()))
+	assert.Equal(t, "", GetTenantId(request.Context()))
+	assert.Nil(t, GetEffectiveScope(request.Context()))
+	assert.Nil(t, GetPrincipal(request.Context()))
+}
+
+func TestGetPrincipal(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	enabled := true
+	configProvider := auth.New(contract.Config{
+		Client: contract.ClientConfig{
+			Provider: provider.NewMemoryApiClientProvider([]entity.MemoryApiClient{
+				{
+					Id:             "client",
+					Secret:         "secret",
+					ApiKey:         "key",
+					AdditionalKeys: []entity.MemoryApiClientKey{{Key: "additional-key", ExpirationDate: &expiresAt}},
+				},
+			}),
+		},
+		Mode: &contract.ModesConfig{ApiKey: &enabled, AdditionalApiKeys: &enabled},
+	})
+	var principal *contract.Principal
+	handler := Middleware(configProvider)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = GetPrincipal(r.Context())
+	}))
+
+	tests := []struct {
+		name      string
+		header    map[string]string
+		method    constants.AuthenticationMethod
+		expiresAt *time.Time
+	}{
+		{
+			name:   "Client id and secret",
+			header: map[string]string{constants.ClientIdHeader: "client", constants.ClientSecretHeader: "secret"},
+			method: constants.AuthenticationMethodClientSecret,
+		},
+		{
+			name:   "Api key",
+			header: map[string]string{constants.ApiKeyHeader: "key"},
+			method: constants.AuthenticationMethodApiKey,
+		},
+		{
+			name:      "Additional api key",
+			header:    map[string]string{constants.ApiKeyHeader: "additional-key"},
+			method:    constants.AuthenticationMethodAdditionalApiKey,
+			expiresAt: &expiresAt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			for key, value := range tt.header {
+				request.Header.Set(key, value)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.NotNil(t, principal)
+			assert.Equal(t, "client", principal.ApiClient.GetClientId())
+			assert.Nil(t, principal.ApiUser)
+			assert.Equal(t, tt.method, principal.Method)
+			assert.Equal(t, tt.expiresAt, principal.ExpiresAt)
+		})
+	}
+}